@@ -1,6 +1,6 @@
 module github.com/comap-smart-home/mynewt-newtmgr
 
-go 1.12
+go 1.13
 
 require (
 	github.com/JuulLabs-OSS/ble v0.0.0-20200716215611-d4fcc9d598bb
@@ -24,5 +24,6 @@ require (
 	github.com/ugorji/go/codec v1.2.10
 	golang.org/x/net v0.17.0
 	gopkg.in/cheggaaa/pb.v1 v1.0.28
+	gopkg.in/yaml.v3 v3.0.1
 	mynewt.apache.org/newt v0.0.0-20230307214303-0b46ad464e7a
 )
@@ -58,6 +58,8 @@ type BllSesn struct {
 	nmpRspChr *ble.Characteristic
 	resReqChr *ble.Characteristic
 	resRspChr *ble.Characteristic
+
+	timer sesn.ConnTimer
 }
 
 func NewBllSesn(cfg BllSesnCfg) *BllSesn {
@@ -283,16 +285,26 @@ func (s *BllSesn) openOnce() (bool, error) {
 	if err != nil {
 		return false, err
 	}
+	txvr.SetOmpRes(s.cfg.OmpRes)
+	txvr.SetNmpAuth(s.cfg.NmpAuth)
 	s.txvr = txvr
 
-	if err := s.connect(); err != nil {
+	// Connecting includes scanning for the peer.
+	start := time.Now()
+	err = s.connect()
+	s.timer.Add(sesn.CONN_PHASE_CONNECT, start)
+	if err != nil {
 		return false, err
 	}
 
-	if err := s.exchangeMtu(); err != nil {
+	start = time.Now()
+	err = s.exchangeMtu()
+	s.timer.Add(sesn.CONN_PHASE_MTU, start)
+	if err != nil {
 		return true, err
 	}
 
+	start = time.Now()
 	if err := s.discoverAll(); err != nil {
 		return false, err
 	}
@@ -300,6 +312,7 @@ func (s *BllSesn) openOnce() (bool, error) {
 	if err := s.subscribe(); err != nil {
 		return false, err
 	}
+	s.timer.Add(sesn.CONN_PHASE_DISCOVER, start)
 
 	return false, nil
 }
@@ -307,9 +320,15 @@ func (s *BllSesn) openOnce() (bool, error) {
 func (s *BllSesn) Open() error {
 	var err error
 
+	s.timer.Reset()
+
 	for i := 0; i < s.cfg.ConnTries; i++ {
 		var retry bool
 
+		if i > 0 {
+			s.cfg.ConnBackoff.Wait(i - 1)
+		}
+
 		retry, err = s.openOnce()
 		if err != nil {
 			// Ensure the session is closed.
@@ -391,7 +410,12 @@ func (s *BllSesn) TxRxMgmt(m *nmp.NmpMsg,
 		return s.txWriteCharacteristic(s.nmpReqChr, b, true)
 	}
 
-	return s.txvr.TxRxMgmt(txRaw, m, s.MtuOut(), timeout)
+	start := time.Now()
+	rsp, err := s.txvr.TxRxMgmt(txRaw, m, s.MtuOut(), timeout)
+	if err == nil {
+		s.timer.RspRcvd(start)
+	}
+	return rsp, err
 }
 
 func (s *BllSesn) TxRxMgmtAsync(m *nmp.NmpMsg,
@@ -435,6 +459,22 @@ func (s *BllSesn) MgmtProto() sesn.MgmtProto {
 	return s.cfg.MgmtProto
 }
 
+func (s *BllSesn) OmpRes() string {
+	return s.cfg.OmpRes
+}
+
+func (s *BllSesn) Tag() string {
+	return s.cfg.Tag
+}
+
+func (s *BllSesn) ConnectTimings() sesn.ConnTimings {
+	return s.timer.Timings()
+}
+
+func (s *BllSesn) NmpAuth() *nmp.NmpAuth {
+	return s.cfg.NmpAuth
+}
+
 func (s *BllSesn) CoapIsTcp() bool {
 	return true
 }
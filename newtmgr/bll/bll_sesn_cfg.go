@@ -28,6 +28,7 @@ import (
 	"github.com/JuulLabs-OSS/ble"
 
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmcoap"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
 )
 
@@ -37,9 +38,16 @@ type BllSesnCfg struct {
 	PreferredMtu uint16
 	ConnTimeout  time.Duration
 	ConnTries    int
+	ConnBackoff  sesn.BackoffCfg
 	WriteRsp     bool
 	TxFilter     nmcoap.TxMsgFilter
 	RxFilter     nmcoap.RxMsgFilter
+	OmpRes       string
+	NmpAuth      *nmp.NmpAuth
+
+	// Caller-assigned label included in log messages about the session's
+	// traffic.
+	Tag string
 }
 
 func NewBllSesnCfg() BllSesnCfg {
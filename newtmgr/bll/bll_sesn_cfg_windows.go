@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmcoap"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
 )
 
@@ -35,6 +36,12 @@ type BllSesnCfg struct {
 	ConnTimeout  time.Duration
 	TxFilter     nmcoap.TxMsgFilter
 	RxFilter     nmcoap.RxMsgFilter
+	OmpRes       string
+	NmpAuth      *nmp.NmpAuth
+
+	// Caller-assigned label included in log messages about the session's
+	// traffic.
+	Tag string
 }
 
 func NewBllSesnCfg() BllSesnCfg {
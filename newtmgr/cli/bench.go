@@ -0,0 +1,272 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/newtmgr/config"
+	"github.com/comap-smart-home/mynewt-newtmgr/newtmgr/nmutil"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/mgmt"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/xact"
+	"mynewt.apache.org/newt/util"
+)
+
+var benchPayloadSize int
+var benchDuration float64
+var benchUploadPath string
+var benchUploadSize int
+var benchJson bool
+
+type benchEchoSummary struct {
+	Count       int     `json:"count"`
+	PayloadSize int     `json:"payload_size"`
+	WireSize    int     `json:"wire_size"`
+	MinMs       float64 `json:"min_ms"`
+	AvgMs       float64 `json:"avg_ms"`
+	MaxMs       float64 `json:"max_ms"`
+	GoodputBps  float64 `json:"goodput_bps"`
+	OverheadPct float64 `json:"overhead_pct"`
+}
+
+type benchUploadSummary struct {
+	Bytes       int     `json:"bytes"`
+	Requests    int     `json:"requests"`
+	Secs        float64 `json:"secs"`
+	GoodputBps  float64 `json:"goodput_bps"`
+	OverheadPct float64 `json:"overhead_pct"`
+}
+
+type benchSummary struct {
+	Transport string              `json:"transport"`
+	Mtu       int                 `json:"mtu"`
+	Echo      benchEchoSummary    `json:"echo"`
+	Upload    *benchUploadSummary `json:"upload,omitempty"`
+}
+
+func msecs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// benchEcho sends echo requests back to back for the configured duration and
+// measures round trip latency.  Goodput counts the payload in both
+// directions.
+func benchEcho(s sesn.Sesn) benchEchoSummary {
+	sum := benchEchoSummary{
+		PayloadSize: benchPayloadSize,
+	}
+
+	payload := strings.Repeat("a", benchPayloadSize)
+
+	r := nmp.NewEchoReq()
+	r.Payload = payload
+	enc, err := mgmt.EncodeMgmt(s, r.Msg())
+	if err != nil {
+		nmUsage(nil, util.ChildNewtError(err))
+	}
+	sum.WireSize = len(enc)
+	if sum.WireSize > s.MtuOut() {
+		nmUsage(nil, util.FmtNewtError(
+			"Echo payload too large; request is %d bytes, MTU is %d",
+			sum.WireSize, s.MtuOut()))
+	}
+	sum.OverheadPct = 100.0 * float64(sum.WireSize-benchPayloadSize) /
+		float64(sum.WireSize)
+
+	var min, max, total time.Duration
+	deadline := time.Now().Add(
+		time.Duration(benchDuration * float64(time.Second)))
+
+	for time.Now().Before(deadline) {
+		c := xact.NewEchoCmd()
+		c.SetTxOptions(nmutil.TxOptions())
+		c.Payload = payload
+
+		start := time.Now()
+		res, err := c.Run(s)
+		if err != nil {
+			nmUsage(nil, util.ChildNewtError(err))
+		}
+		if res.Status() != 0 {
+			nmUsage(nil, util.FmtNewtError("Echo failed; rc=%d",
+				res.Status()))
+		}
+		rtt := time.Since(start)
+
+		if sum.Count == 0 || rtt < min {
+			min = rtt
+		}
+		if rtt > max {
+			max = rtt
+		}
+		total += rtt
+		sum.Count++
+	}
+
+	if sum.Count > 0 {
+		sum.MinMs = msecs(min)
+		sum.MaxMs = msecs(max)
+		sum.AvgMs = msecs(total) / float64(sum.Count)
+		sum.GoodputBps = float64(2*benchPayloadSize*sum.Count) /
+			total.Seconds()
+	}
+
+	return sum
+}
+
+// benchUpload writes a synthetic file to the device and measures goodput.
+// Overhead is estimated from the average amount of file data carried by
+// each request relative to the MTU.
+func benchUpload(s sesn.Sesn) *benchUploadSummary {
+	data := make([]byte, benchUploadSize)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	c := xact.NewFsUploadCmd()
+	c.SetTxOptions(nmutil.TxOptions())
+	c.Name = benchUploadPath
+	c.Data = data
+
+	start := time.Now()
+	res, err := c.Run(s)
+	if err != nil {
+		nmUsage(nil, util.ChildNewtError(err))
+	}
+	elapsed := time.Since(start)
+
+	ures := res.(*xact.FsUploadResult)
+	if ures.Status() != 0 {
+		nmUsage(nil, util.FmtNewtError("Upload failed; rc=%d",
+			ures.Status()))
+	}
+
+	sum := &benchUploadSummary{
+		Bytes:    len(data),
+		Requests: len(ures.Rsps),
+		Secs:     elapsed.Seconds(),
+	}
+	if elapsed > 0 {
+		sum.GoodputBps = float64(len(data)) / elapsed.Seconds()
+	}
+	if sum.Requests > 0 {
+		perReq := float64(len(data)) / float64(sum.Requests)
+		sum.OverheadPct = 100.0 * (1.0 - perReq/float64(s.MtuOut()))
+	}
+
+	return sum
+}
+
+func benchPrint(sum benchSummary) {
+	fmt.Printf("transport:          %s\n", sum.Transport)
+	fmt.Printf("mtu:                %d\n", sum.Mtu)
+	fmt.Printf("echo count:         %d\n", sum.Echo.Count)
+	fmt.Printf("echo payload:       %d bytes (%d on wire)\n",
+		sum.Echo.PayloadSize, sum.Echo.WireSize)
+	fmt.Printf("echo latency:       min=%.1fms avg=%.1fms max=%.1fms\n",
+		sum.Echo.MinMs, sum.Echo.AvgMs, sum.Echo.MaxMs)
+	fmt.Printf("echo goodput:       %.0f B/s\n", sum.Echo.GoodputBps)
+	fmt.Printf("echo overhead:      %.1f%%\n", sum.Echo.OverheadPct)
+
+	if sum.Upload != nil {
+		fmt.Printf("upload size:        %d bytes in %d requests\n",
+			sum.Upload.Bytes, sum.Upload.Requests)
+		fmt.Printf("upload time:        %.2fs\n", sum.Upload.Secs)
+		fmt.Printf("upload goodput:     %.0f B/s\n", sum.Upload.GoodputBps)
+		fmt.Printf("upload overhead:    %.1f%% (approx)\n",
+			sum.Upload.OverheadPct)
+	}
+}
+
+func benchRunCmd(cmd *cobra.Command, args []string) {
+	if benchPayloadSize < 0 || benchDuration <= 0 || benchUploadSize <= 0 {
+		nmUsage(cmd, util.NewNewtError("Invalid benchmark parameters"))
+	}
+
+	cp, err := getConnProfile()
+	if err != nil {
+		nmUsage(nil, err)
+	}
+
+	s, err := GetSesn()
+	if err != nil {
+		nmUsage(nil, err)
+	}
+
+	sum := benchSummary{
+		Transport: config.ConnTypeToString(cp.Type),
+		Mtu:       s.MtuOut(),
+	}
+
+	sum.Echo = benchEcho(s)
+	if benchUploadPath != "" {
+		sum.Upload = benchUpload(s)
+	}
+
+	if benchJson {
+		j, err := json.MarshalIndent(sum, "", "    ")
+		if err != nil {
+			nmUsage(nil, util.ChildNewtError(err))
+		}
+		fmt.Printf("%s\n", j)
+		return
+	}
+
+	benchPrint(sum)
+}
+
+func benchCmd() *cobra.Command {
+	benchEx := "  " + nmutil.ToolInfo.ExeName + " -c olimex bench\n"
+	benchEx += "  " + nmutil.ToolInfo.ExeName +
+		" -c olimex bench --size 128 --duration 10 --upload-path /tmp/bench\n"
+
+	benchCmd := &cobra.Command{
+		Use:   "bench -c <conn_profile>",
+		Short: "Measure transport latency and throughput",
+		Long: "Measure echo round trip latency and goodput for the " +
+			"configured duration.  If --upload-path is given, a synthetic " +
+			"file is also uploaded to that path to measure upload " +
+			"throughput; the file is overwritten, so use a scratch " +
+			"location.",
+		Example: benchEx,
+		Run:     benchRunCmd,
+	}
+
+	benchCmd.PersistentFlags().IntVar(&benchPayloadSize, "size", 64,
+		"Echo payload size in bytes")
+	benchCmd.PersistentFlags().Float64Var(&benchDuration, "duration", 5.0,
+		"Echo test duration in seconds")
+	benchCmd.PersistentFlags().StringVar(&benchUploadPath, "upload-path", "",
+		"Remote file to write during the upload test; the test is skipped "+
+			"if unset")
+	benchCmd.PersistentFlags().IntVar(&benchUploadSize, "upload-size",
+		32*1024, "Number of bytes to upload")
+	benchCmd.PersistentFlags().BoolVarP(&benchJson, "json", "j", false,
+		"Print the summary as JSON")
+
+	return benchCmd
+}
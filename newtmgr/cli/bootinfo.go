@@ -0,0 +1,138 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/newtmgr/nmutil"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/xact"
+	"mynewt.apache.org/newt/util"
+)
+
+var bootInfoJson bool
+var bootInfoResetCount bool
+var bootInfoReasonVar string
+var bootInfoCountVar string
+
+func bootInfoResetCountCmd() {
+	ConfirmOrAbort("Reset the device's boot counter?")
+
+	s, err := GetSesn()
+	if err != nil {
+		nmUsage(nil, err)
+	}
+
+	c := xact.NewBootCountResetCmd()
+	c.SetTxOptions(nmutil.TxOptions())
+	c.CountVar = bootInfoCountVar
+
+	res, err := c.Run(s)
+	if err != nil {
+		nmUsage(nil, util.ChildNewtError(err))
+	}
+
+	rc := res.Status()
+	switch rc {
+	case 0:
+		fmt.Printf("Boot counter reset\n")
+	case nmp.NMP_ERR_ENOENT, nmp.NMP_ERR_EINVAL, nmp.NMP_ERR_ENOTSUP:
+		fmt.Printf("This device does not expose a boot counter (%s)\n",
+			bootInfoCountVar)
+	default:
+		fmt.Printf("Error: %d\n", rc)
+	}
+}
+
+func bootInfoRunCmd(cmd *cobra.Command, args []string) {
+	if bootInfoResetCount {
+		bootInfoResetCountCmd()
+		return
+	}
+
+	s, err := GetSesn()
+	if err != nil {
+		nmUsage(nil, err)
+	}
+
+	c := xact.NewBootStatsReadCmd()
+	c.SetTxOptions(nmutil.TxOptions())
+	c.ReasonVar = bootInfoReasonVar
+	c.CountVar = bootInfoCountVar
+
+	res, err := c.Run(s)
+	if err != nil {
+		nmUsage(nil, util.ChildNewtError(err))
+	}
+
+	bres := res.(*xact.BootStatsReadResult)
+	if bres.Rc != 0 {
+		fmt.Printf("Error: %d\n", bres.Rc)
+		return
+	}
+
+	if bootInfoJson {
+		b, err := json.MarshalIndent(bres.Stats, "", "    ")
+		if err != nil {
+			nmUsage(nil, util.ChildNewtError(err))
+		}
+		fmt.Printf("%s\n", string(b))
+		return
+	}
+
+	if bres.Stats.ResetReason != nil {
+		fmt.Printf("reset reason: %s\n", *bres.Stats.ResetReason)
+	} else {
+		fmt.Printf("reset reason: unavailable\n")
+	}
+	if bres.Stats.BootCount != nil {
+		fmt.Printf("  boot count: %d\n", *bres.Stats.BootCount)
+	} else {
+		fmt.Printf("  boot count: unavailable\n")
+	}
+}
+
+func bootInfoCmd() *cobra.Command {
+	bootInfoCmd := &cobra.Command{
+		Use:   "bootinfo -c <conn_profile>",
+		Short: "Show why a device last reset and how many times it has booted",
+		Long: "Read the reset reason and boot counter from the device's " +
+			"config variables.  Values the firmware doesn't expose are " +
+			"reported as unavailable.",
+		Run: bootInfoRunCmd,
+	}
+
+	bootInfoCmd.PersistentFlags().BoolVarP(&bootInfoJson, "json", "j", false,
+		"Print the values as JSON; absent values are null")
+	bootInfoCmd.PersistentFlags().BoolVar(&bootInfoResetCount, "reset-count",
+		false, "Reset the boot counter to zero instead of reading")
+	bootInfoCmd.PersistentFlags().StringVar(&bootInfoReasonVar, "reason-var",
+		xact.BOOT_STATS_DFLT_REASON_VAR,
+		"Config variable holding the reset reason")
+	bootInfoCmd.PersistentFlags().StringVar(&bootInfoCountVar, "count-var",
+		xact.BOOT_STATS_DFLT_COUNT_VAR,
+		"Config variable holding the boot counter")
+
+	return bootInfoCmd
+}
@@ -0,0 +1,115 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/newtmgr/nmutil"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/xact"
+	"mynewt.apache.org/newt/util"
+)
+
+var capsJson bool
+
+// Capability set of the device at the other end of the global session.  This
+// is populated on first use so that subsequent queries (e.g., in interactive
+// mode) don't need to probe the device again.
+var globalCaps *xact.CapsResult
+
+func getCaps(s sesn.Sesn) (*xact.CapsResult, error) {
+	if globalCaps != nil {
+		return globalCaps, nil
+	}
+
+	c := xact.NewCapsCmd()
+	c.SetTxOptions(nmutil.TxOptions())
+
+	res, err := c.Run(s)
+	if err != nil {
+		return nil, util.ChildNewtError(err)
+	}
+
+	globalCaps = res.(*xact.CapsResult)
+	return globalCaps, nil
+}
+
+func capsPrintTable(caps *xact.CapsResult) {
+	fmt.Printf("%-16s %7s %4s %11s %s\n",
+		"[command]", "[group]", "[id]", "[supported]", "[rc]")
+
+	for _, e := range caps.Entries {
+		rcText := "no response"
+		if e.Rc >= 0 {
+			rcText = fmt.Sprintf("%d", e.Rc)
+		}
+
+		fmt.Printf("%-16s %7d %4d %11v %s\n",
+			e.Name, e.Group, e.Id, e.Supported, rcText)
+	}
+}
+
+func capsRunCmd(cmd *cobra.Command, args []string) {
+	s, err := GetSesn()
+	if err != nil {
+		nmUsage(nil, err)
+	}
+
+	caps, err := getCaps(s)
+	if err != nil {
+		nmUsage(nil, err)
+	}
+
+	if capsJson {
+		j, err := json.MarshalIndent(caps.Entries, "", "    ")
+		if err != nil {
+			nmUsage(nil, util.ChildNewtError(err))
+		}
+		fmt.Printf("%s\n", j)
+	} else {
+		capsPrintTable(caps)
+	}
+}
+
+func capsCmd() *cobra.Command {
+	capsHelpText := "Determine which management commands a device supports.\n\n"
+	capsHelpText += "Each command group is probed with a side-effect free " +
+		"request.  A command is\nreported as unsupported if the device " +
+		"rejects it or does not respond.\n"
+
+	capsEx := "  " + nmutil.ToolInfo.ExeName + " caps -c olimex\n"
+	capsEx += "  " + nmutil.ToolInfo.ExeName + " caps --json -c olimex\n"
+
+	capsCmd := &cobra.Command{
+		Use:     "caps -c <conn_profile>",
+		Short:   "Read the management capability set of a device",
+		Long:    capsHelpText,
+		Example: capsEx,
+		Run:     capsRunCmd,
+	}
+	capsCmd.PersistentFlags().BoolVarP(&capsJson, "json", "j", false,
+		"Print the capability set as JSON")
+
+	return capsCmd
+}
@@ -21,13 +21,17 @@ package cli
 
 import (
 	"fmt"
+	"os"
 	"runtime"
+	"strconv"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
 	"github.com/comap-smart-home/mynewt-newtmgr/newtmgr/nmutil"
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmxutil"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/omp"
 	"mynewt.apache.org/newt/util"
 )
 
@@ -36,6 +40,9 @@ var NewtmgrHelp bool
 
 func Commands() *cobra.Command {
 	logLevelStr := ""
+	nmpFlagsStr := ""
+	outFile := ""
+	var closeOut func() error
 	nmCmd := &cobra.Command{
 		Use:   nmutil.ToolInfo.ExeName,
 		Short: nmutil.ToolInfo.ShortName + " helps you manage remote devices",
@@ -52,9 +59,42 @@ func Commands() *cobra.Command {
 			}
 			nmxutil.SetLogLevel(NewtmgrLogLevel)
 
+			if err := omp.ValidateResPath(nmxutil.OmpRes); err != nil {
+				nmUsage(nil, util.ChildNewtError(err))
+			}
+
+			if nmpFlagsStr != "" {
+				flags, err := strconv.ParseUint(
+					strings.TrimPrefix(strings.ToLower(nmpFlagsStr), "0x"),
+					16, 8)
+				if err != nil {
+					nmUsage(nil, util.FmtNewtError(
+						"Invalid --nmp-flags value: %s", nmpFlagsStr))
+				}
+				nmutil.NmpFlags = uint8(flags)
+			}
+
+			if err := validateProgressStyle(nmutil.Progress); err != nil {
+				nmUsage(nil, err)
+			}
+
+			if outFile != "" {
+				closeOut, err = redirectOutput(outFile)
+				if err != nil {
+					nmUsage(nil, err)
+				}
+			}
+
 			// Set cbgo log level if we're using macOS.
 			OSSpecificInit()
 		},
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {
+			if closeOut != nil {
+				if err := closeOut(); err != nil {
+					nmUsage(nil, err)
+				}
+			}
+		},
 		Run: func(cmd *cobra.Command, args []string) {
 			cmd.HelpFunc()(cmd, args)
 		},
@@ -71,15 +111,67 @@ func Commands() *cobra.Command {
 	nmCmd.PersistentFlags().Float64VarP(&nmutil.Timeout, "timeout", "t", 10.0,
 		"timeout in seconds (partial seconds allowed)")
 
+	nmCmd.PersistentFlags().Float64Var(&nmutil.ConnTimeout,
+		"connect-timeout", 0,
+		"connection establishment timeout in seconds; defaults to --timeout")
+
+	nmCmd.PersistentFlags().Float64Var(&nmutil.DeviceTimeout,
+		"device-timeout", 0,
+		"per-request response timeout in seconds; defaults to --timeout")
+
+	nmCmd.PersistentFlags().StringVar(&nmutil.AuthAlg, "auth-alg", "",
+		"sign NMP requests with this algorithm (hmac-sha256 or ecdsa-p256)")
+	nmCmd.PersistentFlags().StringVar(&nmutil.AuthKeyFile, "auth-key", "",
+		"signing key file: raw secret for hmac-sha256, PEM private key "+
+			"for ecdsa-p256")
+	nmCmd.PersistentFlags().IntVar(&nmutil.AuthKeyId, "auth-key-id", 0,
+		"key ID placed in the authentication trailer")
+
+	nmCmd.PersistentFlags().BoolVarP(&nmutil.AssumeYes, "yes", "y", false,
+		"answer yes to all confirmation prompts; implied when stdin is "+
+			"not a terminal")
+	nmCmd.PersistentFlags().BoolVar(&nmutil.AssumeYes, "assume-yes", false,
+		"same as --yes")
+
+	nmCmd.PersistentFlags().StringVar(&nmpFlagsStr, "nmp-flags", "",
+		"advanced: hex NMP header flag bits to set on outgoing requests "+
+			"(e.g., 0x10); for experimental features only")
+
+	nmCmd.PersistentFlags().StringVar(&outFile, "out", "",
+		"write the command's output to this file instead of stdout; "+
+			"prompts and progress are shown on stderr")
+
+	nmCmd.PersistentFlags().StringVar(&nmutil.Progress, "progress",
+		PROGRESS_STYLE_BAR, "how to report progress of long operations: "+
+			"bar, json (one event per line), or none")
+
 	nmCmd.PersistentFlags().IntVarP(&nmutil.Tries, "tries", "r", 1,
 		"total number of tries in case of timeout")
 
+	nmCmd.PersistentFlags().IntVar(&nmutil.StartTries, "start-tries", 1,
+		"number of attempts to open the transport when it fails "+
+			"transiently (e.g., port busy)")
+
 	nmCmd.PersistentFlags().StringVarP(&logLevelStr, "loglevel", "l", "info",
 		"log level to use")
 
 	nmCmd.PersistentFlags().StringVar(&nmutil.DeviceName, "name",
 		"", "name of target BLE device; overrides profile setting")
 
+	nmCmd.PersistentFlags().BoolVar(&nmutil.QueryMtu, "query-mtu", false,
+		"ask the device for its preferred message size when connecting and "+
+			"fragment requests accordingly")
+
+	nmCmd.PersistentFlags().IntVar(&nmutil.UdpDscp, "udp-dscp", 0,
+		"DSCP (0-63) to mark outgoing UDP datagrams with")
+	nmCmd.PersistentFlags().StringVar(&nmutil.UdpProxy, "udp-proxy", "",
+		"relay UDP traffic through a SOCKS5 proxy "+
+			"(socks5://[user:pass@]host:port)")
+
+	nmCmd.PersistentFlags().BoolVar(&nmutil.SerialSniff, "serial-sniff",
+		false, "hex-dump all raw bytes received on a serial connection to "+
+			"stderr, before framing")
+
 	nmCmd.PersistentFlags().BoolVar(&nmutil.BleWriteRsp, "write-rsp", false,
 		"Send BLE acked write requests instead of unacked write commands")
 
@@ -111,6 +203,7 @@ func Commands() *cobra.Command {
 	nmCmd.PersistentFlags().IntVarP(&nmutil.HciIdx, "hci", "i",
 		0, "HCI index for the controller on Linux machine")
 
+	nmCmd.AddCommand(capsCmd())
 	nmCmd.AddCommand(crashCmd())
 	nmCmd.AddCommand(dateTimeCmd())
 	nmCmd.AddCommand(fsCmd())
@@ -118,15 +211,34 @@ func Commands() *cobra.Command {
 	nmCmd.AddCommand(logCmd())
 	nmCmd.AddCommand(mempoolStatCmd())
 	nmCmd.AddCommand(resetCmd())
+	nmCmd.AddCommand(factoryResetCmd())
 	nmCmd.AddCommand(runCmd())
 	nmCmd.AddCommand(statsCmd())
 	nmCmd.AddCommand(taskStatCmd())
 	nmCmd.AddCommand(configCmd())
+	nmCmd.AddCommand(syscfgCmd())
+	nmCmd.AddCommand(nameCmd())
+	nmCmd.AddCommand(hwIdCmd())
+	nmCmd.AddCommand(infoCmd())
 	nmCmd.AddCommand(connProfileCmd())
 	nmCmd.AddCommand(echoCmd())
+	nmCmd.AddCommand(pingCmd())
+	nmCmd.AddCommand(benchCmd())
+	nmCmd.AddCommand(waitCmd())
+	nmCmd.AddCommand(updateCmd())
+	nmCmd.AddCommand(sequenceCmd())
+	nmCmd.AddCommand(bootInfoCmd())
+	nmCmd.AddCommand(peekCmd())
+	nmCmd.AddCommand(pokeCmd())
 	nmCmd.AddCommand(resCmd())
 	nmCmd.AddCommand(interactiveCmd())
 	nmCmd.AddCommand(shellCmd())
 
+	nmCmd.PersistentFlags().StringVar(&cmdManifestPath, "cmd-manifest", "",
+		"YAML manifest describing user-defined command groups")
+	if err := addManifestCmds(nmCmd, os.Args[1:]); err != nil {
+		nmUsage(nil, err)
+	}
+
 	return nmCmd
 }
@@ -20,7 +20,17 @@
 package cli
 
 import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
@@ -30,6 +40,7 @@ import (
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/mtech_lora"
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmble"
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmcoap"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmserial"
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/udp"
@@ -104,6 +115,19 @@ func getConnProfile() (*config.ConnProfile, error) {
 	return globalP, nil
 }
 
+// Governs retries of transport start and session build failures
+// (--start-tries).
+func xportRetryCfg() xport.RetryCfg {
+	return xport.RetryCfg{
+		Tries: nmutil.StartTries,
+		Backoff: sesn.BackoffCfg{
+			Base:   500 * time.Millisecond,
+			Max:    5 * time.Second,
+			Jitter: sesn.JITTER_EQUAL,
+		},
+	}
+}
+
 func GetXport() (xport.Xport, error) {
 	if globalXport != nil {
 		return globalXport, nil
@@ -121,6 +145,12 @@ func GetXport() (xport.Xport, error) {
 			return nil, err
 		}
 
+		if nmutil.SerialSniff {
+			sc.RxTap = func(data []byte) {
+				fmt.Fprintf(os.Stderr, "Serial rx (raw):\n%s", hex.Dump(data))
+			}
+		}
+
 		globalXport = nmserial.NewSerialXport(sc)
 
 	case config.CONN_TYPE_BLL_PLAIN, config.CONN_TYPE_BLL_OIC:
@@ -160,7 +190,7 @@ func GetXport() (xport.Xport, error) {
 
 	globalXportSet = true
 
-	if err := globalXport.Start(); err != nil {
+	if err := xport.StartWithRetry(globalXport, xportRetryCfg()); err != nil {
 		return nil, util.ChildNewtError(err)
 	}
 
@@ -175,6 +205,74 @@ func GetXportIfOpen() (xport.Xport, error) {
 	return globalXport, nil
 }
 
+// buildNmpAuth creates the request signing configuration specified on the
+// command line.  nil is returned if signing was not requested.
+func buildNmpAuth() (*nmp.NmpAuth, error) {
+	if nmutil.AuthAlg == "" {
+		if nmutil.AuthKeyFile != "" {
+			return nil, util.NewNewtError("--auth-key requires --auth-alg")
+		}
+		return nil, nil
+	}
+
+	alg, err := nmp.NmpAuthAlgFromString(nmutil.AuthAlg)
+	if err != nil {
+		return nil, util.ChildNewtError(err)
+	}
+	if alg == nmp.NMP_AUTH_ALG_NONE {
+		return nil, nil
+	}
+
+	if nmutil.AuthKeyId < 0 || nmutil.AuthKeyId > 255 {
+		return nil, util.FmtNewtError("Invalid auth key ID: %d",
+			nmutil.AuthKeyId)
+	}
+	if nmutil.AuthKeyFile == "" {
+		return nil, util.NewNewtError("--auth-alg requires --auth-key")
+	}
+
+	data, err := ioutil.ReadFile(nmutil.AuthKeyFile)
+	if err != nil {
+		return nil, util.ChildNewtError(err)
+	}
+
+	auth := &nmp.NmpAuth{
+		Alg:   alg,
+		KeyId: uint8(nmutil.AuthKeyId),
+	}
+
+	switch alg {
+	case nmp.NMP_AUTH_ALG_HMAC_SHA256:
+		auth.HmacKey = data
+
+	case nmp.NMP_AUTH_ALG_ECDSA_P256:
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, util.FmtNewtError("%s does not contain a PEM key",
+				nmutil.AuthKeyFile)
+		}
+
+		key, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			k, err2 := x509.ParsePKCS8PrivateKey(block.Bytes)
+			if err2 != nil {
+				return nil, util.FmtNewtError(
+					"Cannot parse ECDSA key in %s: %s",
+					nmutil.AuthKeyFile, err.Error())
+			}
+			var ok bool
+			key, ok = k.(*ecdsa.PrivateKey)
+			if !ok {
+				return nil, util.FmtNewtError("%s is not an ECDSA key",
+					nmutil.AuthKeyFile)
+			}
+		}
+		auth.EcdsaKey = key
+	}
+
+	return auth, nil
+}
+
 func buildSesnCfg() (sesn.SesnCfg, error) {
 	sc := sesn.NewSesnCfg()
 
@@ -183,6 +281,16 @@ func buildSesnCfg() (sesn.SesnCfg, error) {
 		return sc, err
 	}
 
+	sc.NmpAuth, err = buildNmpAuth()
+	if err != nil {
+		return sc, err
+	}
+
+	// An explicit MTU override takes precedence over the device's preference.
+	if nmutil.QueryMtu && nmutil.MtuOverride == 0 {
+		sc.MtuQueryTimeout = nmutil.TxOptions().Timeout
+	}
+
 	switch cp.Type {
 	case config.CONN_TYPE_SERIAL_PLAIN:
 		sc.MgmtProto = sesn.MGMT_PROTO_NMP
@@ -233,12 +341,16 @@ func buildSesnCfg() (sesn.SesnCfg, error) {
 	case config.CONN_TYPE_UDP_PLAIN:
 		sc.MgmtProto = sesn.MGMT_PROTO_NMP
 		sc.PeerSpec.Udp = cp.ConnString
+		sc.Udp.Dscp = nmutil.UdpDscp
+		sc.Udp.Proxy = nmutil.UdpProxy
 
 		return sc, nil
 
 	case config.CONN_TYPE_UDP_OIC:
 		sc.MgmtProto = sesn.MGMT_PROTO_OMP
 		sc.PeerSpec.Udp = cp.ConnString
+		sc.Udp.Dscp = nmutil.UdpDscp
+		sc.Udp.Proxy = nmutil.UdpProxy
 
 		return sc, nil
 
@@ -289,6 +401,11 @@ func buildBllSesn(cp *config.ConnProfile) (sesn.Sesn, error) {
 	sc.TxFilter = globalTxFilter
 	sc.RxFilter = globalRxFilter
 
+	sc.NmpAuth, err = buildNmpAuth()
+	if err != nil {
+		return nil, err
+	}
+
 	s, err := bx.BuildBllSesn(sc)
 	if err != nil {
 		return nil, util.ChildNewtError(err)
@@ -328,7 +445,7 @@ func GetSesn() (sesn.Sesn, error) {
 			return nil, err
 		}
 
-		s, err = x.BuildSesn(sc)
+		s, err = xport.BuildSesnWithRetry(x, sc, xportRetryCfg())
 		if err != nil {
 			return nil, util.ChildNewtError(err)
 		}
@@ -338,6 +455,8 @@ func GetSesn() (sesn.Sesn, error) {
 	if err := globalSesn.Open(); err != nil {
 		return nil, util.ChildNewtError(err)
 	}
+	log.Debugf("Session open timings: %s",
+		sesn.SesnConnectTimings(globalSesn))
 
 	return globalSesn, nil
 }
@@ -354,3 +473,72 @@ func SetFilters(txFilter nmcoap.TxMsgFilter, rxFilter nmcoap.RxMsgFilter) {
 	globalTxFilter = txFilter
 	globalRxFilter = rxFilter
 }
+
+func stdinIsTerminal() bool {
+	fi, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// Destination for prompts and progress indicators.  These must not end up in
+// the --out file, so they move to stderr when it is in effect.
+var statusOut io.Writer = os.Stdout
+
+// redirectOutput sends everything a command writes to stdout to the named
+// file instead.  The returned function flushes and closes the file and
+// restores the original stdout.
+func redirectOutput(path string) (func() error, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, util.ChildNewtError(err)
+	}
+
+	origStdout := os.Stdout
+	origStatusOut := statusOut
+
+	statusOut = os.Stderr
+	os.Stdout = f
+
+	return func() error {
+		os.Stdout = origStdout
+		statusOut = origStatusOut
+
+		if err := f.Sync(); err != nil {
+			f.Close()
+			return util.ChildNewtError(err)
+		}
+		if err := f.Close(); err != nil {
+			return util.ChildNewtError(err)
+		}
+		return nil
+	}, nil
+}
+
+// Writes prompt to w and reads the answer from r.  Anything other than "y"
+// or "yes" (case-insensitive) counts as a refusal.
+func confirm(r io.Reader, w io.Writer, prompt string) bool {
+	fmt.Fprintf(w, "%s [y/N] ", prompt)
+	line, _ := bufio.NewReader(r).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return true
+	}
+
+	return false
+}
+
+// ConfirmOrAbort asks the user to confirm a destructive operation and exits
+// if they decline.  No prompt is shown if --yes was specified or stdin is
+// not a terminal; the operation proceeds as if confirmed.
+func ConfirmOrAbort(prompt string) {
+	if nmutil.AssumeYes || !stdinIsTerminal() {
+		return
+	}
+
+	if !confirm(os.Stdin, statusOut, prompt) {
+		fmt.Fprintf(statusOut, "Aborted\n")
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,131 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/newtmgr/nmutil"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/xact"
+)
+
+func TestRedirectOutput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "newtmgr_out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "out.json")
+
+	// Capture stderr so the status output can be checked.
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	origStdout := os.Stdout
+	origStderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = origStderr }()
+
+	origProgress := nmutil.Progress
+	nmutil.Progress = PROGRESS_STYLE_JSON
+	defer func() { nmutil.Progress = origProgress }()
+
+	closeOut, err := redirectOutput(path)
+	if err != nil {
+		t.Fatalf("redirectOutput: %v", err)
+	}
+
+	render, done := newProgressRenderer()
+	render(xact.ProgressEvent{Phase: "upload", Current: 1, Total: 2})
+	done()
+
+	if err := json.NewEncoder(os.Stdout).Encode(
+		map[string]int{"rc": 0}); err != nil {
+
+		t.Fatal(err)
+	}
+
+	if err := closeOut(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	w.Close()
+
+	if os.Stdout != origStdout {
+		t.Errorf("stdout not restored")
+	}
+	if statusOut != origStdout {
+		t.Errorf("status output not restored")
+	}
+
+	out, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "{\"rc\":0}\n" {
+		t.Errorf("file contents = %q, want only the command's JSON", out)
+	}
+
+	status, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(status), "\"phase\":\"upload\"") {
+		t.Errorf("stderr = %q, want the progress event", status)
+	}
+	if strings.Contains(string(status), "rc") {
+		t.Errorf("stderr = %q, command output leaked", status)
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"y\n", true},
+		{"yes\n", true},
+		{"YES\n", true},
+		{"  Y  \n", true},
+		{"y", true},
+		{"n\n", false},
+		{"no\n", false},
+		{"yep\n", false},
+		{"\n", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		got := confirm(strings.NewReader(tt.input), &out, "Erase?")
+		if got != tt.want {
+			t.Errorf("input %q: confirm = %v, want %v", tt.input, got, tt.want)
+		}
+		if out.String() != "Erase? [y/N] " {
+			t.Errorf("input %q: prompt = %q", tt.input, out.String())
+		}
+	}
+}
@@ -20,16 +20,59 @@
 package cli
 
 import (
+	"encoding/hex"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/comap-smart-home/mynewt-newtmgr/newtmgr/nmutil"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/xact"
 	"mynewt.apache.org/newt/util"
 )
 
+var configValType string
+
+// configTypedVal converts a command-line value to the type selected with
+// --type.  A nil result means the value is sent as a plain string.
+func configTypedVal(val string) (interface{}, error) {
+	switch configValType {
+	case "str":
+		return nil, nil
+
+	case "int":
+		n, err := strconv.ParseInt(val, 0, 64)
+		if err != nil {
+			return nil, util.FmtNewtError("Invalid int value: %s", val)
+		}
+		return n, nil
+
+	case "bool":
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, util.FmtNewtError("Invalid bool value: %s", val)
+		}
+		return b, nil
+
+	case "bytes":
+		b, err := hex.DecodeString(strings.TrimPrefix(val, "0x"))
+		if err != nil {
+			return nil, util.FmtNewtError(
+				"Invalid bytes value (expected hex): %s", val)
+		}
+		return b, nil
+
+	default:
+		return nil, util.FmtNewtError(
+			"Invalid value type: %s (must be int, bool, str, or bytes)",
+			configValType)
+	}
+}
+
 func configRead(s sesn.Sesn, args []string) {
 	c := xact.NewConfigReadCmd()
 	c.SetTxOptions(nmutil.TxOptions())
@@ -54,6 +97,12 @@ func configWrite(s sesn.Sesn, args []string) {
 	c.Name = args[0]
 	c.Val = args[1]
 
+	tv, err := configTypedVal(args[1])
+	if err != nil {
+		nmUsage(nil, err)
+	}
+	c.TypedVal = tv
+
 	res, err := c.Run(s)
 	if err != nil {
 		nmUsage(nil, util.ChildNewtError(err))
@@ -85,13 +134,47 @@ func configSave(s sesn.Sesn, args []string) {
 	}
 }
 
+func configDump(s sesn.Sesn, args []string) {
+	c := xact.NewConfigDumpCmd()
+	c.SetTxOptions(nmutil.TxOptions())
+	c.Names = args
+
+	res, err := c.Run(s)
+	if err != nil {
+		nmUsage(nil, util.ChildNewtError(err))
+	}
+
+	sres := res.(*xact.ConfigDumpResult)
+	if sres.Rc == nmp.NMP_ERR_ENOTSUP {
+		fmt.Printf("Device does not support listing config values; " +
+			"specify the var-names to read\n")
+		return
+	}
+	if sres.Rc != 0 {
+		fmt.Printf("Error: %d\n", sres.Rc)
+		return
+	}
+
+	names := make([]string, 0, len(sres.Vals))
+	for name, _ := range sres.Vals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Printf("%s: %s\n", name, sres.Vals[name])
+	}
+}
+
 func configRunCmd(cmd *cobra.Command, args []string) {
 	s, err := GetSesn()
 	if err != nil {
 		nmUsage(nil, err)
 	}
 
-	if len(args) == 1 {
+	if len(args) >= 1 && args[0] == "dump" {
+		configDump(s, args[1:])
+	} else if len(args) == 1 {
 		if args[0] == "save" {
 			configSave(s, args)
 		} else {
@@ -107,10 +190,16 @@ func configRunCmd(cmd *cobra.Command, args []string) {
 func configCmd() *cobra.Command {
 	configCmdLongHelp := "Read or write a config value for <var-name> variable on " +
 		"a device.\nSpecify a var-value to write a value to a device.\n" +
-		"To persist existing configuration use 'save' as the var-name.\n"
+		"To persist existing configuration use 'save' as the var-name.\n" +
+		"To read all config values use 'dump'; if the device cannot list " +
+		"its values,\nthe var-names given after 'dump' are read " +
+		"individually.\n"
 	configEx := "    " + nmutil.ToolInfo.ExeName + " -c olimex config test/8\n"
 	configEx += "    " + nmutil.ToolInfo.ExeName + " -c olimex config test/8 1\n"
+	configEx += "    " + nmutil.ToolInfo.ExeName + " -c olimex config --type int test/8 1\n"
 	configEx += "    " + nmutil.ToolInfo.ExeName + " -c olimex config save\n"
+	configEx += "    " + nmutil.ToolInfo.ExeName + " -c olimex config dump\n"
+	configEx += "    " + nmutil.ToolInfo.ExeName + " -c olimex config dump test/8 test/9\n"
 	configCmd := &cobra.Command{
 		Use:     "config <var-name> [var-value] -c <conn_profile>",
 		Short:   "Read or write a config value on a device",
@@ -119,5 +208,8 @@ func configCmd() *cobra.Command {
 		Run:     configRunCmd,
 	}
 
+	configCmd.PersistentFlags().StringVar(&configValType, "type", "str",
+		"CBOR type to encode var-value as: int, bool, str, or bytes (hex)")
+
 	return configCmd
 }
@@ -161,5 +161,22 @@ func connProfileCmd() *cobra.Command {
 	}
 	cpCmd.AddCommand(showCmd)
 
+	scanCmd := &cobra.Command{
+		Use:   "scan --serial",
+		Short: "Find serial ports with a responsive Mynewt device",
+		Long: "Open each candidate serial port, send an NMP echo, and list " +
+			"the ports that reply.  Ports that can't be opened are skipped.",
+		Example: "  " + nmutil.ToolInfo.ExeName + " conn scan --serial\n" +
+			"  " + nmutil.ToolInfo.ExeName + " conn scan --serial --baud 9600\n",
+		Run: connScanCmd,
+	}
+	scanCmd.Flags().BoolVar(&connScanSerial, "serial", false,
+		"Scan serial ports")
+	scanCmd.Flags().IntVar(&connScanBaud, "baud", 115200,
+		"Baud rate to use when probing serial ports")
+	scanCmd.Flags().Float64Var(&connScanTimeout, "port-timeout", 1.0,
+		"Per-port response timeout in seconds")
+	cpCmd.AddCommand(scanCmd)
+
 	return cpCmd
 }
@@ -0,0 +1,119 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmserial"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/xact"
+	"mynewt.apache.org/newt/util"
+)
+
+const connScanEchoPayload = "newtmgr-scan"
+
+var connScanSerial bool
+var connScanBaud int
+var connScanTimeout float64
+
+// Opens the given port and checks whether it answers an NMP echo.  Ports
+// that can't be opened are reported as an error so the caller can skip them.
+func connScanSerialPort(devPath string) (bool, error) {
+	tmo := time.Duration(connScanTimeout * float64(time.Second))
+
+	xc := nmserial.NewXportCfg()
+	xc.DevPath = devPath
+	xc.Baud = connScanBaud
+	xc.ReadTimeout = tmo
+
+	x := nmserial.NewSerialXport(xc)
+	if err := x.Start(); err != nil {
+		return false, err
+	}
+	defer x.Stop()
+
+	sc := sesn.NewSesnCfg()
+	sc.MgmtProto = sesn.MGMT_PROTO_NMP
+
+	s, err := x.BuildSesn(sc)
+	if err != nil {
+		return false, err
+	}
+	if err := s.Open(); err != nil {
+		return false, err
+	}
+	defer s.Close()
+
+	c := xact.NewEchoCmd()
+	c.Payload = connScanEchoPayload
+	c.SetTxOptions(sesn.TxOptions{
+		Timeout: tmo,
+		Tries:   1,
+	})
+
+	res, err := c.Run(s)
+	if err != nil {
+		// No (valid) response; not a Mynewt device.
+		return false, nil
+	}
+	eres := res.(*xact.EchoResult)
+
+	return eres.Status() == 0 && eres.Rsp.Payload == connScanEchoPayload, nil
+}
+
+func connScanCmd(cmd *cobra.Command, args []string) {
+	if !connScanSerial {
+		nmUsage(cmd, util.NewNewtError(
+			"Specify a transport to scan (only --serial is supported)"))
+	}
+
+	ports, err := nmserial.ListPorts()
+	if err != nil {
+		nmUsage(nil, util.ChildNewtError(err))
+	}
+
+	found := 0
+	for _, p := range ports {
+		ok, err := connScanSerialPort(p.DevPath)
+		if err != nil {
+			log.Debugf("Skipping %s: %s", p.DevPath, err.Error())
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		found++
+		if p.Vid != "" {
+			fmt.Printf("%s (vid=%s pid=%s)\n", p.DevPath, p.Vid, p.Pid)
+		} else {
+			fmt.Printf("%s\n", p.DevPath)
+		}
+	}
+
+	if found == 0 {
+		fmt.Printf("No devices found\n")
+	}
+}
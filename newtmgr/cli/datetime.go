@@ -31,6 +31,8 @@ import (
 	"mynewt.apache.org/newt/util"
 )
 
+var dateTimeRaw bool
+
 func dateTimeRead(s sesn.Sesn) error {
 	c := xact.NewDateTimeReadCmd()
 	c.SetTxOptions(nmutil.TxOptions())
@@ -41,6 +43,17 @@ func dateTimeRead(s sesn.Sesn) error {
 	}
 
 	sres := res.(*xact.DateTimeReadResult)
+	if sres.Rsp.Rc != 0 {
+		fmt.Printf("Error: %d\n", sres.Rsp.Rc)
+		return nil
+	}
+
+	if !dateTimeRaw && sres.ClockUnset() {
+		fmt.Printf("Clock not set (device reports %s); set it with "+
+			"'%s datetime now'\n", sres.Rsp.DateTime, nmutil.ToolInfo.ExeName)
+		return nil
+	}
+
 	fmt.Println("Datetime(RFC 3339 format):", sres.Rsp.DateTime)
 
 	return nil
@@ -124,5 +137,8 @@ func dateTimeCmd() *cobra.Command {
 		Run:     dateTimeRunCmd,
 	}
 
+	dateTimeCmd.PersistentFlags().BoolVar(&dateTimeRaw, "raw", false,
+		"Print the device's datetime as is, even if its clock is unset")
+
 	return dateTimeCmd
 }
@@ -20,7 +20,9 @@
 package cli
 
 import (
+	"encoding/hex"
 	"fmt"
+	"io/ioutil"
 
 	"github.com/spf13/cobra"
 
@@ -29,11 +31,63 @@ import (
 	"mynewt.apache.org/newt/util"
 )
 
-func echoRunCmd(cmd *cobra.Command, args []string) {
-	if len(args) != 1 {
+var echoHex string
+var echoFile string
+
+// echoFirstDiff returns the offset of the first byte at which a and b
+// differ, or -1 if they are identical.
+func echoFirstDiff(a []byte, b []byte) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return len(a)
+		}
+		return len(b)
+	}
+	return -1
+}
+
+// echoPayload determines the payload to send.  The second return value is
+// true if the payload is binary, in which case the echoed data is verified
+// rather than printed.
+func echoPayload(cmd *cobra.Command, args []string) ([]byte, bool) {
+	sources := len(args)
+	if echoHex != "" {
+		sources++
+	}
+	if echoFile != "" {
+		sources++
+	}
+	if sources != 1 {
 		nmUsage(cmd, nil)
 	}
 
+	if echoHex != "" {
+		b, err := hex.DecodeString(echoHex)
+		if err != nil {
+			nmUsage(cmd, util.ChildNewtError(err))
+		}
+		return b, true
+	}
+
+	if echoFile != "" {
+		b, err := ioutil.ReadFile(echoFile)
+		if err != nil {
+			nmUsage(cmd, util.ChildNewtError(err))
+		}
+		return b, true
+	}
+
+	return []byte(args[0]), false
+}
+
+func echoRunCmd(cmd *cobra.Command, args []string) {
+	payload, binary := echoPayload(cmd, args)
+
 	s, err := GetSesn()
 	if err != nil {
 		nmUsage(nil, err)
@@ -41,7 +95,7 @@ func echoRunCmd(cmd *cobra.Command, args []string) {
 
 	c := xact.NewEchoCmd()
 	c.SetTxOptions(nmutil.TxOptions())
-	c.Payload = args[0]
+	c.Payload = string(payload)
 
 	res, err := c.Run(s)
 	if err != nil {
@@ -49,15 +103,34 @@ func echoRunCmd(cmd *cobra.Command, args []string) {
 	}
 
 	eres := res.(*xact.EchoResult)
-	fmt.Println(eres.Rsp.Payload)
+	if !binary {
+		fmt.Println(eres.Rsp.Payload)
+		return
+	}
+
+	rsp := []byte(eres.Rsp.Payload)
+	if off := echoFirstDiff(payload, rsp); off >= 0 {
+		nmUsage(nil, util.FmtNewtError(
+			"Echo mismatch at offset %d; sent %d bytes, received %d",
+			off, len(payload), len(rsp)))
+	}
+
+	fmt.Printf("Echoed %d bytes OK\n", len(payload))
 }
 
 func echoCmd() *cobra.Command {
 	echoCmd := &cobra.Command{
-		Use:   "echo <text> -c <conn_profile>",
+		Use:   "echo [<text> | --hex <hex> | --file <file>] -c <conn_profile>",
 		Short: "Send data to a device and display the echoed back data",
-		Run:   echoRunCmd,
+		Long: "Send data to a device and display the echoed back data.  " +
+			"Binary payloads given with --hex or --file are verified " +
+			"byte for byte instead of displayed.",
+		Run: echoRunCmd,
 	}
+	echoCmd.PersistentFlags().StringVar(&echoHex, "hex", "",
+		"Send the bytes encoded by this hex string")
+	echoCmd.PersistentFlags().StringVar(&echoFile, "file", "",
+		"Send the contents of this file")
 
 	return echoCmd
 }
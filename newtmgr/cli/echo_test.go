@@ -0,0 +1,47 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package cli
+
+import "testing"
+
+func TestEchoFirstDiff(t *testing.T) {
+	tests := []struct {
+		a    string
+		b    string
+		want int
+	}{
+		{"", "", -1},
+		{"abc", "abc", -1},
+		{"abc", "abd", 2},
+		{"xbc", "abc", 0},
+		{"abc", "ab", 2},
+		{"ab", "abc", 2},
+		{"", "a", 0},
+		{"a\x00c", "a\x01c", 1},
+	}
+
+	for _, tt := range tests {
+		got := echoFirstDiff([]byte(tt.a), []byte(tt.b))
+		if got != tt.want {
+			t.Errorf("echoFirstDiff(%q, %q) = %d, want %d",
+				tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/newtmgr/nmutil"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/xact"
+	"mynewt.apache.org/newt/util"
+)
+
+var factoryKeepKeys bool
+var factoryKeyPrefixes []string
+
+func factoryResetRunCmd(cmd *cobra.Command, args []string) {
+	ConfirmOrAbort("Clear the configuration, erase the unused image slot " +
+		"and reset the device?")
+
+	s, err := GetSesn()
+	if err != nil {
+		nmUsage(nil, err)
+	}
+
+	c := xact.NewFactoryResetCmd()
+	c.SetTxOptions(nmutil.TxOptions())
+	c.Names = args
+	if factoryKeepKeys {
+		c.KeepPrefixes = factoryKeyPrefixes
+	}
+	c.StepCb = func(step xact.FactoryResetStep) {
+		switch {
+		case step.Err != nil:
+			fmt.Printf("%s: failed\n", step.Name)
+		case step.Skipped:
+			fmt.Printf("%s: not supported by device; skipped\n", step.Name)
+		default:
+			fmt.Printf("%s: done\n", step.Name)
+		}
+	}
+
+	res, err := c.Run(s)
+	if res != nil {
+		fres := res.(*xact.FactoryResetResult)
+		for _, name := range fres.Kept {
+			fmt.Printf("Kept %s\n", name)
+		}
+		for name, rc := range fres.Failed {
+			fmt.Fprintf(os.Stderr, "Warning: cannot clear %s; rc=%d\n",
+				name, rc)
+		}
+	}
+	if err != nil {
+		nmUsage(nil, util.ChildNewtError(err))
+	}
+
+	fmt.Printf("Done\n")
+}
+
+func factoryResetCmd() *cobra.Command {
+	factoryEx := "  " + nmutil.ToolInfo.ExeName + " -c olimex factory-reset\n"
+	factoryEx += "  " + nmutil.ToolInfo.ExeName +
+		" -c olimex factory-reset --keep-keys --key-prefix prov/\n"
+
+	factoryCmd := &cobra.Command{
+		Use:   "factory-reset [var-name...] -c <conn_profile>",
+		Short: "Clear config, erase the unused image and reset a device",
+		Long: "Clear every config value the device lists and save the " +
+			"result, erase the image slot that is not running, then reset " +
+			"the device.\nIf the device cannot list its config, the " +
+			"var-names given are cleared instead.  Steps the device does " +
+			"not support are skipped.",
+		Example: factoryEx,
+		Run:     factoryResetRunCmd,
+	}
+
+	factoryCmd.PersistentFlags().BoolVar(&factoryKeepKeys, "keep-keys",
+		false, "Keep config values that hold provisioning secrets")
+	factoryCmd.PersistentFlags().StringSliceVar(&factoryKeyPrefixes,
+		"key-prefix", []string{"key/", "prov/"},
+		"With --keep-keys, name prefix of the config values to keep; "+
+			"may be repeated")
+
+	return factoryCmd
+}
@@ -20,43 +20,161 @@
 package cli
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/comap-smart-home/mynewt-newtmgr/newtmgr/nmutil"
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/xact"
 	"mynewt.apache.org/newt/util"
 )
 
-func fsDownloadRunCmd(cmd *cobra.Command, args []string) {
-	if len(args) < 2 {
-		nmUsage(cmd, nil)
+var fsStatJson bool
+var fsNoResume bool
+var fsMountsJson bool
+
+// fsLocalHash calculates the SHA256 of the first n bytes of a local file.
+func fsLocalHash(path string, n int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
 	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.CopyN(h, f, n); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// fsRemoteHash retrieves the SHA256 of the first n bytes of a remote file.
+// The returned bool is false if the device cannot calculate file hashes.
+func fsRemoteHash(s sesn.Sesn, name string, n int64) ([]byte, bool, error) {
+	c := xact.NewFsHashCmd()
+	c.SetTxOptions(nmutil.TxOptions())
+	c.Name = name
+	c.Len = int(n)
 
-	file, err := os.OpenFile(args[1], os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0660)
+	res, err := c.Run(s)
 	if err != nil {
-		nmUsage(cmd, util.FmtNewtError(
-			"Cannot open file %s - %s", args[1], err.Error()))
+		return nil, false, err
 	}
-	defer file.Close()
 
-	s, err := GetSesn()
+	rsp := res.(*xact.FsHashResult).Rsp
+	if rsp.Rc == nmp.NMP_ERR_ENOTSUP {
+		return nil, false, nil
+	}
+	if rsp.Rc != 0 {
+		return nil, false, util.FmtNewtError(
+			"Cannot hash file %s; rc=%d", name, rsp.Rc)
+	}
+	return rsp.Output, true, nil
+}
+
+// fsMatches indicates whether the first n bytes of a local and remote file
+// are identical.  The second return value is false if the device cannot
+// say.
+func fsMatches(s sesn.Sesn, remote string, local string,
+	n int64) (bool, bool, error) {
+
+	rh, ok, err := fsRemoteHash(s, remote, n)
+	if err != nil || !ok {
+		return false, ok, err
+	}
+
+	lh, err := fsLocalHash(local, n)
 	if err != nil {
-		nmUsage(nil, err)
+		return false, true, util.ChildNewtError(err)
+	}
+
+	return bytes.Equal(lh, rh), true, nil
+}
+
+// fsResumeOffset determines where an interrupted download of remote into
+// local can be resumed from.  Zero is returned if there is nothing usable
+// to resume.
+func fsResumeOffset(s sesn.Sesn, remote string, local string) int64 {
+	info, err := os.Stat(local)
+	if err != nil || info.Size() == 0 {
+		return 0
+	}
+	localSz := info.Size()
+
+	c := xact.NewFsStatCmd()
+	c.SetTxOptions(nmutil.TxOptions())
+	c.Name = remote
+
+	res, err := c.Run(s)
+	if err != nil {
+		nmUsage(nil, util.ChildNewtError(err))
+	}
+	sres := res.(*xact.FsStatResult)
+	if sres.Rc != 0 {
+		// Let the download report the error.
+		return 0
+	}
+
+	if int64(sres.Stat.Size) < localSz {
+		fmt.Printf("Warning: remote file is smaller than %s; "+
+			"restarting download\n", local)
+		return 0
 	}
 
+	match, ok, err := fsMatches(s, remote, local, localSz)
+	if err != nil {
+		nmUsage(nil, util.ChildNewtError(err))
+	}
+	if !ok {
+		fmt.Printf("Warning: device cannot verify partial file %s; "+
+			"restarting download\n", local)
+		return 0
+	}
+	if !match {
+		fmt.Printf("Warning: remote file has changed since %s was "+
+			"written; restarting download\n", local)
+		return 0
+	}
+
+	return localSz
+}
+
+// fsDownload reads remote into local starting at off.  Data is appended to
+// local if off is nonzero; otherwise local is truncated first.  Each chunk
+// is written to local as it arrives.  A complete download is checked
+// against the device's hash of the file if the device supports hashing.
+func fsDownload(s sesn.Sesn, remote string, local string, off int64) bool {
+	flags := os.O_WRONLY | os.O_CREATE
+	if off > 0 {
+		flags |= os.O_APPEND
+	} else {
+		flags |= os.O_TRUNC
+	}
+
+	file, err := os.OpenFile(local, flags, 0660)
+	if err != nil {
+		nmUsage(nil, util.FmtNewtError(
+			"Cannot open file %s - %s", local, err.Error()))
+	}
+	defer file.Close()
+
 	c := xact.NewFsDownloadCmd()
 	c.SetTxOptions(nmutil.TxOptions())
-	c.Name = args[0]
+	c.Name = remote
+	c.Off = int(off)
+	c.Writer = file
 	c.ProgressCb = func(c *xact.FsDownloadCmd, rsp *nmp.FsDownloadRsp) {
 		fmt.Printf("%d\n", rsp.Off)
-		if _, err := file.Write(rsp.Data); err != nil {
-			nmUsage(nil, util.ChildNewtError(err))
-		}
 	}
 
 	res, err := c.Run(s)
@@ -68,9 +186,67 @@ func fsDownloadRunCmd(cmd *cobra.Command, args []string) {
 	rsp := sres.Rsps[len(sres.Rsps)-1]
 	if rsp.Rc != 0 {
 		fmt.Printf("Error: %d\n", rsp.Rc)
+		return false
+	}
+
+	if off == 0 {
+		rh, ok, err := fsRemoteHash(s, remote, int64(sres.Len))
+		if err != nil {
+			nmUsage(nil, util.ChildNewtError(err))
+		}
+		if ok && !bytes.Equal(rh, sres.Sha256) {
+			nmUsage(nil, util.FmtNewtError(
+				"Downloaded file does not match the device's hash; "+
+					"expected %x, got %x", rh, sres.Sha256))
+		}
+	}
+
+	return true
+}
+
+func fsDownloadRunCmd(cmd *cobra.Command, args []string) {
+	if len(args) < 2 {
+		nmUsage(cmd, nil)
+	}
+	remote := args[0]
+	local := args[1]
+
+	s, err := GetSesn()
+	if err != nil {
+		nmUsage(nil, err)
+	}
+
+	var off int64
+	if !fsNoResume {
+		off = fsResumeOffset(s, remote, local)
+		if off > 0 {
+			fmt.Printf("Resuming download at offset %d\n", off)
+		}
+	}
+
+	if !fsDownload(s, remote, local, off) {
 		return
 	}
 
+	if off > 0 {
+		info, err := os.Stat(local)
+		if err != nil {
+			nmUsage(nil, util.ChildNewtError(err))
+		}
+
+		match, _, err := fsMatches(s, remote, local, info.Size())
+		if err != nil {
+			nmUsage(nil, util.ChildNewtError(err))
+		}
+		if !match {
+			fmt.Printf("Warning: remote file changed during download; " +
+				"restarting download\n")
+			if !fsDownload(s, remote, local, 0) {
+				return
+			}
+		}
+	}
+
 	fmt.Printf("Done\n")
 }
 
@@ -112,6 +288,104 @@ func fsUploadRunCmd(cmd *cobra.Command, args []string) {
 	fmt.Printf("Done\n")
 }
 
+func fsStatRunCmd(cmd *cobra.Command, args []string) {
+	if len(args) < 1 {
+		nmUsage(cmd, nil)
+	}
+
+	s, err := GetSesn()
+	if err != nil {
+		nmUsage(nil, err)
+	}
+
+	c := xact.NewFsStatCmd()
+	c.SetTxOptions(nmutil.TxOptions())
+	c.Name = args[0]
+
+	res, err := c.Run(s)
+	if err != nil {
+		nmUsage(nil, util.ChildNewtError(err))
+	}
+
+	sres := res.(*xact.FsStatResult)
+	if sres.Rc == nmp.NMP_ERR_ENOENT {
+		nmUsage(nil, util.FmtNewtError("No such file on device: %s", args[0]))
+	}
+	if sres.Rc != 0 {
+		fmt.Printf("Error: %d\n", sres.Rc)
+		return
+	}
+
+	if fsStatJson {
+		j, err := json.MarshalIndent(sres.Stat, "", "    ")
+		if err != nil {
+			nmUsage(nil, util.ChildNewtError(err))
+		}
+		fmt.Printf("%s\n", j)
+		return
+	}
+
+	fmt.Printf("name: %s\n", sres.Stat.Name)
+	fmt.Printf("size: %d\n", sres.Stat.Size)
+	if sres.Stat.HasMtime {
+		fmt.Printf("mtime: %s\n",
+			time.Unix(sres.Stat.Mtime, 0).Format(time.RFC3339))
+	}
+}
+
+func fsMountsRunCmd(cmd *cobra.Command, args []string) {
+	s, err := GetSesn()
+	if err != nil {
+		nmUsage(nil, err)
+	}
+
+	c := xact.NewFsMountsCmd()
+	c.SetTxOptions(nmutil.TxOptions())
+
+	res, err := c.Run(s)
+	if err != nil {
+		nmUsage(nil, util.ChildNewtError(err))
+	}
+
+	sres := res.(*xact.FsMountsResult)
+	if nmp.RcNotSupported(sres.Rc) {
+		fmt.Printf("This device does not report its mount points\n")
+		return
+	}
+	if sres.Rc != 0 {
+		fmt.Printf("Error: %d\n", sres.Rc)
+		return
+	}
+
+	if fsMountsJson {
+		mounts := sres.Mounts
+		if mounts == nil {
+			mounts = []xact.FsMount{}
+		}
+		j, err := json.MarshalIndent(mounts, "", "    ")
+		if err != nil {
+			nmUsage(nil, util.ChildNewtError(err))
+		}
+		fmt.Printf("%s\n", j)
+		return
+	}
+
+	if len(sres.Mounts) == 0 {
+		fmt.Printf("(no mount points)\n")
+		return
+	}
+
+	fmt.Printf("%-16s %-10s %12s %12s\n", "path", "type", "size", "free")
+	for _, m := range sres.Mounts {
+		size, free := "-", "-"
+		if m.Size != 0 {
+			size = strconv.FormatUint(m.Size, 10)
+			free = strconv.FormatUint(m.Free, 10)
+		}
+		fmt.Printf("%-16s %-10s %12s %12s\n", m.Path, m.Type, size, free)
+	}
+}
+
 func fsCmd() *cobra.Command {
 	fsCmd := &cobra.Command{
 		Use:   "fs",
@@ -136,12 +410,44 @@ func fsCmd() *cobra.Command {
 		" -c olimex image download /cfg/mfg mfg.txt\n"
 
 	downloadCmd := &cobra.Command{
-		Use:     "download <src-filename> <dst-filename> -c <conn_profile>",
-		Short:   "Download file from a device",
+		Use:   "download <src-filename> <dst-filename> -c <conn_profile>",
+		Short: "Download file from a device",
+		Long: "Download file from a device.\nIf <dst-filename> already " +
+			"holds the start of the file, the download resumes where it " +
+			"left off.",
 		Example: downloadEx,
 		Run:     fsDownloadRunCmd,
 	}
+	downloadCmd.PersistentFlags().BoolVar(&fsNoResume, "no-resume", false,
+		"Always download the whole file, discarding any partial local copy")
 	fsCmd.AddCommand(downloadCmd)
 
+	statEx := "  " + nmutil.ToolInfo.ExeName +
+		" -c olimex fs stat /cfg/mfg\n"
+	statEx += "  " + nmutil.ToolInfo.ExeName +
+		" -c olimex fs stat --json /cfg/mfg\n"
+
+	statCmd := &cobra.Command{
+		Use:     "stat <filename> -c <conn_profile>",
+		Short:   "Show the size of a file on a device",
+		Example: statEx,
+		Run:     fsStatRunCmd,
+	}
+	statCmd.PersistentFlags().BoolVarP(&fsStatJson, "json", "j", false,
+		"Print file status as JSON")
+	fsCmd.AddCommand(statCmd)
+
+	mountsCmd := &cobra.Command{
+		Use:   "mounts -c <conn_profile>",
+		Short: "List the file systems mounted on a device",
+		Long: "List the file systems mounted on a device, with their type " +
+			"and, where reported, size and free space in bytes.  Requires " +
+			"firmware that reports mount points.",
+		Run: fsMountsRunCmd,
+	}
+	mountsCmd.PersistentFlags().BoolVarP(&fsMountsJson, "json", "j", false,
+		"Print mount points as JSON")
+	fsCmd.AddCommand(mountsCmd)
+
 	return fsCmd
 }
@@ -0,0 +1,88 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/newtmgr/nmutil"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/xact"
+	"mynewt.apache.org/newt/util"
+)
+
+var hwIdVar string
+
+// hwIdRead reads the device's hardware id from the given config variable.
+// An error is returned if the device doesn't expose one.
+func hwIdRead(s sesn.Sesn, v string) ([]byte, error) {
+	c := xact.NewHwIdReadCmd()
+	c.SetTxOptions(nmutil.TxOptions())
+	c.Var = v
+
+	res, err := c.Run(s)
+	if err != nil {
+		return nil, util.ChildNewtError(err)
+	}
+
+	hres := res.(*xact.HwIdReadResult)
+	if hres.Rc != 0 {
+		return nil, util.FmtNewtError(
+			"Failed to read hardware id; rc=%d", hres.Rc)
+	}
+	if hres.HwId == nil {
+		return nil, util.FmtNewtError(
+			"Device does not expose a hardware id (%s)", v)
+	}
+
+	return hres.HwId, nil
+}
+
+func hwIdRunCmd(cmd *cobra.Command, args []string) {
+	s, err := GetSesn()
+	if err != nil {
+		nmUsage(nil, err)
+	}
+
+	id, err := hwIdRead(s, hwIdVar)
+	if err != nil {
+		nmUsage(nil, err)
+	}
+
+	fmt.Printf("%x\n", id)
+}
+
+func hwIdCmd() *cobra.Command {
+	hwIdCmd := &cobra.Command{
+		Use:   "hwid -c <conn_profile>",
+		Short: "Show a device's unique hardware id",
+		Long: "Print the device's unique hardware id in hex.  The id is " +
+			"read from the config variable given with --var.  The exit " +
+			"status is non-zero if the device doesn't expose one.",
+		Run: hwIdRunCmd,
+	}
+
+	hwIdCmd.PersistentFlags().StringVar(&hwIdVar, "var",
+		xact.HWID_DFLT_VAR, "Config variable holding the hardware id")
+
+	return hwIdCmd
+}
@@ -20,18 +20,22 @@
 package cli
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"strings"
 
 	"github.com/spf13/cobra"
-	pb "gopkg.in/cheggaaa/pb.v1"
 
 	"github.com/comap-smart-home/mynewt-newtmgr/newtmgr/core"
 	"github.com/comap-smart-home/mynewt-newtmgr/newtmgr/nmutil"
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/xact"
 	"mynewt.apache.org/newt/util"
 )
@@ -40,12 +44,55 @@ var (
 	coreElfify   bool
 	coreOffset   uint32
 	coreNumBytes uint32
+	coreDecode   bool
+	coreSha256   string
 )
 
 var noerase bool
 var upgrade bool
+var denyDowngrade bool
+var allowDowngrade bool
 var imageNum int
 var maxWinSz int
+var imageListJson bool
+var imageListReconnect bool
+var imageHashSlot int
+var imageUploadSlot int
+var imageUploadUrl string
+var imageUploadSha256 string
+var imageUploadHeaders []string
+var imageUploadStateFile string
+var imageUploadChunkRetries int
+var imageUploadFlush bool
+
+// Progress of an interrupted image upload, persisted by
+// `image upload --state-file` so that a later invocation can resume it.
+type imageUploadState struct {
+	ImageSha256 string `json:"image_sha256"`
+	Size        int    `json:"size"`
+	Device      string `json:"device"`
+	Offset      int    `json:"offset"`
+	Checksum    string `json:"checksum"`
+}
+
+type imageJsonEntry struct {
+	Image     int    `json:"image"`
+	Slot      int    `json:"slot"`
+	Version   string `json:"version"`
+	Hash      string `json:"hash"`
+	Bootable  bool   `json:"bootable"`
+	Pending   bool   `json:"pending"`
+	Confirmed bool   `json:"confirmed"`
+	Active    bool   `json:"active"`
+	Permanent bool   `json:"permanent"`
+	SwapType  string `json:"swap_type"`
+}
+
+type imageListJsonOut struct {
+	Images      []imageJsonEntry `json:"images"`
+	SplitStatus string           `json:"split_status"`
+	Boot        xact.BootInfo    `json:"boot"`
+}
 
 func imageFlagsStr(image nmp.ImageStateEntry) string {
 	strs := []string{}
@@ -77,6 +124,7 @@ func imageStatePrintRsp(rsp *nmp.ImageStateRsp) error {
 		fmt.Printf("    version: %s\n", img.Version)
 		fmt.Printf("    bootable: %v\n", img.Bootable)
 		fmt.Printf("    flags: %s\n", imageFlagsStr(img))
+		fmt.Printf("    swap type: %s\n", img.SwapType().String())
 		if len(img.Hash) == 0 {
 			fmt.Printf("    hash: Unavailable\n")
 		} else {
@@ -97,6 +145,7 @@ func imageStateListCmd(cmd *cobra.Command, args []string) {
 
 	c := xact.NewImageStateReadCmd()
 	c.SetTxOptions(nmutil.TxOptions())
+	c.Reconnect = imageListReconnect
 
 	res, err := c.Run(s)
 	if err != nil {
@@ -104,11 +153,170 @@ func imageStateListCmd(cmd *cobra.Command, args []string) {
 	}
 	ires := res.(*xact.ImageStateReadResult)
 
+	if imageListJson {
+		imageStatePrintJson(s, ires.Rsp)
+		return
+	}
+
 	if err := imageStatePrintRsp(ires.Rsp); err != nil {
 		nmUsage(nil, err)
 	}
 }
 
+func imageStatePrintJson(s sesn.Sesn, rsp *nmp.ImageStateRsp) {
+	if rsp.Rc != 0 {
+		fmt.Printf("Error: %d\n", rsp.Rc)
+		return
+	}
+
+	out := imageListJsonOut{
+		Images:      []imageJsonEntry{},
+		SplitStatus: rsp.SplitStatus.String(),
+	}
+	for _, img := range rsp.Images {
+		out.Images = append(out.Images, imageJsonEntry{
+			Image:     img.Image,
+			Slot:      img.Slot,
+			Version:   img.Version,
+			Hash:      hex.EncodeToString(img.Hash),
+			Bootable:  img.Bootable,
+			Pending:   img.Pending,
+			Confirmed: img.Confirmed,
+			Active:    img.Active,
+			Permanent: img.Permanent,
+			SwapType:  img.SwapType().String(),
+		})
+	}
+
+	// Boot loader details are best effort; an unsupported device just
+	// reports "supported": false.
+	bc := xact.NewBootInfoCmd()
+	bc.SetTxOptions(nmutil.TxOptions())
+	if res, err := bc.Run(s); err == nil {
+		out.Boot = res.(*xact.BootInfoResult).Info
+	}
+
+	j, err := json.MarshalIndent(out, "", "    ")
+	if err != nil {
+		nmUsage(nil, util.ChildNewtError(err))
+	}
+	fmt.Println(string(j))
+}
+
+// Prints only the hash of the image in the requested slot, for capture by
+// scripts.  Exits with a non-zero status if the slot is empty.
+func imageHashCmd(cmd *cobra.Command, args []string) {
+	if imageHashSlot < 0 {
+		nmUsage(cmd, util.NewNewtError("Invalid slot number"))
+	}
+
+	s, err := GetSesn()
+	if err != nil {
+		nmUsage(nil, err)
+	}
+
+	c := xact.NewImageStateReadCmd()
+	c.SetTxOptions(nmutil.TxOptions())
+
+	res, err := c.Run(s)
+	if err != nil {
+		nmUsage(nil, util.ChildNewtError(err))
+	}
+	rsp := res.(*xact.ImageStateReadResult).Rsp
+	if rsp.Rc != 0 {
+		fmt.Fprintf(os.Stderr, "Error: %d\n", rsp.Rc)
+		os.Exit(1)
+	}
+
+	for _, img := range rsp.Images {
+		if img.Image == imageNum && img.Slot == imageHashSlot &&
+			len(img.Hash) > 0 {
+
+			fmt.Printf("%x\n", img.Hash)
+			return
+		}
+	}
+
+	os.Exit(1)
+}
+
+func imageBootInfoCmd(cmd *cobra.Command, args []string) {
+	s, err := GetSesn()
+	if err != nil {
+		nmUsage(nil, err)
+	}
+
+	c := xact.NewBootInfoCmd()
+	c.SetTxOptions(nmutil.TxOptions())
+
+	res, err := c.Run(s)
+	if err != nil {
+		nmUsage(nil, util.ChildNewtError(err))
+	}
+	bres := res.(*xact.BootInfoResult)
+
+	if bres.Rc != 0 {
+		fmt.Printf("Error: %d\n", bres.Rc)
+		return
+	}
+
+	info := bres.Info
+	if !info.Supported {
+		fmt.Println("Boot loader info not supported by device")
+		return
+	}
+
+	fmt.Printf("Boot loader: %s\n", info.Bootloader)
+	if info.Mode != nmp.BOOT_MODE_UNKNOWN {
+		fmt.Printf("    mode: %s (%d)\n", info.ModeName, info.Mode)
+		fmt.Printf("    no-downgrade: %v\n", info.NoDowngrade)
+	}
+}
+
+func imageInfoCmd(cmd *cobra.Command, args []string) {
+	if len(args) < 1 {
+		nmUsage(cmd, util.NewNewtError("Need to specify image file"))
+	}
+
+	data, err := ioutil.ReadFile(args[0])
+	if err != nil {
+		nmUsage(cmd, util.ChildNewtError(err))
+	}
+
+	info, err := xact.ParseImageInfo(data)
+	if err != nil {
+		nmUsage(nil, util.FmtNewtError("Cannot parse %s: %s", args[0],
+			err.Error()))
+	}
+
+	hdr := info.Header
+	fmt.Printf("Header:\n")
+	fmt.Printf("    magic: 0x%08x\n", hdr.Magic)
+	fmt.Printf("    load addr: 0x%08x\n", hdr.LoadAddr)
+	fmt.Printf("    header size: %d\n", hdr.HdrSz)
+	fmt.Printf("    protected TLV size: %d\n", hdr.ProtTlvSz)
+	fmt.Printf("    image size: %d\n", hdr.ImgSz)
+	fmt.Printf("    flags: 0x%08x\n", hdr.Flags)
+	fmt.Printf("    version: %s\n", hdr.Vers.String())
+
+	fmt.Printf("TLVs:\n")
+	for _, tlv := range info.Tlvs {
+		prot := ""
+		if tlv.Protected {
+			prot = " protected"
+		}
+		fmt.Printf("    type=0x%02x (%s)%s len=%d\n", tlv.Type,
+			xact.ImageTlvTypeToString(tlv.Type), prot, len(tlv.Data))
+		fmt.Printf("        %s\n", tlv.ValueString())
+	}
+
+	valid := "mismatch"
+	if info.HashValid() {
+		valid = "valid"
+	}
+	fmt.Printf("Calculated hash: %x (%s)\n", info.Hash, valid)
+}
+
 func imageStateTestCmd(cmd *cobra.Command, args []string) {
 	if len(args) < 1 {
 		nmUsage(cmd, nil)
@@ -140,6 +348,45 @@ func imageStateTestCmd(cmd *cobra.Command, args []string) {
 	}
 }
 
+func imageSwapCmd(cmd *cobra.Command, args []string) {
+	if len(args) < 1 {
+		nmUsage(cmd, nil)
+	}
+
+	st, err := nmp.SwapTypeFromString(args[0])
+	if err != nil {
+		nmUsage(cmd, util.ChildNewtError(err))
+	}
+
+	var hexBytes []byte
+	if len(args) >= 2 {
+		hexBytes, err = hex.DecodeString(args[1])
+		if err != nil {
+			nmUsage(cmd, util.ChildNewtError(err))
+		}
+	}
+
+	s, err := GetSesn()
+	if err != nil {
+		nmUsage(nil, err)
+	}
+
+	c := xact.NewImageSwapCmd()
+	c.SetTxOptions(nmutil.TxOptions())
+	c.Type = st
+	c.Hash = hexBytes
+
+	res, err := c.Run(s)
+	if err != nil {
+		nmUsage(nil, util.ChildNewtError(err))
+	}
+	ires := res.(*xact.ImageStateWriteResult)
+
+	if err := imageStatePrintRsp(ires.Rsp); err != nil {
+		nmUsage(nil, err)
+	}
+}
+
 func imageStateConfirmCmd(cmd *cobra.Command, args []string) {
 	var hexBytes []byte
 	if len(args) >= 1 {
@@ -171,14 +418,146 @@ func imageStateConfirmCmd(cmd *cobra.Command, args []string) {
 	}
 }
 
-func imageUploadCmd(cmd *cobra.Command, args []string) {
-	if len(args) < 1 {
-		nmUsage(cmd, util.NewNewtError("Need to specify image to upload"))
+// imageUpgradeCmdUrl creates an upgrade command for an image fetched over
+// HTTP(S).  Each header is of the form "Name: value".
+func imageUpgradeCmdUrl(url string,
+	headers []string) (*xact.ImageUpgradeCmd, error) {
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, util.ChildNewtError(err)
+	}
+	for _, h := range headers {
+		parts := strings.SplitN(h, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, util.FmtNewtError(
+				"Invalid header \"%s\"; expected \"Name: value\"", h)
+		}
+		req.Header.Add(strings.TrimSpace(parts[0]),
+			strings.TrimSpace(parts[1]))
+	}
+
+	rsp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, util.ChildNewtError(err)
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK {
+		return nil, util.FmtNewtError("Failed to fetch %s: %s", url,
+			rsp.Status)
+	}
+
+	if rsp.ContentLength > 0 {
+		c, err := xact.NewImageUpgradeCmdReader(rsp.Body,
+			int(rsp.ContentLength))
+		if err != nil {
+			return nil, util.ChildNewtError(err)
+		}
+		return c, nil
+	}
+
+	// Length unknown; buffer the whole body.
+	data, err := ioutil.ReadAll(rsp.Body)
+	if err != nil {
+		return nil, util.ChildNewtError(err)
+	}
+	c, err := xact.NewImageUpgradeCmdReader(bytes.NewReader(data), len(data))
+	if err != nil {
+		return nil, util.ChildNewtError(err)
+	}
+	return c, nil
+}
+
+// Describes the target device well enough to tell whether an upload state
+// file belongs to it.
+func imageUploadDevice() string {
+	return strings.Join([]string{nmutil.ConnProfile, nmutil.ConnType,
+		nmutil.ConnString, nmutil.ConnExtra, nmutil.DeviceName}, "|")
+}
+
+func (st *imageUploadState) sum() string {
+	h := sha256.Sum256([]byte(fmt.Sprintf("%s|%d|%s|%d",
+		st.ImageSha256, st.Size, st.Device, st.Offset)))
+	return hex.EncodeToString(h[:8])
+}
+
+// Reads an upload state file.  nil is returned if the file doesn't exist or
+// is corrupt; in the latter case a warning is printed.
+func readImageUploadState(path string) *imageUploadState {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "Warning: can't read %s: %s\n", path,
+				err.Error())
+		}
+		return nil
+	}
+
+	st := &imageUploadState{}
+	if err := json.Unmarshal(b, st); err != nil || st.Checksum != st.sum() {
+		fmt.Fprintf(os.Stderr,
+			"Warning: ignoring corrupt upload state file %s\n", path)
+		return nil
 	}
 
-	imageFile, err := ioutil.ReadFile(args[0])
+	return st
+}
+
+// Writes an upload state file.  The new contents are written to a temporary
+// file first so that an interrupted write doesn't leave a truncated file.
+func writeImageUploadState(path string, st *imageUploadState) error {
+	st.Checksum = st.sum()
+	b, err := json.Marshal(st)
 	if err != nil {
-		nmUsage(cmd, util.NewNewtError(err.Error()))
+		return util.ChildNewtError(err)
+	}
+
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0644); err != nil {
+		return util.ChildNewtError(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return util.ChildNewtError(err)
+	}
+	return nil
+}
+
+func imageUploadCmd(cmd *cobra.Command, args []string) {
+	var c *xact.ImageUpgradeCmd
+	var err error
+
+	if imageUploadUrl != "" {
+		if len(args) > 0 {
+			nmUsage(cmd, util.NewNewtError(
+				"Specify either an image file or --url, not both"))
+		}
+		c, err = imageUpgradeCmdUrl(imageUploadUrl, imageUploadHeaders)
+		if err != nil {
+			nmUsage(nil, err)
+		}
+	} else {
+		if len(args) < 1 {
+			nmUsage(cmd, util.NewNewtError("Need to specify image to upload"))
+		}
+
+		c, err = xact.NewImageUpgradeCmdFile(args[0])
+		if err != nil {
+			nmUsage(cmd, util.NewNewtError(err.Error()))
+		}
+	}
+
+	if imageUploadSha256 != "" {
+		want, err := hex.DecodeString(imageUploadSha256)
+		if err != nil || len(want) != sha256.Size {
+			nmUsage(cmd, util.FmtNewtError("Invalid SHA256: \"%s\"",
+				imageUploadSha256))
+		}
+		got := sha256.Sum256(c.Data)
+		if !bytes.Equal(got[:], want) {
+			nmUsage(nil, util.FmtNewtError(
+				"Image SHA256 mismatch: expected %x, got %x", want, got))
+		}
 	}
 
 	s, err := GetSesn()
@@ -186,9 +565,7 @@ func imageUploadCmd(cmd *cobra.Command, args []string) {
 		nmUsage(nil, err)
 	}
 
-	c := xact.NewImageUpgradeCmd()
 	c.SetTxOptions(nmutil.TxOptions())
-	c.Data = imageFile
 	if noerase == true {
 		c.NoErase = true
 	}
@@ -196,20 +573,64 @@ func imageUploadCmd(cmd *cobra.Command, args []string) {
 		nmUsage(cmd, util.NewNewtError("Invalid image number"))
 	}
 	c.ImageNum = imageNum
+	if imageUploadSlot != xact.IMAGE_UPLOAD_SLOT_DEFAULT &&
+		(imageUploadSlot < 0 || imageUploadSlot > xact.IMAGE_UPLOAD_SLOT_MAX) {
+
+		nmUsage(cmd, util.FmtNewtError("Invalid slot number: %d",
+			imageUploadSlot))
+	}
+	c.Slot = imageUploadSlot
 	c.Upgrade = upgrade
-	c.ProgressBar = pb.StartNew(len(imageFile))
-	c.ProgressBar.SetUnits(pb.U_BYTES)
-	c.ProgressBar.ShowSpeed = true
+	c.DenyDowngrade = denyDowngrade && !allowDowngrade
+	progressCb, progressDone := newProgressRenderer()
+	c.ProgressEventCb = progressCb
 	c.LastOff = 0
 	c.MaxWinSz = maxWinSz
+	if imageUploadChunkRetries < 0 {
+		nmUsage(cmd, util.NewNewtError("Invalid chunk retry count"))
+	}
+	c.ChunkRetries = imageUploadChunkRetries
+	c.Flush = imageUploadFlush
+
+	var state *imageUploadState
+	if imageUploadStateFile != "" {
+		hash := sha256.Sum256(c.Data)
+		state = &imageUploadState{
+			ImageSha256: hex.EncodeToString(hash[:]),
+			Size:        len(c.Data),
+			Device:      imageUploadDevice(),
+		}
+
+		prev := readImageUploadState(imageUploadStateFile)
+		if prev != nil {
+			if prev.ImageSha256 == state.ImageSha256 &&
+				prev.Size == state.Size && prev.Device == state.Device {
+
+				c.ResumeOff = prev.Offset
+				fmt.Printf("Resuming upload at offset %d\n", c.ResumeOff)
+			} else {
+				fmt.Fprintf(os.Stderr, "Warning: %s is for a different "+
+					"image or device; starting over\n", imageUploadStateFile)
+			}
+		}
+	}
+
 	c.ProgressCb = func(cmd *xact.ImageUploadCmd, rsp *nmp.ImageUploadRsp) {
 		if rsp.Off > c.LastOff {
-			c.ProgressBar.Add(int(rsp.Off - c.LastOff))
 			c.LastOff = rsp.Off
+
+			if state != nil {
+				state.Offset = int(rsp.Off)
+				err := writeImageUploadState(imageUploadStateFile, state)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: %s\n", err.Error())
+				}
+			}
 		}
 	}
 
 	res, err := c.Run(s)
+	progressDone()
 	if err != nil {
 		nmUsage(nil, util.ChildNewtError(err))
 	}
@@ -219,8 +640,16 @@ func imageUploadCmd(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	c.ProgressBar.Finish()
-	fmt.Printf("Done\n")
+	if state != nil {
+		os.Remove(imageUploadStateFile)
+	}
+
+	ures := res.(*xact.ImageUpgradeResult).UploadRes
+	if ures != nil && ures.RetriedChunks > 0 {
+		fmt.Printf("Done; %d chunk(s) resent\n", ures.RetriedChunks)
+	} else {
+		fmt.Printf("Done\n")
+	}
 }
 
 func coreListCmd(cmd *cobra.Command, args []string) {
@@ -248,11 +677,62 @@ func coreListCmd(cmd *cobra.Command, args []string) {
 	}
 }
 
+func corePrintDecoded(cc *core.CoreConvert) {
+	fmt.Printf("Image hash: %x\n", cc.ImageHash)
+
+	if len(cc.Regs) == 0 {
+		fmt.Printf("No registers in core dump\n")
+	} else {
+		fmt.Printf("Registers:\n")
+		for i, reg := range cc.Regs {
+			fmt.Printf("    %-5s 0x%08x\n", cc.RegName(i), reg)
+		}
+	}
+	if pc, ok := cc.Pc(); ok {
+		fmt.Printf("Crashing PC: 0x%08x\n", pc)
+	}
+
+	fmt.Printf("Memory regions:\n")
+	for _, m := range cc.Mem {
+		fmt.Printf("    0x%08x-0x%08x (%d bytes)\n",
+			m.Addr, m.Addr+uint32(len(m.Data)), len(m.Data))
+	}
+}
+
+// Checks the downloaded core dump before it gets saved, so that a corrupt
+// download is never mistaken for a good one.
+func coreParseFile(filename string) (*core.CoreConvert, error) {
+	cc := core.NewCoreConvert()
+
+	var err error
+	cc.Source, err = os.Open(filename)
+	if err != nil {
+		return nil, util.ChildNewtError(err)
+	}
+	defer cc.Source.Close()
+
+	if err := cc.Parse(); err != nil {
+		return nil, err
+	}
+
+	return cc, nil
+}
+
 func coreDownloadCmd(cmd *cobra.Command, args []string) {
 	if len(args) < 1 {
 		nmUsage(cmd, nil)
 	}
 
+	var expHash []byte
+	if coreSha256 != "" {
+		var err error
+		expHash, err = hex.DecodeString(coreSha256)
+		if err != nil || len(expHash) != sha256.Size {
+			nmUsage(cmd, util.FmtNewtError(
+				"Invalid SHA256 hash: %s", coreSha256))
+		}
+	}
+
 	tmpName := args[0] + ".tmp"
 	file, err := os.OpenFile(tmpName, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0660)
 	if err != nil {
@@ -267,6 +747,8 @@ func coreDownloadCmd(cmd *cobra.Command, args []string) {
 		nmUsage(nil, err)
 	}
 
+	hash := sha256.New()
+
 	c := xact.NewCoreLoadCmd()
 	c.SetTxOptions(nmutil.TxOptions())
 	c.ProgressCb = func(c *xact.CoreLoadCmd, rsp *nmp.CoreLoadRsp) {
@@ -274,6 +756,7 @@ func coreDownloadCmd(cmd *cobra.Command, args []string) {
 		if _, err := file.Write(rsp.Data); err != nil {
 			nmUsage(nil, util.ChildNewtError(err))
 		}
+		hash.Write(rsp.Data)
 	}
 
 	res, err := c.Run(s)
@@ -282,11 +765,27 @@ func coreDownloadCmd(cmd *cobra.Command, args []string) {
 	}
 
 	sres := res.(*xact.CoreLoadResult)
-	if sres.Status() != 0 {
+	switch sres.Status() {
+	case 0:
+	case nmp.NMP_ERR_ENOENT:
+		fmt.Printf("No core dump present\n")
+		return
+	default:
 		fmt.Printf("Error: %d\n", sres.Status())
 		return
 	}
 
+	sum := hash.Sum(nil)
+	if expHash != nil && !bytes.Equal(sum, expHash) {
+		nmUsage(nil, util.FmtNewtError(
+			"Core dump hash mismatch; have=%x want=%x", sum, expHash))
+	}
+
+	cc, err := coreParseFile(tmpName)
+	if err != nil {
+		nmUsage(nil, err)
+	}
+
 	if !coreElfify {
 		os.Rename(tmpName, args[0])
 		fmt.Printf("Done writing core file to %s\n", args[0])
@@ -300,9 +799,16 @@ func coreDownloadCmd(cmd *cobra.Command, args []string) {
 		fmt.Printf("Done writing core file to %s; hash=%x\n", args[0],
 			coreConvert.ImageHash)
 	}
+	fmt.Printf("Core dump sha256=%x\n", sum)
+
+	if coreDecode {
+		corePrintDecoded(cc)
+	}
 }
 
 func coreEraseCmd(cmd *cobra.Command, args []string) {
+	ConfirmOrAbort("Erase the core dump?")
+
 	s, err := GetSesn()
 	if err != nil {
 		nmUsage(nil, err)
@@ -326,6 +832,8 @@ func coreEraseCmd(cmd *cobra.Command, args []string) {
 }
 
 func imageEraseCmd(cmd *cobra.Command, args []string) {
+	ConfirmOrAbort("Erase the unused image slot?")
+
 	s, err := GetSesn()
 	if err != nil {
 		nmUsage(nil, err)
@@ -377,8 +885,50 @@ func imageCmd() *cobra.Command {
 		Short: "Show images on a device",
 		Run:   imageStateListCmd,
 	}
+	listCmd.PersistentFlags().BoolVarP(&imageListJson, "json", "j", false,
+		"Print images and boot loader info as JSON")
+	listCmd.PersistentFlags().BoolVar(&imageListReconnect, "reconnect", false,
+		"If the connection drops during the read (e.g., the device resets), "+
+			"reconnect and retry once")
 	imageCmd.AddCommand(listCmd)
 
+	bootInfoCmd := &cobra.Command{
+		Use:   "bootinfo -c <conn_profile>",
+		Short: "Show boot loader name and mode",
+		Long: "Show the boot loader name and, for MCUboot, its mode and " +
+			"downgrade policy.  Devices that do not implement the " +
+			"boot loader info command report it as unsupported.",
+		Run: imageBootInfoCmd,
+	}
+	imageCmd.AddCommand(bootInfoCmd)
+
+	hashCmd := &cobra.Command{
+		Use:   "hash [--slot N] -c <conn_profile>",
+		Short: "Print the hash of the image in a slot",
+		Long: "Print only the hex hash of the image in the specified slot.  " +
+			"Nothing is printed and the exit status is non-zero if the " +
+			"slot is empty.",
+		Example: "  " + nmutil.ToolInfo.ExeName +
+			" -c olimex image hash --slot 0\n",
+		Run: imageHashCmd,
+	}
+	hashCmd.Flags().IntVarP(&imageHashSlot, "slot", "s", 0,
+		"Slot whose image hash to print")
+	hashCmd.Flags().IntVarP(&imageNum, "image", "n", 0,
+		"In a multi-image system, which image's slot to read")
+	imageCmd.AddCommand(hashCmd)
+
+	infoCmd := &cobra.Command{
+		Use:   "info <image-file>",
+		Short: "Show the header and TLV trailer of a local image file",
+		Long: "Parse an image file on this host and print its header and " +
+			"each TLV's type, length, and value.  No device is contacted.",
+		Example: "  " + nmutil.ToolInfo.ExeName +
+			" image info bin/slinky_zero/apps/slinky.img\n",
+		Run: imageInfoCmd,
+	}
+	imageCmd.AddCommand(infoCmd)
+
 	testCmd := &cobra.Command{
 		Use:   "test <hex-image-hash>",
 		Short: "Test an image on next reboot",
@@ -396,11 +946,30 @@ func imageCmd() *cobra.Command {
 	}
 	imageCmd.AddCommand(confirmCmd)
 
+	swapCmd := &cobra.Command{
+		Use:   "swap <none|test|perm> [hex-image-hash] -c <conn_profile>",
+		Short: "Set the swap to perform on next reboot",
+		Long: "Request a specific swap type on the next reboot.  'test' " +
+			"and 'perm' require the hash of the image to swap in; 'test' " +
+			"reverts on the following reboot unless confirmed.  'none' " +
+			"confirms the running image, cancelling its pending revert.  " +
+			"It does not clear a test swap that is already pending for " +
+			"the next reboot; the device has no request that does.",
+		Example: "  " + nmutil.ToolInfo.ExeName +
+			" -c olimex image swap test <hex-image-hash>\n" +
+			"  " + nmutil.ToolInfo.ExeName + " -c olimex image swap none\n",
+		Run: imageSwapCmd,
+	}
+	imageCmd.AddCommand(swapCmd)
+
 	uploadEx := "  " + nmutil.ToolInfo.ExeName +
 		" -c olimex image upload bin/slinky_zero/apps/slinky.img\n"
+	uploadEx += "  " + nmutil.ToolInfo.ExeName +
+		" -c olimex image upload --url https://ci.example.com/slinky.img " +
+		"--header \"Authorization: Bearer $TOKEN\"\n"
 
 	uploadCmd := &cobra.Command{
-		Use:     "upload <image-file> -c <conn_profile>",
+		Use:     "upload [<image-file> | --url <url>] -c <conn_profile>",
 		Short:   "Upload image to a device",
 		Example: uploadEx,
 		Run:     imageUploadCmd,
@@ -412,13 +981,39 @@ func imageCmd() *cobra.Command {
 		"upgrade", "u", false,
 		"Only allow the upload if the new image's version is greater than "+
 			"that of the currently running image")
+	uploadCmd.PersistentFlags().BoolVar(&denyDowngrade,
+		"deny-downgrade", false,
+		"Refuse to upload an image older than the device's confirmed image")
+	uploadCmd.PersistentFlags().BoolVar(&allowDowngrade,
+		"allow-downgrade", false,
+		"Override --deny-downgrade (e.g., when it is set in a script)")
 	uploadCmd.PersistentFlags().IntVarP(&imageNum,
 		"image", "n", 0,
 		"In a multi-image system, which image should be uploaded")
+	uploadCmd.PersistentFlags().StringVar(&imageUploadUrl, "url", "",
+		"Fetch the image from an HTTP(S) URL instead of a local file")
+	uploadCmd.PersistentFlags().StringArrayVar(&imageUploadHeaders,
+		"header", nil,
+		"Extra HTTP header for --url, as \"Name: value\"; may be repeated")
+	uploadCmd.PersistentFlags().StringVar(&imageUploadSha256, "sha256", "",
+		"Refuse to upload unless the image's SHA256 matches this hex digest")
+	uploadCmd.PersistentFlags().IntVarP(&imageUploadSlot,
+		"slot", "s", xact.IMAGE_UPLOAD_SLOT_DEFAULT,
+		"Slot to upload the image into; by default the device chooses")
+	uploadCmd.PersistentFlags().StringVar(&imageUploadStateFile,
+		"state-file", "",
+		"Record upload progress in this file and resume from it if a "+
+			"previous upload of the same image was interrupted")
 	uploadCmd.PersistentFlags().IntVarP(&maxWinSz,
 		"maxwinsize", "w", xact.IMAGE_UPLOAD_DEF_MAX_WS,
 		"Set the maximum size for the window of outstanding chunks in transit. "+
 			"caution:higher num may not translate to better perf and may result in errors")
+	uploadCmd.PersistentFlags().IntVar(&imageUploadChunkRetries,
+		"chunk-retries", xact.IMAGE_UPLOAD_DEF_CHUNK_RETRIES,
+		"Number of times to resend a failed chunk before giving up")
+	uploadCmd.PersistentFlags().BoolVar(&imageUploadFlush, "flush", false,
+		"Finish with a zero-length chunk for firmware that needs an "+
+			"explicit end-of-image marker")
 	imageCmd.AddCommand(uploadCmd)
 
 	coreListCmd := &cobra.Command{
@@ -433,10 +1028,15 @@ func imageCmd() *cobra.Command {
 		" -c olimex image coredownload -e core\n"
 	coreEx += "  " + nmutil.ToolInfo.ExeName +
 		" -c olimex image coredownload --offset 10 -n 10 core\n"
+	coreEx += "  " + nmutil.ToolInfo.ExeName +
+		" -c olimex image coredownload --decode core\n"
 
 	coreDownloadCmd := &cobra.Command{
-		Use:     "coredownload <core-file> -c <conn_profile>",
-		Short:   "Download core from a device",
+		Use:   "coredownload <core-file> -c <conn_profile>",
+		Short: "Download core from a device",
+		Long: "Download the core dump from a device and save it to a file.  " +
+			"The dump's header and TLVs are checked before the file is " +
+			"written, and its SHA256 hash is reported.",
 		Example: coreEx,
 		Run:     coreDownloadCmd,
 	}
@@ -445,6 +1045,10 @@ func imageCmd() *cobra.Command {
 	coreDownloadCmd.Flags().Uint32Var(&coreOffset, "offset", 0, "Start offset")
 	coreDownloadCmd.Flags().Uint32VarP(&coreNumBytes, "bytes", "n", 0,
 		"Number of bytes of the core to download")
+	coreDownloadCmd.Flags().BoolVarP(&coreDecode, "decode", "d", false,
+		"Print the fault registers and crashing PC")
+	coreDownloadCmd.Flags().StringVar(&coreSha256, "sha256", "",
+		"Fail unless the downloaded dump has this SHA256 hash (hex)")
 	imageCmd.AddCommand(coreDownloadCmd)
 
 	coreEraseEx := "  " + nmutil.ToolInfo.ExeName +
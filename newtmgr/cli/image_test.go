@@ -0,0 +1,85 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package cli
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestImageUpgradeCmdUrl(t *testing.T) {
+	image := bytes.Repeat([]byte{0x3d, 0xb8, 0xf3, 0x96}, 64)
+
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/image":
+				w.Write(image)
+			case "/chunked":
+				// Flushing before the body is written forces a chunked
+				// response with no Content-Length.
+				w.(http.Flusher).Flush()
+				w.Write(image)
+			case "/private":
+				if r.Header.Get("Authorization") != "Bearer abc" {
+					http.Error(w, "denied", http.StatusUnauthorized)
+					return
+				}
+				w.Write(image)
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		path    string
+		headers []string
+		wantErr bool
+	}{
+		{"known length", "/image", nil, false},
+		{"unknown length", "/chunked", nil, false},
+		{"header", "/private", []string{"Authorization: Bearer abc"}, false},
+		{"missing header", "/private", nil, true},
+		{"malformed header", "/image", []string{"Authorization"}, true},
+		{"empty header name", "/image", []string{": x"}, true},
+		{"not found", "/nothing", nil, true},
+	}
+
+	for _, tt := range tests {
+		c, err := imageUpgradeCmdUrl(srv.URL+tt.path, tt.headers)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: no error", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if !bytes.Equal(c.Data, image) {
+			t.Errorf("%s: fetched image differs", tt.name)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package cli
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/newtmgr/nmutil"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/xact"
+	"mynewt.apache.org/newt/util"
+)
+
+var infoJson bool
+var infoHwIdVar string
+
+type infoJsonOut struct {
+	HwId   string           `json:"hwid,omitempty"`
+	Images []imageJsonEntry `json:"images"`
+	Boot   xact.BootInfo    `json:"boot"`
+}
+
+func infoRunCmd(cmd *cobra.Command, args []string) {
+	s, err := GetSesn()
+	if err != nil {
+		nmUsage(nil, err)
+	}
+
+	out := infoJsonOut{
+		Images: []imageJsonEntry{},
+	}
+
+	// Each part is best effort; a device that lacks one still reports the
+	// others.
+	hwIdErr := ""
+	if id, err := hwIdRead(s, infoHwIdVar); err != nil {
+		hwIdErr = err.Error()
+	} else {
+		out.HwId = hex.EncodeToString(id)
+	}
+
+	ic := xact.NewImageStateReadCmd()
+	ic.SetTxOptions(nmutil.TxOptions())
+	res, err := ic.Run(s)
+	if err != nil {
+		nmUsage(nil, util.ChildNewtError(err))
+	}
+	rsp := res.(*xact.ImageStateReadResult).Rsp
+	if rsp.Rc == 0 {
+		for _, img := range rsp.Images {
+			out.Images = append(out.Images, imageJsonEntry{
+				Image:     img.Image,
+				Slot:      img.Slot,
+				Version:   img.Version,
+				Hash:      hex.EncodeToString(img.Hash),
+				Bootable:  img.Bootable,
+				Pending:   img.Pending,
+				Confirmed: img.Confirmed,
+				Active:    img.Active,
+				Permanent: img.Permanent,
+				SwapType:  img.SwapType().String(),
+			})
+		}
+	}
+
+	bc := xact.NewBootInfoCmd()
+	bc.SetTxOptions(nmutil.TxOptions())
+	if res, err := bc.Run(s); err == nil {
+		out.Boot = res.(*xact.BootInfoResult).Info
+	}
+
+	if infoJson {
+		j, err := json.MarshalIndent(out, "", "    ")
+		if err != nil {
+			nmUsage(nil, util.ChildNewtError(err))
+		}
+		fmt.Println(string(j))
+		return
+	}
+
+	if out.HwId != "" {
+		fmt.Printf("Hardware id: %s\n", out.HwId)
+	} else {
+		fmt.Printf("Hardware id: unavailable (%s)\n", hwIdErr)
+	}
+
+	if rsp.Rc != 0 {
+		fmt.Printf("Images: error %d\n", rsp.Rc)
+	} else {
+		fmt.Printf("Images:\n")
+		for _, img := range rsp.Images {
+			fmt.Printf(" image=%d slot=%d version=%s flags=%s\n",
+				img.Image, img.Slot, img.Version, imageFlagsStr(img))
+		}
+	}
+
+	if !out.Boot.Supported {
+		fmt.Printf("Boot loader: unavailable\n")
+	} else {
+		fmt.Printf("Boot loader: %s\n", out.Boot.Bootloader)
+		if out.Boot.Mode != nmp.BOOT_MODE_UNKNOWN {
+			fmt.Printf("    mode: %s (%d)\n", out.Boot.ModeName,
+				out.Boot.Mode)
+		}
+	}
+}
+
+func infoCmd() *cobra.Command {
+	infoCmd := &cobra.Command{
+		Use:   "info -c <conn_profile>",
+		Short: "Show a summary of a device's identity and firmware",
+		Long: "Show the device's hardware id, the images in its slots and " +
+			"its boot loader.  Details the device doesn't expose are " +
+			"reported as unavailable.",
+		Run: infoRunCmd,
+	}
+
+	infoCmd.PersistentFlags().BoolVarP(&infoJson, "json", "j", false,
+		"Print the summary as JSON")
+	infoCmd.PersistentFlags().StringVar(&infoHwIdVar, "hwid-var",
+		xact.HWID_DFLT_VAR, "Config variable holding the hardware id")
+
+	return infoCmd
+}
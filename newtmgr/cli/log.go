@@ -23,8 +23,12 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -36,7 +40,27 @@ import (
 	"mynewt.apache.org/newt/util"
 )
 
+// How long each reconnection attempt waits for the device when following a
+// log with --reconnect.  Attempts repeat until the device responds.
+const LOG_FOLLOW_RECONNECT_WAIT = 10 * time.Second
+
 var optLogShowFull bool
+var optLogFollow bool
+var optLogPoll float64
+var optLogFollowOut string
+var optLogRotateSize string
+var optLogRotateKeep int
+var optLogTee bool
+var optLogReconnect bool
+var optLogCrashModule string
+
+// Fault report fields, in the order they are listed.  Fields not listed
+// here follow in alphabetical order.
+var logCrashFieldOrder = []string{
+	"rsn", "reason", "die", "pc", "lr", "sp", "psr",
+	"r0", "r1", "r2", "r3", "r4", "r5", "r6", "r7",
+	"r8", "r9", "r10", "r11", "r12",
+}
 
 // Converts the provided CBOR map to a JSON string.
 func logCborMsgText(cborMap []byte) (string, error) {
@@ -105,12 +129,17 @@ func printLogShowRsp(rsp *nmp.LogShowRsp, printHdr bool) {
 		return
 	}
 
+	fprintLogShowRsp(os.Stdout, rsp, printHdr)
+}
+
+// Writes the entries in a log show response to w.  Each entry is written
+// with a single call to w.Write.
+func fprintLogShowRsp(w io.Writer, rsp *nmp.LogShowRsp, printHdr bool) {
 	for _, log := range rsp.Logs {
 		if printHdr {
-			fmt.Printf("Name: %s\n", log.Name)
-			fmt.Printf("Type: %s\n", nmp.LogTypeToString(log.Type))
-
-			fmt.Printf("%10s %22s | %16s %16s %6s %8s %s\n",
+			fmt.Fprintf(w, "Name: %s\nType: %s\n"+
+				"%10s %22s | %16s %16s %6s %8s %s\n",
+				log.Name, nmp.LogTypeToString(log.Type),
 				"[index]", "[timestamp]", "[module]", "[level]", "[type]",
 				"[img]", "[message]")
 		}
@@ -145,7 +174,7 @@ func printLogShowRsp(rsp *nmp.LogShowRsp, printHdr bool) {
 				msgText = hex.EncodeToString(entry.Msg)
 			}
 
-			fmt.Printf("%10d %20dus | %16s %16s %6s %8s %s\n",
+			fmt.Fprintf(w, "%10d %20dus | %16s %16s %6s %8s %s\n",
 				entry.Index,
 				entry.Timestamp,
 				modText,
@@ -157,6 +186,17 @@ func printLogShowRsp(rsp *nmp.LogShowRsp, printHdr bool) {
 	}
 }
 
+// Reports a failed log operation.  A device that doesn't implement the
+// operation (NMP_ERR_ENOTSUP) gets a descriptive message instead of a bare
+// error code.
+func logPrintRc(rc int, what string) {
+	if rc == nmp.NMP_ERR_ENOTSUP {
+		fmt.Printf("This device does not support %s\n", what)
+	} else {
+		fmt.Printf("error: %d\n", rc)
+	}
+}
+
 func logShowFullCmd(s sesn.Sesn, cfg *logShowCfg) error {
 	if cfg.Name == "" {
 		return util.FmtNewtError("must specify a single log to read when `-a` is used")
@@ -170,15 +210,26 @@ func logShowFullCmd(s sesn.Sesn, cfg *logShowCfg) error {
 
 	first := true
 	c.ProgressCb = func(_ *xact.LogShowFullCmd, rsp *nmp.LogShowRsp) {
+		if rsp.Rc == nmp.NMP_ERR_ENOTSUP {
+			return
+		}
 		printLogShowRsp(rsp, first)
 		first = false
 	}
 
-	_, err := c.Run(s)
+	res, err := c.Run(s)
 	if err != nil {
 		return err
 	}
 
+	sres := res.(*xact.LogShowFullResult)
+	if len(sres.Rsps) > 0 {
+		rc := sres.Rsps[len(sres.Rsps)-1].Rc
+		if rc == nmp.NMP_ERR_ENOTSUP {
+			logPrintRc(rc, "reading logs")
+		}
+	}
+
 	return nil
 }
 
@@ -196,6 +247,11 @@ func logShowPartialCmd(s sesn.Sesn, cfg *logShowCfg) error {
 	}
 
 	sres := res.(*xact.LogShowResult)
+	if sres.Status() == nmp.NMP_ERR_ENOTSUP {
+		logPrintRc(sres.Status(), "reading logs")
+		return nil
+	}
+
 	fmt.Printf("Status: %d\n", sres.Status())
 	fmt.Printf("Next index: %d\n", sres.Rsp.NextIndex)
 	if len(sres.Rsp.Logs) == 0 {
@@ -207,6 +263,145 @@ func logShowPartialCmd(s sesn.Sesn, cfg *logShowCfg) error {
 	return nil
 }
 
+// Parses a size such as "512", "64K", or "10M".
+func parseByteSize(s string) (int64, error) {
+	mult := int64(1)
+	num := strings.ToUpper(strings.TrimSpace(s))
+
+	switch {
+	case strings.HasSuffix(num, "K"):
+		mult = 1024
+	case strings.HasSuffix(num, "M"):
+		mult = 1024 * 1024
+	case strings.HasSuffix(num, "G"):
+		mult = 1024 * 1024 * 1024
+	}
+	if mult != 1 {
+		num = num[:len(num)-1]
+	}
+
+	n, err := strconv.ParseInt(num, 10, 64)
+	if err != nil || n < 0 {
+		return 0, util.FmtNewtError("Invalid size: \"%s\"", s)
+	}
+	return n * mult, nil
+}
+
+// Waits for the device to come back after a failed poll, reopening the
+// session if the transport lost it.  Any lost contact is assumed to be a
+// reboot, which is how a device normally disappears mid-follow.
+func logFollowReconnect(w io.Writer, s sesn.Sesn, err error) {
+	fmt.Fprintf(os.Stderr, "Lost contact with device (%s); reconnecting\n",
+		err.Error())
+
+	for {
+		_, err := xact.WaitReachable(s, LOG_FOLLOW_RECONNECT_WAIT,
+			time.Second)
+		if err == nil {
+			break
+		}
+	}
+
+	fmt.Fprintf(w, "--- device rebooted ---\n")
+}
+
+// Polls a log for new entries until interrupted.  Entries are written to
+// stdout, or to a rotating file if --follow-out is specified.  With --reconnect,
+// polling survives device resets: the session is re-established and reading
+// continues from the last seen index, or from the start if the log was
+// cleared.
+func logShowFollowCmd(s sesn.Sesn, cfg *logShowCfg) error {
+	if cfg.Name == "" {
+		return util.FmtNewtError(
+			"must specify a single log to read when `--follow` is used")
+	}
+
+	var w io.Writer = os.Stdout
+	if optLogFollowOut != "" {
+		maxSize, err := parseByteSize(optLogRotateSize)
+		if err != nil {
+			return err
+		}
+
+		rf, err := nmutil.OpenRotatingFile(optLogFollowOut, maxSize,
+			optLogRotateKeep)
+		if err != nil {
+			return util.ChildNewtError(err)
+		}
+		defer rf.Close()
+
+		if optLogTee {
+			w = io.MultiWriter(rf, os.Stdout)
+		} else {
+			w = rf
+		}
+	}
+
+	poll := time.Duration(optLogPoll * float64(time.Second))
+	index := cfg.Index
+	timestamp := cfg.Timestamp
+	first := true
+	rebooted := false
+
+	for {
+		c := xact.NewLogShowCmd()
+		c.SetTxOptions(nmutil.TxOptions())
+		c.Name = cfg.Name
+		c.Index = index
+		c.Timestamp = timestamp
+
+		res, err := c.Run(s)
+		if err != nil {
+			if !optLogReconnect {
+				return err
+			}
+			logFollowReconnect(w, s, err)
+			rebooted = true
+			continue
+		}
+
+		rsp := res.(*xact.LogShowResult).Rsp
+		if rsp.Rc != 0 {
+			logPrintRc(rsp.Rc, "reading logs")
+			return nil
+		}
+
+		// The timestamp filter only applies to the initial read.
+		timestamp = 0
+
+		// After a reboot, a next index below the last one seen means the
+		// log was cleared; start over from its beginning.
+		if rebooted {
+			rebooted = false
+			if rsp.NextIndex < index {
+				fmt.Fprintf(w,
+					"--- log cleared; restarting at index 0 ---\n")
+				index = 0
+				continue
+			}
+		}
+
+		got := false
+		for _, log := range rsp.Logs {
+			if len(log.Entries) > 0 {
+				got = true
+			}
+		}
+		if got {
+			fprintLogShowRsp(w, rsp, first)
+			first = false
+		}
+
+		if rsp.NextIndex > index {
+			index = rsp.NextIndex
+		}
+
+		if !got {
+			time.Sleep(poll)
+		}
+	}
+}
+
 func logShowCmd(cmd *cobra.Command, args []string) {
 	cfg, err := logShowParseArgs(args)
 	if err != nil {
@@ -218,7 +413,16 @@ func logShowCmd(cmd *cobra.Command, args []string) {
 		nmUsage(nil, err)
 	}
 
-	if optLogShowFull {
+	if optLogFollowOut != "" && !optLogFollow {
+		nmUsage(cmd, util.NewNewtError("--follow-out requires --follow"))
+	}
+	if optLogReconnect && !optLogFollow {
+		nmUsage(cmd, util.NewNewtError("--reconnect requires --follow"))
+	}
+
+	if optLogFollow {
+		err = logShowFollowCmd(s, cfg)
+	} else if optLogShowFull {
 		err = logShowFullCmd(s, cfg)
 	} else {
 		err = logShowPartialCmd(s, cfg)
@@ -244,7 +448,7 @@ func logListCmd(cmd *cobra.Command, args []string) {
 
 	sres := res.(*xact.LogListResult)
 	if sres.Rsp.Rc != 0 {
-		fmt.Printf("error: %d\n", sres.Rsp.Rc)
+		logPrintRc(sres.Rsp.Rc, "listing logs")
 		return
 	}
 
@@ -272,7 +476,7 @@ func logModuleListCmd(cmd *cobra.Command, args []string) {
 
 	sres := res.(*xact.LogModuleListResult)
 	if sres.Rsp.Rc != 0 {
-		fmt.Printf("error: %d\n", sres.Rsp.Rc)
+		logPrintRc(sres.Rsp.Rc, "listing log modules")
 		return
 	}
 
@@ -304,7 +508,7 @@ func logLevelListCmd(cmd *cobra.Command, args []string) {
 
 	sres := res.(*xact.LogLevelListResult)
 	if sres.Rsp.Rc != 0 {
-		fmt.Printf("error: %d\n", sres.Rsp.Rc)
+		logPrintRc(sres.Rsp.Rc, "listing log levels")
 		return
 	}
 
@@ -323,6 +527,8 @@ func logLevelListCmd(cmd *cobra.Command, args []string) {
 }
 
 func logClearCmd(cmd *cobra.Command, args []string) {
+	ConfirmOrAbort("Clear all logs on the device?")
+
 	s, err := GetSesn()
 	if err != nil {
 		nmUsage(nil, err)
@@ -338,11 +544,192 @@ func logClearCmd(cmd *cobra.Command, args []string) {
 
 	sres := res.(*xact.LogClearResult)
 	if sres.Rsp.Rc != 0 {
-		fmt.Printf("error: %d\n", sres.Rsp.Rc)
+		logPrintRc(sres.Rsp.Rc, "clearing logs")
 		return
 	}
 
-	fmt.Printf("done\n")
+	if sres.Rsp.Cleared != nil {
+		fmt.Printf("done; cleared %d entries\n", *sres.Rsp.Cleared)
+	} else {
+		fmt.Printf("done\n")
+	}
+}
+
+// Resolves a log module name or number.  Names that nmxact does not know
+// are looked up in the device's module list.
+func logParseModule(s sesn.Sesn, name string) (int, error) {
+	if n, err := strconv.ParseUint(name, 0, 8); err == nil {
+		return int(n), nil
+	}
+
+	for id, mn := range nmp.LogModuleNameMap {
+		if strings.EqualFold(mn, name) {
+			return id, nil
+		}
+	}
+
+	c := xact.NewLogModuleListCmd()
+	c.SetTxOptions(nmutil.TxOptions())
+
+	res, err := c.Run(s)
+	if err != nil {
+		return 0, util.ChildNewtError(err)
+	}
+
+	for mn, id := range res.(*xact.LogModuleListResult).Rsp.Map {
+		if strings.EqualFold(mn, name) {
+			return id, nil
+		}
+	}
+
+	return 0, util.FmtNewtError("Unknown log module: %s", name)
+}
+
+func logCrashIsReg(name string) bool {
+	switch name {
+	case "pc", "lr", "sp", "psr":
+		return true
+	}
+
+	if len(name) < 2 || name[0] != 'r' {
+		return false
+	}
+	_, err := strconv.Atoi(name[1:])
+	return err == nil
+}
+
+// Extracts the fault details from a crash log entry.  CBOR entries are
+// decoded as maps; string entries as comma-separated "key:value" pairs, the
+// format of the Mynewt reboot log.  Nil is returned if the entry is in
+// neither form.
+func logCrashFields(entry nmp.LogEntry) map[string]string {
+	fields := map[string]string{}
+
+	switch entry.Type {
+	case nmp.LOG_ENTRY_TYPE_CBOR:
+		cm, err := nmxutil.DecodeCborMap(entry.Msg)
+		if err != nil {
+			return nil
+		}
+		for k, v := range cm {
+			// Register values are shown in hex.
+			switch n := v.(type) {
+			case uint64:
+				if logCrashIsReg(k) {
+					fields[k] = fmt.Sprintf("0x%08x", n)
+					continue
+				}
+			case int64:
+				if logCrashIsReg(k) {
+					fields[k] = fmt.Sprintf("0x%08x", uint32(n))
+					continue
+				}
+			}
+			fields[k] = fmt.Sprintf("%v", v)
+		}
+
+	case nmp.LOG_ENTRY_TYPE_STRING:
+		for _, part := range strings.Split(string(entry.Msg), ",") {
+			kv := strings.SplitN(strings.TrimSpace(part), ":", 2)
+			if len(kv) != 2 || kv[0] == "" {
+				return nil
+			}
+			fields[kv[0]] = kv[1]
+		}
+
+	default:
+		return nil
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
+func printLogCrashEntry(logName string, entry nmp.LogEntry) {
+	fmt.Printf("%s #%d at %dus:\n", logName, entry.Index, entry.Timestamp)
+
+	fields := logCrashFields(entry)
+	if fields == nil {
+		if entry.Type == nmp.LOG_ENTRY_TYPE_STRING {
+			fmt.Printf("    %s\n", string(entry.Msg))
+		} else {
+			fmt.Printf("    %s\n", hex.EncodeToString(entry.Msg))
+		}
+		return
+	}
+
+	for _, k := range logCrashFieldOrder {
+		if v, ok := fields[k]; ok {
+			fmt.Printf("    %-6s %s\n", k+":", v)
+			delete(fields, k)
+		}
+	}
+
+	rest := make([]string, 0, len(fields))
+	for k, _ := range fields {
+		rest = append(rest, k)
+	}
+	sort.Strings(rest)
+	for _, k := range rest {
+		fmt.Printf("    %-6s %s\n", k+":", fields[k])
+	}
+}
+
+func logCrashCmd(cmd *cobra.Command, args []string) {
+	s, err := GetSesn()
+	if err != nil {
+		nmUsage(nil, err)
+	}
+
+	mod, err := logParseModule(s, optLogCrashModule)
+	if err != nil {
+		nmUsage(cmd, err)
+	}
+
+	var rsps []*nmp.LogShowRsp
+	if len(args) > 0 {
+		c := xact.NewLogShowFullCmd()
+		c.SetTxOptions(nmutil.TxOptions())
+		c.Name = args[0]
+
+		res, err := c.Run(s)
+		if err != nil {
+			nmUsage(nil, util.ChildNewtError(err))
+		}
+		rsps = res.(*xact.LogShowFullResult).Rsps
+	} else {
+		c := xact.NewLogShowCmd()
+		c.SetTxOptions(nmutil.TxOptions())
+
+		res, err := c.Run(s)
+		if err != nil {
+			nmUsage(nil, util.ChildNewtError(err))
+		}
+		rsps = []*nmp.LogShowRsp{res.(*xact.LogShowResult).Rsp}
+	}
+
+	found := false
+	for _, rsp := range rsps {
+		if rsp.Rc != 0 {
+			logPrintRc(rsp.Rc, "reading logs")
+			return
+		}
+
+		for _, log := range rsp.Logs {
+			for _, entry := range log.Entries {
+				if int(entry.Module) == mod {
+					printLogCrashEntry(log.Name, entry)
+					found = true
+				}
+			}
+		}
+	}
+
+	if !found {
+		fmt.Printf("(no crash entries retrieved)\n")
+	}
 }
 
 func logCmd() *cobra.Command {
@@ -365,6 +752,7 @@ func logCmd() *cobra.Command {
 	logShowEx += nmutil.ToolInfo.ExeName + " log show reboot_log last -c myserial\n"
 	logShowEx += nmutil.ToolInfo.ExeName + " log show reboot_log 5 -c myserial\n"
 	logShowEx += nmutil.ToolInfo.ExeName + " log show reboot_log 3 1122222 -c myserial\n"
+	logShowEx += nmutil.ToolInfo.ExeName + " log show reboot_log --follow --follow-out soak.log --rotate-size 10M -c myserial\n"
 
 	showCmd := &cobra.Command{
 		Use:     "show [log-name [min-index [min-timestamp]]] -c <conn_profile>",
@@ -374,6 +762,22 @@ func logCmd() *cobra.Command {
 		Run:     logShowCmd,
 	}
 	showCmd.PersistentFlags().BoolVarP(&optLogShowFull, "all", "a", false, "read until end of log")
+	showCmd.PersistentFlags().BoolVarP(&optLogFollow, "follow", "f", false,
+		"keep polling for new entries until interrupted")
+	showCmd.PersistentFlags().Float64Var(&optLogPoll, "poll", 1.0,
+		"seconds to wait between polls when following")
+	showCmd.PersistentFlags().StringVar(&optLogFollowOut, "follow-out", "",
+		"write followed entries to this file instead of stdout")
+	showCmd.PersistentFlags().StringVar(&optLogRotateSize, "rotate-size",
+		"0", "rotate the output file when it would exceed this size "+
+			"(e.g., 10M); 0 disables rotation")
+	showCmd.PersistentFlags().IntVar(&optLogRotateKeep, "rotate-keep", 5,
+		"number of rotated output files to keep")
+	showCmd.PersistentFlags().BoolVar(&optLogTee, "tee", false,
+		"also print followed entries to stdout when --follow-out is used")
+	showCmd.PersistentFlags().BoolVar(&optLogReconnect, "reconnect", false,
+		"when following, wait for the device to come back if it becomes "+
+			"unreachable (e.g., it reset) instead of exiting")
 	logCmd.AddCommand(showCmd)
 
 	clearCmd := &cobra.Command{
@@ -384,6 +788,21 @@ func logCmd() *cobra.Command {
 	}
 	logCmd.AddCommand(clearCmd)
 
+	crashCmd := &cobra.Command{
+		Use:   "crash [log-name] -c <conn_profile>",
+		Short: "Show the crash entries in the logs on a device",
+		Long: "Show the log entries written by the crash module, decoding " +
+			"fault details such as the PC, LR and registers where the " +
+			"entry format allows.  Other entries are shown raw.  If " +
+			"log-name is specified, that log is read to the end; " +
+			"otherwise one response's worth of every log is read.",
+		Run: logCrashCmd,
+	}
+	crashCmd.PersistentFlags().StringVar(&optLogCrashModule, "module",
+		nmp.LogModuleToString(nmp.MODULE_REBOOT),
+		"name or number of the module that writes crash entries")
+	logCmd.AddCommand(crashCmd)
+
 	moduleListCmd := &cobra.Command{
 		Use:   "module_list -c <conn_profile>",
 		Short: "Show the log module names",
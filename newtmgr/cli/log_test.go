@@ -0,0 +1,134 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package cli
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmxutil"
+)
+
+func TestLogCrashIsReg(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"pc", true},
+		{"lr", true},
+		{"sp", true},
+		{"psr", true},
+		{"r0", true},
+		{"r12", true},
+		{"rsn", false},
+		{"r", false},
+		{"reason", false},
+		{"die", false},
+	}
+
+	for _, tt := range tests {
+		if got := logCrashIsReg(tt.name); got != tt.want {
+			t.Errorf("logCrashIsReg(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLogCrashFields(t *testing.T) {
+	cborMsg, err := nmxutil.EncodeCborMap(map[string]interface{}{
+		"rsn": "hard fault",
+		"pc":  0x8001234,
+		"r3":  7,
+		"cnt": 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		entry nmp.LogEntry
+		want  map[string]string
+	}{
+		{
+			name: "cbor",
+			entry: nmp.LogEntry{
+				Type: nmp.LOG_ENTRY_TYPE_CBOR,
+				Msg:  cborMsg,
+			},
+			want: map[string]string{
+				"rsn": "hard fault",
+				"pc":  "0x08001234",
+				"r3":  "0x00000007",
+				"cnt": "2",
+			},
+		},
+		{
+			name: "invalid cbor",
+			entry: nmp.LogEntry{
+				Type: nmp.LOG_ENTRY_TYPE_CBOR,
+				Msg:  []byte{0x01}, // An integer, not a map.
+			},
+			want: nil,
+		},
+		{
+			name: "reboot log string",
+			entry: nmp.LogEntry{
+				Type: nmp.LOG_ENTRY_TYPE_STRING,
+				Msg:  []byte("rsn:SOFT, cnt:3, img:1.2.0.0"),
+			},
+			want: map[string]string{
+				"rsn": "SOFT",
+				"cnt": "3",
+				"img": "1.2.0.0",
+			},
+		},
+		{
+			name: "free-form string",
+			entry: nmp.LogEntry{
+				Type: nmp.LOG_ENTRY_TYPE_STRING,
+				Msg:  []byte("Assert @ 0x8001234"),
+			},
+			want: nil,
+		},
+		{
+			name: "empty string",
+			entry: nmp.LogEntry{
+				Type: nmp.LOG_ENTRY_TYPE_STRING,
+			},
+			want: nil,
+		},
+		{
+			name: "binary",
+			entry: nmp.LogEntry{
+				Type: nmp.LOG_ENTRY_TYPE_BINARY,
+				Msg:  []byte("rsn:SOFT"),
+			},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		got := logCrashFields(tt.entry)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: fields = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
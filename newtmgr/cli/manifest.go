@@ -0,0 +1,227 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package cli
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/newtmgr/config"
+	"github.com/comap-smart-home/mynewt-newtmgr/newtmgr/nmutil"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/xact"
+	"mynewt.apache.org/newt/util"
+)
+
+var cmdManifestPath string
+
+// Subcommands must exist before cobra parses the command line, so the
+// manifest path is pulled out of the raw arguments ahead of time.
+func manifestPathFromArgs(args []string) string {
+	for i, arg := range args {
+		if arg == "--cmd-manifest" && i+1 < len(args) {
+			return args[i+1]
+		}
+		if strings.HasPrefix(arg, "--cmd-manifest=") {
+			return strings.TrimPrefix(arg, "--cmd-manifest=")
+		}
+	}
+	return ""
+}
+
+// Converts a decoded CBOR value into something encoding/json can marshal.
+// Byte strings are rendered as hex.
+func manifestJsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[fmt.Sprintf("%v", k)] = manifestJsonValue(e)
+		}
+		return m
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[k] = manifestJsonValue(e)
+		}
+		return m
+	case []interface{}:
+		a := make([]interface{}, len(v))
+		for i, e := range v {
+			a[i] = manifestJsonValue(e)
+		}
+		return a
+	case []byte:
+		return hex.EncodeToString(v)
+	default:
+		return v
+	}
+}
+
+func manifestParseArgs(mc config.ManifestCmd,
+	args []string) (map[string]interface{}, error) {
+
+	fields := map[string]interface{}{}
+	for _, arg := range args {
+		kv := strings.SplitN(arg, "=", 2)
+		if len(kv) != 2 {
+			return nil, util.FmtNewtError(
+				"Invalid argument \"%s\"; expected <field>=<value>", arg)
+		}
+
+		typ, ok := mc.Request[kv[0]]
+		if !ok {
+			return nil, util.FmtNewtError("Unknown field \"%s\"", kv[0])
+		}
+
+		val, err := config.ParseManifestValue(typ, kv[1])
+		if err != nil {
+			return nil, util.FmtNewtError("Invalid %s value for \"%s\": %s",
+				typ, kv[0], kv[1])
+		}
+		fields[kv[0]] = val
+	}
+
+	return fields, nil
+}
+
+func manifestRunCmd(mg config.ManifestGroup, mc config.ManifestCmd,
+	cmd *cobra.Command, args []string) {
+
+	fields, err := manifestParseArgs(mc, args)
+	if err != nil {
+		nmUsage(cmd, err)
+	}
+
+	s, err := GetSesn()
+	if err != nil {
+		nmUsage(nil, err)
+	}
+
+	c := xact.NewGenericCmd()
+	c.SetTxOptions(nmutil.TxOptions())
+	c.Op = mc.NmpOp()
+	c.Group = mg.Id
+	c.Id = mc.Id
+	c.Fields = fields
+
+	res, err := c.Run(s)
+	if err != nil {
+		nmUsage(nil, util.ChildNewtError(err))
+	}
+	gres := res.(*xact.GenericResult)
+
+	if gres.Status() != 0 {
+		fmt.Printf("Error: %d\n", gres.Status())
+		return
+	}
+
+	for name := range mc.Response {
+		if _, ok := gres.Rsp.Fields[name]; !ok {
+			fmt.Printf("Warning: response is missing field \"%s\"\n", name)
+		}
+	}
+
+	j, err := json.MarshalIndent(manifestJsonValue(gres.Rsp.Fields), "",
+		"    ")
+	if err != nil {
+		nmUsage(nil, util.ChildNewtError(err))
+	}
+	fmt.Println(string(j))
+}
+
+func manifestUsage(mc config.ManifestCmd) string {
+	names := make([]string, 0, len(mc.Request))
+	for name := range mc.Request {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	use := mc.Name
+	for _, name := range names {
+		use += fmt.Sprintf(" [%s=<%s>]", name, mc.Request[name])
+	}
+	return use + " -c <conn_profile>"
+}
+
+// Adds a subcommand for each group and command described in the manifest
+// named on the command line, if any.
+func addManifestCmds(nmCmd *cobra.Command, args []string) error {
+	path := manifestPathFromArgs(args)
+	if path == "" {
+		return nil
+	}
+
+	reserved := map[string]bool{}
+	for _, c := range nmCmd.Commands() {
+		reserved[c.Name()] = true
+	}
+	// Cobra adds these lazily.
+	reserved["help"] = true
+	reserved["completion"] = true
+
+	m, err := config.ReadCmdManifest(path, reserved)
+	if err != nil {
+		return err
+	}
+
+	for _, mg := range m.Groups {
+		mg := mg
+
+		groupCmd := &cobra.Command{
+			Use:   mg.Name,
+			Short: mg.Help,
+			Run: func(cmd *cobra.Command, args []string) {
+				cmd.HelpFunc()(cmd, args)
+			},
+		}
+		if groupCmd.Short == "" {
+			groupCmd.Short = fmt.Sprintf("User command group %d", mg.Id)
+		}
+
+		for _, mc := range mg.Commands {
+			mc := mc
+
+			if err := nmp.RegisterGenericRsp(mc.NmpOp(), mg.Id,
+				mc.Id); err != nil {
+
+				return util.ChildNewtError(err)
+			}
+
+			subCmd := &cobra.Command{
+				Use:   manifestUsage(mc),
+				Short: mc.Help,
+				Run: func(cmd *cobra.Command, args []string) {
+					manifestRunCmd(mg, mc, cmd, args)
+				},
+			}
+			groupCmd.AddCommand(subCmd)
+		}
+
+		nmCmd.AddCommand(groupCmd)
+	}
+
+	return nil
+}
@@ -0,0 +1,220 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/newtmgr/nmutil"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/xact"
+	"mynewt.apache.org/newt/util"
+)
+
+// Largest region peek will read in one invocation.
+const MEM_PEEK_MAX_LEN = 4096
+
+var memGroup int
+var memWordSize int
+var memPokeForce bool
+
+// memCheckGroup ensures --group names a per-user group.  The memory group
+// has no fixed number, so it must always be given.
+func memCheckGroup(cmd *cobra.Command) {
+	if memGroup < nmp.NMP_GROUP_PERUSER || memGroup > 0xffff {
+		nmUsage(cmd, util.FmtNewtError(
+			"--group must give the device's memory group (%d-65535)",
+			nmp.NMP_GROUP_PERUSER))
+	}
+}
+
+// memWord interprets a little-endian word.
+func memWord(b []byte) uint64 {
+	var v uint64
+	for i := len(b) - 1; i >= 0; i-- {
+		v = v<<8 | uint64(b[i])
+	}
+	return v
+}
+
+func memDump(addr uint64, data []byte, width int) {
+	for off := 0; off < len(data); off += 16 {
+		end := off + 16
+		if end > len(data) {
+			end = len(data)
+		}
+
+		words := []string{}
+		for w := off; w < end; w += width {
+			words = append(words,
+				fmt.Sprintf("%0*x", width*2, memWord(data[w:w+width])))
+		}
+		fmt.Printf("%08x: %s\n", addr+uint64(off), strings.Join(words, " "))
+	}
+}
+
+func peekRunCmd(cmd *cobra.Command, args []string) {
+	if len(args) < 2 {
+		nmUsage(cmd, nil)
+	}
+	memCheckGroup(cmd)
+
+	addr, err := strconv.ParseUint(args[0], 0, 64)
+	if err != nil {
+		nmUsage(cmd, util.FmtNewtError("Invalid address: %s", args[0]))
+	}
+	length, err := strconv.ParseUint(args[1], 0, 32)
+	if err != nil || length == 0 {
+		nmUsage(cmd, util.FmtNewtError("Invalid length: %s", args[1]))
+	}
+	if length > MEM_PEEK_MAX_LEN {
+		nmUsage(cmd, util.FmtNewtError(
+			"Length %d exceeds the maximum of %d bytes",
+			length, MEM_PEEK_MAX_LEN))
+	}
+
+	s, err := GetSesn()
+	if err != nil {
+		nmUsage(nil, err)
+	}
+
+	c := xact.NewMemReadCmd()
+	c.SetTxOptions(nmutil.TxOptions())
+	c.Group = uint16(memGroup)
+	c.Addr = addr
+	c.Len = int(length)
+	c.Width = memWordSize
+
+	res, err := c.Run(s)
+	if err != nil {
+		nmUsage(nil, util.ChildNewtError(err))
+	}
+
+	mres := res.(*xact.MemReadResult)
+	if mres.Rc != 0 {
+		fmt.Printf("Error: %d\n", mres.Rc)
+		return
+	}
+
+	memDump(addr, mres.Data, memWordSize)
+}
+
+func pokeRunCmd(cmd *cobra.Command, args []string) {
+	if len(args) < 2 {
+		nmUsage(cmd, nil)
+	}
+
+	if !memPokeForce {
+		nmUsage(cmd, util.NewNewtError(
+			"Writing device memory can crash or damage the device; "+
+				"pass --force to proceed"))
+	}
+	memCheckGroup(cmd)
+
+	addr, err := strconv.ParseUint(args[0], 0, 64)
+	if err != nil {
+		nmUsage(cmd, util.FmtNewtError("Invalid address: %s", args[0]))
+	}
+
+	// Each value is written as one little-endian word.
+	data := []byte{}
+	for _, arg := range args[1:] {
+		v, err := strconv.ParseUint(arg, 0, memWordSize*8)
+		if err != nil {
+			nmUsage(cmd, util.FmtNewtError("Invalid %d-byte value: %s",
+				memWordSize, arg))
+		}
+		for i := 0; i < memWordSize; i++ {
+			data = append(data, byte(v>>(uint(i)*8)))
+		}
+	}
+
+	s, err := GetSesn()
+	if err != nil {
+		nmUsage(nil, err)
+	}
+
+	c := xact.NewMemWriteCmd()
+	c.SetTxOptions(nmutil.TxOptions())
+	c.Group = uint16(memGroup)
+	c.Addr = addr
+	c.Width = memWordSize
+	c.Data = data
+
+	res, err := c.Run(s)
+	if err != nil {
+		nmUsage(nil, util.ChildNewtError(err))
+	}
+
+	if rc := res.Status(); rc != 0 {
+		fmt.Printf("Error: %d\n", rc)
+	} else {
+		fmt.Printf("Done\n")
+	}
+}
+
+func peekCmd() *cobra.Command {
+	peekCmd := &cobra.Command{
+		Use:   "peek <addr> <len> -c <conn_profile>",
+		Short: "Read and hex-dump device memory",
+		Long: "Read a region of device memory or peripheral registers.  " +
+			"Requires firmware that implements the memory management " +
+			"group; --group gives the per-user group number it uses.",
+		Example: "  newtmgr peek --group 100 0x20000000 64 -c profile01\n" +
+			"  newtmgr peek --group 100 --word-size 4 0x40001000 16 " +
+			"-c profile01",
+		Run: peekRunCmd,
+	}
+
+	peekCmd.PersistentFlags().IntVar(&memGroup, "group", 0,
+		"Group number of the device's memory group (required)")
+	peekCmd.PersistentFlags().IntVar(&memWordSize, "word-size", 1,
+		"Access width in bytes (1, 2, 4, or 8)")
+
+	return peekCmd
+}
+
+func pokeCmd() *cobra.Command {
+	pokeCmd := &cobra.Command{
+		Use:   "poke <addr> <value> [value...] -c <conn_profile> --force",
+		Short: "Write device memory",
+		Long: "Write one or more words to device memory or peripheral " +
+			"registers.  Each value occupies --word-size bytes and is " +
+			"written little-endian.  This can crash the device, so it " +
+			"requires --force.  --group gives the per-user group number " +
+			"of the device's memory group.",
+		Example: "  newtmgr poke --force --group 100 --word-size 4 " +
+			"0x40001000 0x1 -c profile01",
+		Run: pokeRunCmd,
+	}
+
+	pokeCmd.PersistentFlags().IntVar(&memGroup, "group", 0,
+		"Group number of the device's memory group (required)")
+	pokeCmd.PersistentFlags().IntVar(&memWordSize, "word-size", 1,
+		"Access width in bytes (1, 2, 4, or 8)")
+	pokeCmd.PersistentFlags().BoolVar(&memPokeForce, "force", false,
+		"Confirm that device memory should be written")
+
+	return pokeCmd
+}
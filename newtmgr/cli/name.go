@@ -0,0 +1,143 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package cli
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/spf13/cobra"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/newtmgr/nmutil"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/xact"
+	"mynewt.apache.org/newt/util"
+)
+
+// Config variable that holds the device name unless --key is specified.
+const NAME_DFLT_KEY = "dev/name"
+
+const NAME_MAX_LEN = 32
+
+var nameValidRe = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+var nameKey string
+var nameSave bool
+
+func nameValidate(name string) error {
+	if len(name) == 0 || len(name) > NAME_MAX_LEN {
+		return util.FmtNewtError(
+			"Invalid name: must be 1 to %d characters long", NAME_MAX_LEN)
+	}
+	if !nameValidRe.MatchString(name) {
+		return util.FmtNewtError("Invalid name: \"%s\"; only letters, "+
+			"digits, '.', '_' and '-' are allowed", name)
+	}
+	return nil
+}
+
+// nameRead reads the configured name.  A nonzero rc is returned if the
+// device rejected the read.
+func nameRead(s sesn.Sesn) (string, int) {
+	c := xact.NewConfigReadCmd()
+	c.SetTxOptions(nmutil.TxOptions())
+	c.Name = nameKey
+
+	res, err := c.Run(s)
+	if err != nil {
+		nmUsage(nil, util.ChildNewtError(err))
+	}
+
+	rsp := res.(*xact.ConfigReadResult).Rsp
+	return rsp.Val, rsp.Rc
+}
+
+func nameWrite(s sesn.Sesn, name string) int {
+	c := xact.NewConfigWriteCmd()
+	c.SetTxOptions(nmutil.TxOptions())
+	c.Name = nameKey
+	c.Val = name
+	c.Save = nameSave
+
+	res, err := c.Run(s)
+	if err != nil {
+		nmUsage(nil, util.ChildNewtError(err))
+	}
+
+	return res.Status()
+}
+
+func nameRunCmd(cmd *cobra.Command, args []string) {
+	if len(args) > 1 {
+		nmUsage(cmd, nil)
+	}
+	if len(args) == 1 {
+		if err := nameValidate(args[0]); err != nil {
+			nmUsage(cmd, err)
+		}
+	}
+
+	s, err := GetSesn()
+	if err != nil {
+		nmUsage(nil, err)
+	}
+
+	if len(args) == 1 {
+		if rc := nameWrite(s, args[0]); rc != 0 {
+			fmt.Printf("Error: %d\n", rc)
+			return
+		}
+	}
+
+	name, rc := nameRead(s)
+	if rc != 0 {
+		fmt.Printf("Error: %d\n", rc)
+		return
+	}
+
+	if len(args) == 1 && name != args[0] {
+		nmUsage(nil, util.FmtNewtError(
+			"Name not updated: device reports \"%s\"", name))
+	}
+
+	fmt.Printf("Name: %s\n", name)
+}
+
+func nameCmd() *cobra.Command {
+	nameEx := "  " + nmutil.ToolInfo.ExeName + " -c olimex name\n"
+	nameEx += "  " + nmutil.ToolInfo.ExeName + " -c olimex name --save kitchen-01\n"
+
+	nameCmd := &cobra.Command{
+		Use:   "name [new-name] -c <conn_profile>",
+		Short: "Read or set a device's name",
+		Long: "Read the device name stored in config or, if new-name is " +
+			"specified, set it and read it back to confirm.  Names are up " +
+			"to 32 letters, digits, '.', '_' or '-'.",
+		Example: nameEx,
+		Run:     nameRunCmd,
+	}
+
+	nameCmd.PersistentFlags().StringVar(&nameKey, "key", NAME_DFLT_KEY,
+		"Config variable that holds the name")
+	nameCmd.PersistentFlags().BoolVar(&nameSave, "save", false,
+		"Persist the new name so that it survives a reset")
+
+	return nameCmd
+}
@@ -0,0 +1,50 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNameValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"kitchen-01", true},
+		{"a", true},
+		{"Node_7.b", true},
+		{strings.Repeat("x", NAME_MAX_LEN), true},
+		{strings.Repeat("x", NAME_MAX_LEN+1), false},
+		{"", false},
+		{"two words", false},
+		{"semi;colon", false},
+		{"café", false},
+	}
+
+	for _, tt := range tests {
+		err := nameValidate(tt.name)
+		if (err == nil) != tt.valid {
+			t.Errorf("nameValidate(%q) = %v, want valid=%v",
+				tt.name, err, tt.valid)
+		}
+	}
+}
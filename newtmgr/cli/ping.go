@@ -0,0 +1,59 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/newtmgr/nmutil"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/xact"
+	"mynewt.apache.org/newt/util"
+)
+
+func pingRunCmd(cmd *cobra.Command, args []string) {
+	s, err := GetSesn()
+	if err != nil {
+		nmUsage(nil, err)
+	}
+
+	rtt, err := xact.Ping(s, nmutil.TxOptions())
+	if err != nil {
+		nmUsage(nil, util.ChildNewtError(err))
+	}
+
+	fmt.Printf("%.3f\n", float64(rtt)/float64(time.Millisecond))
+}
+
+func pingCmd() *cobra.Command {
+	pingCmd := &cobra.Command{
+		Use:   "ping -c <conn_profile>",
+		Short: "Check that a device responds to an echo request",
+		Long: "Send one echo request.  If the device responds within the " +
+			"timeout, print the round-trip time in milliseconds and exit " +
+			"with status 0; otherwise exit with a non-zero status.  " +
+			"Suitable for health-check scripts.",
+		Run: pingRunCmd,
+	}
+
+	return pingCmd
+}
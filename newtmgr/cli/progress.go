@@ -0,0 +1,100 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	pb "gopkg.in/cheggaaa/pb.v1"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/newtmgr/nmutil"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/xact"
+	"mynewt.apache.org/newt/util"
+)
+
+// Values accepted by --progress.
+const (
+	PROGRESS_STYLE_BAR  = "bar"
+	PROGRESS_STYLE_JSON = "json"
+	PROGRESS_STYLE_NONE = "none"
+)
+
+func validateProgressStyle(style string) error {
+	switch style {
+	case PROGRESS_STYLE_BAR, PROGRESS_STYLE_JSON, PROGRESS_STYLE_NONE:
+		return nil
+	default:
+		return util.FmtNewtError(
+			"Invalid --progress value: %s (must be bar, json, or none)", style)
+	}
+}
+
+// newProgressRenderer returns a callback that presents progress events in the
+// style selected with --progress, along with a function to call once the
+// command completes.  A bar style draws one progress bar per phase; the json
+// style writes each event as a line of JSON.
+func newProgressRenderer() (xact.ProgressEventFn, func()) {
+	switch nmutil.Progress {
+	case PROGRESS_STYLE_NONE:
+		return nil, func() {}
+
+	case PROGRESS_STYLE_JSON:
+		enc := json.NewEncoder(statusOut)
+		return func(ev xact.ProgressEvent) {
+			enc.Encode(ev)
+		}, func() {}
+
+	default:
+		var bar *pb.ProgressBar
+		phase := ""
+
+		finish := func() {
+			if bar != nil {
+				bar.Finish()
+				bar = nil
+			}
+		}
+
+		return func(ev xact.ProgressEvent) {
+			if ev.Phase != phase {
+				finish()
+				phase = ev.Phase
+
+				if ev.Total == 0 {
+					if ev.Message != "" {
+						fmt.Fprintf(statusOut, "%s\n", ev.Message)
+					}
+					return
+				}
+
+				bar = pb.New(ev.Total)
+				bar.Output = statusOut
+				bar.SetUnits(pb.U_BYTES)
+				bar.ShowSpeed = true
+				bar.Start()
+			}
+
+			if bar != nil {
+				bar.Set(ev.Current)
+			}
+		}, finish
+	}
+}
@@ -0,0 +1,303 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package cli
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/newtmgr/config"
+	"github.com/comap-smart-home/mynewt-newtmgr/newtmgr/nmutil"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/xact"
+	"mynewt.apache.org/newt/util"
+)
+
+const SEQ_DEF_WAIT_TIMEOUT = 60 * time.Second
+
+// State carried from one step of a sequence to the next.
+type seqState struct {
+	s sesn.Sesn
+
+	// Hash of the image most recently uploaded by the sequence; the default
+	// target of a test step.
+	uploadHash []byte
+}
+
+func seqCheckRc(what string, res xact.Result) error {
+	if res.Status() != 0 {
+		return fmt.Errorf("%s failed; rc=%d", what, res.Status())
+	}
+	return nil
+}
+
+func seqUpload(st *seqState, ss *config.SequenceStep) error {
+	c, err := xact.NewImageUpgradeCmdFile(ss.File)
+	if err != nil {
+		return err
+	}
+	c.SetTxOptions(nmutil.TxOptions())
+	c.ImageNum = ss.Image
+	c.Upgrade = ss.Upgrade
+
+	progressCb, progressDone := newProgressRenderer()
+	c.ProgressEventCb = progressCb
+	res, err := c.Run(st.s)
+	progressDone()
+	if err != nil {
+		return err
+	}
+	if err := seqCheckRc("image upload", res); err != nil {
+		return err
+	}
+
+	// Remember the new image's hash so that a later test step need not
+	// specify it.
+	rc := xact.NewImageStateReadCmd()
+	rc.SetTxOptions(nmutil.TxOptions())
+	rres, err := rc.Run(st.s)
+	if err != nil {
+		return err
+	}
+	if err := seqCheckRc("image state read", rres); err != nil {
+		return err
+	}
+
+	st.uploadHash = nil
+	for _, img := range rres.(*xact.ImageStateReadResult).Rsp.Images {
+		if img.Image == ss.Image && !img.Active && len(img.Hash) > 0 {
+			st.uploadHash = img.Hash
+		}
+	}
+
+	return nil
+}
+
+// The device acknowledges the request before resetting; waiting for it to go
+// down makes sure a following step doesn't reach the old firmware.
+func seqReset(st *seqState, ss *config.SequenceStep) error {
+	return xact.ResetAndWait(st.s, nmutil.TxOptions())
+}
+
+func seqWait(st *seqState, ss *config.SequenceStep) error {
+	timeout := SEQ_DEF_WAIT_TIMEOUT
+	if ss.Timeout > 0 {
+		timeout = time.Duration(ss.Timeout * float64(time.Second))
+	}
+
+	_, err := xact.WaitReachable(st.s, timeout, time.Second)
+	return err
+}
+
+func seqStateWrite(st *seqState, ss *config.SequenceStep) error {
+	hash, err := ss.HashBytes()
+	if err != nil {
+		return err
+	}
+
+	confirm := ss.Op == config.SEQ_OP_CONFIRM
+	if hash == nil && !confirm {
+		if st.uploadHash == nil {
+			return fmt.Errorf("no hash specified and no image uploaded")
+		}
+		hash = st.uploadHash
+	}
+
+	c := xact.NewImageStateWriteCmd()
+	c.SetTxOptions(nmutil.TxOptions())
+	c.Hash = hash
+	c.Confirm = confirm
+
+	res, err := c.Run(st.s)
+	if err != nil {
+		return err
+	}
+	return seqCheckRc("image state write", res)
+}
+
+func seqConfigSet(st *seqState, ss *config.SequenceStep) error {
+	c := xact.NewConfigWriteCmd()
+	c.SetTxOptions(nmutil.TxOptions())
+	c.Name = ss.Name
+	c.Val = ss.Value
+	c.Save = ss.Save
+
+	res, err := c.Run(st.s)
+	if err != nil {
+		return err
+	}
+	return seqCheckRc("config write", res)
+}
+
+func seqFsUpload(st *seqState, ss *config.SequenceStep) error {
+	data, err := ioutil.ReadFile(ss.File)
+	if err != nil {
+		return err
+	}
+
+	c := xact.NewFsUploadCmd()
+	c.SetTxOptions(nmutil.TxOptions())
+	c.Name = ss.Name
+	c.Data = data
+
+	res, err := c.Run(st.s)
+	if err != nil {
+		return err
+	}
+	return seqCheckRc("fs upload", res)
+}
+
+func seqFsDownload(st *seqState, ss *config.SequenceStep) error {
+	file, err := os.Create(ss.File)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	c := xact.NewFsDownloadCmd()
+	c.SetTxOptions(nmutil.TxOptions())
+	c.Name = ss.Name
+	c.Writer = file
+
+	res, err := c.Run(st.s)
+	if err != nil {
+		return err
+	}
+	return seqCheckRc("fs download", res)
+}
+
+type seqRunner func(*seqState, *config.SequenceStep) error
+
+var seqRunners = map[string]seqRunner{
+	config.SEQ_OP_UPLOAD:      seqUpload,
+	config.SEQ_OP_RESET:       seqReset,
+	config.SEQ_OP_WAIT:        seqWait,
+	config.SEQ_OP_TEST:        seqStateWrite,
+	config.SEQ_OP_CONFIRM:     seqStateWrite,
+	config.SEQ_OP_CONFIG_SET:  seqConfigSet,
+	config.SEQ_OP_FS_UPLOAD:   seqFsUpload,
+	config.SEQ_OP_FS_DOWNLOAD: seqFsDownload,
+}
+
+// runSequence runs the steps of sq in order with the given runners, reporting
+// each step's outcome to w.  It stops at the first failed step unless that
+// step sets continue-on-error, and returns the number of steps that failed.
+func runSequence(st *seqState, sq *config.Sequence,
+	runners map[string]seqRunner, w io.Writer) int {
+
+	failed := 0
+	for i := range sq.Steps {
+		ss := &sq.Steps[i]
+		fmt.Fprintf(w, "[%d/%d] %s: ", i+1, len(sq.Steps), ss.Op)
+
+		start := time.Now()
+		err := runners[ss.Op](st, ss)
+		d := time.Since(start).Seconds()
+
+		if err == nil {
+			fmt.Fprintf(w, "ok (%.1fs)\n", d)
+			continue
+		}
+
+		failed++
+		if ss.ContinueOnError {
+			fmt.Fprintf(w, "failed, continuing (%.1fs): %s\n", d,
+				err.Error())
+			continue
+		}
+
+		fmt.Fprintf(w, "failed (%.1fs): %s\n", d, err.Error())
+		for j := i + 1; j < len(sq.Steps); j++ {
+			fmt.Fprintf(w, "[%d/%d] %s: skipped\n", j+1, len(sq.Steps),
+				sq.Steps[j].Op)
+		}
+		break
+	}
+
+	return failed
+}
+
+func sequenceRunCmd(cmd *cobra.Command, args []string) {
+	if len(args) < 1 {
+		nmUsage(cmd, util.NewNewtError("Need to specify sequence file"))
+	}
+
+	sq, err := config.ReadSequence(args[0])
+	if err != nil {
+		nmUsage(nil, err)
+	}
+
+	s, err := GetSesn()
+	if err != nil {
+		nmUsage(nil, err)
+	}
+
+	failed := runSequence(&seqState{s: s}, sq, seqRunners, os.Stdout)
+	if failed > 0 {
+		nmUsage(nil, util.FmtNewtError("%d of %d steps failed", failed,
+			len(sq.Steps)))
+	}
+}
+
+func sequenceCmd() *cobra.Command {
+	sequenceEx := "  " + nmutil.ToolInfo.ExeName +
+		" -c olimex sequence provision.yml\n"
+
+	sequenceHelpText := "Run the steps listed in a YAML sequence file, in " +
+		"order, against one device.  Each step has an op and the fields " +
+		"that op needs:\n\n" +
+		"  upload       file, [image], [upgrade]\n" +
+		"  reset        (returns once the device has gone down)\n" +
+		"  wait         [timeout] (seconds; default 60)\n" +
+		"  test         [hash] (default: the image last uploaded)\n" +
+		"  confirm      [hash] (default: the running image)\n" +
+		"  config-set   name, value, [save]\n" +
+		"  fs-upload    file (local), name (on the device)\n" +
+		"  fs-download  name (on the device), file (local)\n\n" +
+		"The sequence stops at the first failed step unless that step " +
+		"sets continue-on-error.  The exit status is non-zero if any " +
+		"step failed.  For example:\n\n" +
+		"  steps:\n" +
+		"    - op: upload\n" +
+		"      file: bin/slinky.img\n" +
+		"    - op: test\n" +
+		"    - op: reset\n" +
+		"    - op: wait\n" +
+		"    - op: confirm\n" +
+		"    - op: config-set\n" +
+		"      name: id/serial\n" +
+		"      value: \"1234\"\n" +
+		"      save: true\n"
+
+	sequenceCmd := &cobra.Command{
+		Use:     "sequence <sequence-file> -c <conn_profile>",
+		Short:   "Run a scripted sequence of operations",
+		Long:    sequenceHelpText,
+		Example: sequenceEx,
+		Run:     sequenceRunCmd,
+	}
+
+	return sequenceCmd
+}
@@ -0,0 +1,123 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/newtmgr/config"
+)
+
+func TestRunSequence(t *testing.T) {
+	tests := []struct {
+		name   string
+		steps  []config.SequenceStep
+		ran    []string
+		failed int
+		output []string
+	}{
+		{
+			name: "all pass",
+			steps: []config.SequenceStep{
+				{Op: config.SEQ_OP_RESET},
+				{Op: config.SEQ_OP_WAIT},
+			},
+			ran:    []string{"reset", "wait"},
+			output: []string{"[1/2] reset: ok", "[2/2] wait: ok"},
+		},
+		{
+			name: "stop on failure",
+			steps: []config.SequenceStep{
+				{Op: config.SEQ_OP_RESET},
+				{Op: config.SEQ_OP_TEST},
+				{Op: config.SEQ_OP_WAIT},
+			},
+			ran:    []string{"reset", "test"},
+			failed: 1,
+			output: []string{
+				"[1/3] reset: ok",
+				"[2/3] test: failed",
+				"[3/3] wait: skipped",
+			},
+		},
+		{
+			name: "continue on error",
+			steps: []config.SequenceStep{
+				{Op: config.SEQ_OP_TEST, ContinueOnError: true},
+				{Op: config.SEQ_OP_WAIT},
+				{Op: config.SEQ_OP_TEST},
+				{Op: config.SEQ_OP_RESET},
+			},
+			ran:    []string{"test", "wait", "test"},
+			failed: 2,
+			output: []string{
+				"[1/4] test: failed, continuing",
+				"[2/4] wait: ok",
+				"[3/4] test: failed (",
+				"[4/4] reset: skipped",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ran []string
+			runner := func(st *seqState, ss *config.SequenceStep) error {
+				ran = append(ran, ss.Op)
+				if ss.Op == config.SEQ_OP_TEST {
+					return fmt.Errorf("injected failure")
+				}
+				return nil
+			}
+			runners := map[string]seqRunner{
+				config.SEQ_OP_RESET: runner,
+				config.SEQ_OP_WAIT:  runner,
+				config.SEQ_OP_TEST:  runner,
+			}
+
+			var out bytes.Buffer
+			sq := &config.Sequence{Steps: tt.steps}
+			failed := runSequence(&seqState{}, sq, runners, &out)
+
+			if failed != tt.failed {
+				t.Errorf("failed = %d, want %d", failed, tt.failed)
+			}
+			if !reflect.DeepEqual(ran, tt.ran) {
+				t.Errorf("ran %v, want %v", ran, tt.ran)
+			}
+
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			if len(lines) != len(tt.output) {
+				t.Fatalf("output:\n%s\nwant %d lines", out.String(),
+					len(tt.output))
+			}
+			for i, prefix := range tt.output {
+				if !strings.HasPrefix(lines[i], prefix) {
+					t.Errorf("line %d = %q, want prefix %q", i, lines[i],
+						prefix)
+				}
+			}
+		})
+	}
+}
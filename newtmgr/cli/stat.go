@@ -20,6 +20,7 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
 	"sort"
 
@@ -63,7 +64,82 @@ func statsListRunCmd(cmd *cobra.Command, args []string) {
 	}
 }
 
+var statAll bool
+var statJson bool
+
+func printStatFields(fields map[string]interface{}) {
+	if len(fields) == 0 {
+		fmt.Printf("    (empty)\n")
+		return
+	}
+
+	names := make([]string, 0, len(fields))
+	for k, _ := range fields {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	for _, n := range names {
+		fmt.Printf("%10d %s\n", fields[n], n)
+	}
+}
+
+func statsAllRunCmd() {
+	s, err := GetSesn()
+	if err != nil {
+		nmUsage(nil, err)
+	}
+
+	c := xact.NewStatReadAllCmd()
+	c.SetTxOptions(nmutil.TxOptions())
+
+	res, err := c.Run(s)
+	if err != nil {
+		nmUsage(nil, util.ChildNewtError(err))
+	}
+
+	sres := res.(*xact.StatReadAllResult)
+	if sres.Rc != 0 {
+		fmt.Printf("Error: %d\n", sres.Rc)
+		return
+	}
+
+	if statJson {
+		b, err := json.MarshalIndent(sres.Groups, "", "    ")
+		if err != nil {
+			nmUsage(nil, util.ChildNewtError(err))
+		}
+		fmt.Printf("%s\n", string(b))
+		return
+	}
+
+	groups := make([]string, 0, len(sres.Groups))
+	for g, _ := range sres.Groups {
+		groups = append(groups, g)
+	}
+	sort.Strings(groups)
+
+	for _, g := range groups {
+		snap := sres.Groups[g]
+
+		fmt.Printf("stat group: %s\n", g)
+		switch {
+		case snap.Err != "":
+			fmt.Printf("    Error: %s\n", snap.Err)
+		case snap.Rc != 0:
+			fmt.Printf("    Error: %d\n", snap.Rc)
+		default:
+			printStatFields(snap.Fields)
+		}
+	}
+}
+
 func statsRunCmd(cmd *cobra.Command, args []string) {
+	if statAll {
+		statsAllRunCmd()
+		return
+	}
+
 	if len(args) < 1 {
 		nmUsage(cmd, nil)
 	}
@@ -87,26 +163,15 @@ func statsRunCmd(cmd *cobra.Command, args []string) {
 		fmt.Printf("Error: %d\n", sres.Rsp.Rc)
 	} else {
 		fmt.Printf("stat group: %s\n", sres.Rsp.Name)
-		if len(sres.Rsp.Fields) == 0 {
-			fmt.Printf("    (empty)\n")
-		} else {
-			names := make([]string, 0, len(sres.Rsp.Fields))
-			for k, _ := range sres.Rsp.Fields {
-				names = append(names, k)
-			}
-			sort.Strings(names)
-
-			for _, n := range names {
-				fmt.Printf("%10d %s\n", sres.Rsp.Fields[n], n)
-			}
-		}
+		printStatFields(sres.Rsp.Fields)
 	}
 }
 
 func statsCmd() *cobra.Command {
-	statsHelpText := "Read statistics for the specified stats_name from a device"
+	statsHelpText := "Read statistics for the specified stats_name from a " +
+		"device.\nWith --all, read every stat group the device reports."
 	statsCmd := &cobra.Command{
-		Use:   "stat <stats_name> -c <conn_profile>",
+		Use:   "stat [stats_name] -c <conn_profile>",
 		Short: "Read statistics from a device",
 		Long:  statsHelpText,
 		Run:   statsRunCmd,
@@ -118,6 +183,11 @@ func statsCmd() *cobra.Command {
 		Run:   statsListRunCmd,
 	}
 
+	statsCmd.Flags().BoolVar(&statAll, "all", false,
+		"Read all stat groups")
+	statsCmd.Flags().BoolVarP(&statJson, "json", "j", false,
+		"With --all, print a JSON object keyed by group name")
+
 	statsCmd.AddCommand(ListCmd)
 
 	return statsCmd
@@ -0,0 +1,116 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/newtmgr/nmutil"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/xact"
+	"mynewt.apache.org/newt/util"
+)
+
+var syscfgJson bool
+var syscfgPrefix string
+var syscfgStatGroup string
+
+func syscfgRunCmd(cmd *cobra.Command, args []string) {
+	s, err := GetSesn()
+	if err != nil {
+		nmUsage(nil, err)
+	}
+
+	c := xact.NewSyscfgReadCmd()
+	c.SetTxOptions(nmutil.TxOptions())
+	c.Prefix = syscfgPrefix
+	c.StatGroup = syscfgStatGroup
+	c.Names = args
+
+	res, err := c.Run(s)
+	if err != nil {
+		nmUsage(nil, util.ChildNewtError(err))
+	}
+
+	sres := res.(*xact.SyscfgReadResult)
+	if sres.Rc != 0 {
+		fmt.Printf("Error: %d\n", sres.Rc)
+		return
+	}
+
+	if syscfgJson {
+		b, err := json.MarshalIndent(sres.Syscfg, "", "    ")
+		if err != nil {
+			nmUsage(nil, util.ChildNewtError(err))
+		}
+		fmt.Printf("%s\n", string(b))
+		return
+	}
+
+	if sres.Syscfg.Source == "" {
+		fmt.Printf("This device does not expose its syscfg settings\n")
+		return
+	}
+
+	names := make([]string, 0, len(sres.Syscfg.Settings))
+	for name := range sres.Syscfg.Settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Printf("%s: %s\n", name, sres.Syscfg.Settings[name])
+	}
+}
+
+func syscfgCmd() *cobra.Command {
+	syscfgEx := "    " + nmutil.ToolInfo.ExeName + " -c olimex syscfg\n"
+	syscfgEx += "    " + nmutil.ToolInfo.ExeName +
+		" -c olimex syscfg --json\n"
+	syscfgEx += "    " + nmutil.ToolInfo.ExeName +
+		" -c olimex syscfg MSYS_1_BLOCK_COUNT BLE_MAX_CONNECTIONS\n"
+
+	syscfgCmd := &cobra.Command{
+		Use:   "syscfg [setting-name...] -c <conn_profile>",
+		Short: "Show the syscfg settings a device's image was built with",
+		Long: "Read the build-time syscfg settings the running image " +
+			"exposes.  Config variables named under --prefix are read " +
+			"first; if there are none, the fields of the --stat-group " +
+			"stats group are shown instead.  If the device cannot list " +
+			"its config variables, only the named settings are read.",
+		Example: syscfgEx,
+		Run:     syscfgRunCmd,
+	}
+
+	syscfgCmd.PersistentFlags().BoolVarP(&syscfgJson, "json", "j", false,
+		"Print the settings as JSON")
+	syscfgCmd.PersistentFlags().StringVar(&syscfgPrefix, "prefix",
+		xact.SYSCFG_DFLT_PREFIX,
+		"Prefix of the config variables holding syscfg settings")
+	syscfgCmd.PersistentFlags().StringVar(&syscfgStatGroup, "stat-group",
+		xact.SYSCFG_DFLT_STAT_GROUP,
+		"Stats group to read if no config variables are found; "+
+			"empty to skip")
+
+	return syscfgCmd
+}
@@ -0,0 +1,134 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/newtmgr/nmutil"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/xact"
+	"mynewt.apache.org/newt/util"
+)
+
+var updateImageNum int
+var updateUpgrade bool
+var updateNoConfirm bool
+var updateNoReset bool
+var updateRebootTimeout float64
+
+func updateRunCmd(cmd *cobra.Command, args []string) {
+	if len(args) < 1 {
+		nmUsage(cmd, util.NewNewtError("Need to specify image to upload"))
+	}
+	if updateImageNum < 0 {
+		nmUsage(cmd, util.NewNewtError("Invalid image number"))
+	}
+	if updateRebootTimeout <= 0 {
+		nmUsage(cmd, util.NewNewtError("Invalid reboot timeout"))
+	}
+
+	uc, err := xact.NewImageUpgradeCmdFile(args[0])
+	if err != nil {
+		nmUsage(cmd, util.NewNewtError(err.Error()))
+	}
+
+	s, err := GetSesn()
+	if err != nil {
+		nmUsage(nil, err)
+	}
+
+	c := xact.NewOtaUpdateCmd()
+	c.SetTxOptions(nmutil.TxOptions())
+	c.ImageNum = updateImageNum
+	c.Upgrade = updateUpgrade
+	c.NoConfirm = updateNoConfirm
+	c.NoReset = updateNoReset
+	c.RebootTimeout = time.Duration(updateRebootTimeout * float64(time.Second))
+
+	progressCb, progressDone := newProgressRenderer()
+	c.ProgressEventCb = progressCb
+	c.StepCb = func(step xact.OtaStep) {
+		if step.Name == xact.OTA_STEP_UPLOAD {
+			progressDone()
+		}
+		if step.Err != nil {
+			fmt.Printf("%s: failed\n", step.Name)
+		} else {
+			fmt.Printf("%s: done\n", step.Name)
+		}
+	}
+
+	res, err := xact.OtaUpdate(s, uc.Data, c)
+	if res != nil && res.RolledBack {
+		fmt.Printf("Device rolled back to the previous image; " +
+			"the new image was not confirmed\n")
+	}
+	if err != nil {
+		nmUsage(nil, util.ChildNewtError(err))
+	}
+
+	if updateNoReset {
+		fmt.Printf("Marked %x for test; reset the device to boot it, then "+
+			"confirm it with 'image confirm' while it is running\n",
+			res.NewHash)
+	} else if updateNoConfirm {
+		fmt.Printf("Running %x; not confirmed, so it reverts on the next "+
+			"reset unless confirmed with 'image confirm'\n", res.NewHash)
+	} else {
+		fmt.Printf("Running and confirmed %x\n", res.NewHash)
+	}
+}
+
+func updateCmd() *cobra.Command {
+	updateEx := "  " + nmutil.ToolInfo.ExeName +
+		" -c olimex update bin/slinky_zero/apps/slinky.img\n"
+
+	updateCmd := &cobra.Command{
+		Use:   "update <image-file> -c <conn_profile>",
+		Short: "Upload, test, boot and confirm a new image",
+		Long: "Upload an image, mark it for test and reset the device.  " +
+			"Once the device is reachable again, check that it is running " +
+			"the new image and confirm it.  If the device booted the old " +
+			"image instead, the new one is not confirmed and the command " +
+			"fails.",
+		Example: updateEx,
+		Run:     updateRunCmd,
+	}
+
+	updateCmd.PersistentFlags().IntVarP(&updateImageNum, "image", "n", 0,
+		"In a multi-image system, which image should be updated")
+	updateCmd.PersistentFlags().BoolVarP(&updateUpgrade, "upgrade", "u",
+		false,
+		"Only allow the upload if the new image's version is greater than "+
+			"that of the currently running image")
+	updateCmd.PersistentFlags().BoolVar(&updateNoConfirm, "no-confirm", false,
+		"Stop once the device has booted the new image, without confirming it")
+	updateCmd.PersistentFlags().BoolVar(&updateNoReset, "no-reset", false,
+		"Stop once the new image is marked for test, without resetting the "+
+			"device; the caller must reset it and confirm the image")
+	updateCmd.PersistentFlags().Float64Var(&updateRebootTimeout,
+		"reboot-timeout", xact.OTA_DEF_REBOOT_TIMEOUT.Seconds(),
+		"Maximum time to wait for the device after reset, in seconds")
+
+	return updateCmd
+}
@@ -0,0 +1,76 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/xact"
+	"mynewt.apache.org/newt/util"
+)
+
+var waitMax float64
+var waitInterval float64
+
+func waitRunCmd(cmd *cobra.Command, args []string) {
+	if waitMax <= 0 || waitInterval <= 0 {
+		nmUsage(cmd, util.NewNewtError("Invalid wait parameters"))
+	}
+
+	s, err := GetSesn()
+	if err != nil {
+		if globalSesn == nil {
+			nmUsage(nil, err)
+		}
+
+		// The session was built but the device did not accept the
+		// connection; keep trying.
+		s = globalSesn
+	}
+
+	d, err := xact.WaitReachable(s,
+		time.Duration(waitMax*float64(time.Second)),
+		time.Duration(waitInterval*float64(time.Second)))
+	if err != nil {
+		nmUsage(nil, util.ChildNewtError(err))
+	}
+
+	fmt.Printf("Reachable after %.1fs\n", d.Seconds())
+}
+
+func waitCmd() *cobra.Command {
+	waitCmd := &cobra.Command{
+		Use:   "wait -c <conn_profile>",
+		Short: "Wait until a device responds",
+		Long: "Send echo requests until the device responds or --max-wait " +
+			"seconds elapse.  The exit status is non-zero on timeout.",
+		Run: waitRunCmd,
+	}
+
+	waitCmd.PersistentFlags().Float64Var(&waitMax, "max-wait", 60.0,
+		"Maximum time to wait, in seconds")
+	waitCmd.PersistentFlags().Float64Var(&waitInterval, "interval", 1.0,
+		"Time between attempts, in seconds")
+
+	return waitCmd
+}
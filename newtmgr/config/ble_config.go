@@ -47,12 +47,18 @@ type BleConfig struct {
 	ControllerPath string
 
 	HciIdx int
+
+	// Non-standard management service and characteristic UUIDs; empty for
+	// the defaults.
+	MgmtSvcUuid    string
+	MgmtReqChrUuid string
+	MgmtRspChrUuid string
 }
 
 func NewBleConfig() *BleConfig {
 	return &BleConfig{
 		OwnAddrType:  bledefs.BLE_ADDR_TYPE_RANDOM,
-		ConnTimeout:  nmutil.Timeout,
+		ConnTimeout:  nmutil.ConnTimeoutSecs(),
 		BlehostdPath: "blehostd",
 	}
 }
@@ -106,6 +112,21 @@ func ParseBleConnString(cs string) (*BleConfig, error) {
 			bc.BlehostdPath = v
 		case "ctlr_path":
 			bc.ControllerPath = v
+		case "mgmt_svc":
+			if _, err := bledefs.ParseUuid(v); err != nil {
+				return nil, einvalBleConnString("Invalid mgmt_svc: %s", v)
+			}
+			bc.MgmtSvcUuid = v
+		case "mgmt_req_chr":
+			if _, err := bledefs.ParseUuid(v); err != nil {
+				return nil, einvalBleConnString("Invalid mgmt_req_chr: %s", v)
+			}
+			bc.MgmtReqChrUuid = v
+		case "mgmt_rsp_chr":
+			if _, err := bledefs.ParseUuid(v); err != nil {
+				return nil, einvalBleConnString("Invalid mgmt_rsp_chr: %s", v)
+			}
+			bc.MgmtRspChrUuid = v
 		default:
 			return nil, einvalBleConnString("Unrecognized key: %s", k)
 		}
@@ -127,8 +148,10 @@ func FillSesnCfg(bx *nmble.BleXport, bc *BleConfig, sc *sesn.SesnCfg) error {
 		scanPred := func(r bledefs.BleAdvReport) bool {
 			return r.Fields.Name != nil && *r.Fields.Name == bc.PeerName
 		}
+		start := time.Now()
 		dev, err := nmble.DiscoverDevice(
 			bx, bc.OwnAddrType, 15*time.Second, scanPred)
+		sc.Ble.ScanTime = time.Since(start)
 
 		if err != nil {
 			return err
@@ -152,6 +175,10 @@ func FillSesnCfg(bx *nmble.BleXport, bc *BleConfig, sc *sesn.SesnCfg) error {
 
 	sc.Ble.WriteRsp = nmutil.BleWriteRsp
 
+	sc.Ble.MgmtSvcUuid = bc.MgmtSvcUuid
+	sc.Ble.MgmtReqChrUuid = bc.MgmtReqChrUuid
+	sc.Ble.MgmtRspChrUuid = bc.MgmtRspChrUuid
+
 	return nil
 }
 
@@ -50,7 +50,7 @@ type BllConfig struct {
 
 func NewBllConfig() *BllConfig {
 	return &BllConfig{
-		ConnTimeout: nmutil.Timeout,
+		ConnTimeout: nmutil.ConnTimeoutSecs(),
 	}
 }
 
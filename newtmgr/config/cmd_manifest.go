@@ -0,0 +1,213 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package config
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
+	"mynewt.apache.org/newt/util"
+)
+
+// Field types permitted in a command manifest schema.
+const (
+	MANIFEST_TYPE_INT    = "int"
+	MANIFEST_TYPE_UINT   = "uint"
+	MANIFEST_TYPE_BOOL   = "bool"
+	MANIFEST_TYPE_STRING = "string"
+	MANIFEST_TYPE_BYTES  = "bytes"
+	MANIFEST_TYPE_FLOAT  = "float"
+)
+
+var manifestTypes = map[string]bool{
+	MANIFEST_TYPE_INT:    true,
+	MANIFEST_TYPE_UINT:   true,
+	MANIFEST_TYPE_BOOL:   true,
+	MANIFEST_TYPE_STRING: true,
+	MANIFEST_TYPE_BYTES:  true,
+	MANIFEST_TYPE_FLOAT:  true,
+}
+
+type ManifestCmd struct {
+	Name     string            `yaml:"name"`
+	Id       uint8             `yaml:"id"`
+	Op       string            `yaml:"op"`
+	Help     string            `yaml:"help"`
+	Request  map[string]string `yaml:"request"`
+	Response map[string]string `yaml:"response"`
+}
+
+type ManifestGroup struct {
+	Name     string        `yaml:"name"`
+	Id       uint16        `yaml:"id"`
+	Help     string        `yaml:"help"`
+	Commands []ManifestCmd `yaml:"commands"`
+}
+
+// CmdManifest describes user-defined NMP command groups.
+type CmdManifest struct {
+	Groups []ManifestGroup `yaml:"groups"`
+}
+
+// NmpOp returns the NMP request opcode for the command.
+func (mc *ManifestCmd) NmpOp() uint8 {
+	if mc.Op == "write" {
+		return nmp.NMP_OP_WRITE
+	}
+	return nmp.NMP_OP_READ
+}
+
+func einvalManifest(f string, args ...interface{}) error {
+	return util.FmtNewtError("Invalid command manifest; "+f, args...)
+}
+
+func validateManifestFields(where string, fields map[string]string) error {
+	for name, typ := range fields {
+		if name == "" {
+			return einvalManifest("%s: empty field name", where)
+		}
+		if !manifestTypes[typ] {
+			return einvalManifest("%s: field \"%s\" has unknown type \"%s\"",
+				where, name, typ)
+		}
+	}
+	return nil
+}
+
+// Validate checks the manifest for malformed entries and for groups that
+// would collide with each other, with the system groups, or with any name in
+// reserved (typically the names of built-in commands).  It also rejects
+// commands whose responses nmxact already knows how to decode.
+func (m *CmdManifest) Validate(reserved map[string]bool) error {
+	groupIds := map[uint16]string{}
+	groupNames := map[string]bool{}
+
+	for _, g := range m.Groups {
+		if g.Name == "" {
+			return einvalManifest("group %d has no name", g.Id)
+		}
+		if reserved[g.Name] {
+			return einvalManifest("group \"%s\" conflicts with a built-in "+
+				"command", g.Name)
+		}
+		if groupNames[g.Name] {
+			return einvalManifest("duplicate group name \"%s\"", g.Name)
+		}
+		groupNames[g.Name] = true
+
+		if g.Id < nmp.NMP_GROUP_PERUSER {
+			return einvalManifest("group \"%s\" uses reserved id %d; "+
+				"user groups start at %d", g.Name, g.Id, nmp.NMP_GROUP_PERUSER)
+		}
+		if other, ok := groupIds[g.Id]; ok {
+			return einvalManifest("groups \"%s\" and \"%s\" share id %d",
+				other, g.Name, g.Id)
+		}
+		groupIds[g.Id] = g.Name
+
+		cmdNames := map[string]bool{}
+		cmdOis := map[string]bool{}
+		for _, c := range g.Commands {
+			where := g.Name + " " + c.Name
+			if c.Name == "" {
+				return einvalManifest("group \"%s\": command %d has no name",
+					g.Name, c.Id)
+			}
+			if cmdNames[c.Name] {
+				return einvalManifest("group \"%s\": duplicate command "+
+					"name \"%s\"", g.Name, c.Name)
+			}
+			cmdNames[c.Name] = true
+
+			if c.Op != "read" && c.Op != "write" {
+				return einvalManifest("%s: op must be \"read\" or \"write\"",
+					where)
+			}
+			oi := c.Op + "/" + strconv.Itoa(int(c.Id))
+			if cmdOis[oi] {
+				return einvalManifest("group \"%s\": duplicate %s command "+
+					"id %d", g.Name, c.Op, c.Id)
+			}
+			cmdOis[oi] = true
+
+			ogi := nmp.Ogi{Op: c.NmpOp() + 1, Group: g.Id, Id: c.Id}
+			if nmp.ResponseHandlerRegistered(ogi) {
+				return einvalManifest("%s: group %d %s command id %d is "+
+					"already handled by newtmgr", where, g.Id, c.Op, c.Id)
+			}
+
+			if err := validateManifestFields(where, c.Request); err != nil {
+				return err
+			}
+			if err := validateManifestFields(where, c.Response); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// ReadCmdManifest loads and validates a command manifest file.
+func ReadCmdManifest(path string,
+	reserved map[string]bool) (*CmdManifest, error) {
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, util.ChildNewtError(err)
+	}
+
+	m := &CmdManifest{}
+	if err := yaml.Unmarshal(data, m); err != nil {
+		return nil, util.FmtNewtError("Failed to parse command manifest "+
+			"%s: %s", path, err.Error())
+	}
+
+	if err := m.Validate(reserved); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// ParseManifestValue converts a command-line string to the schema type.
+func ParseManifestValue(typ string, s string) (interface{}, error) {
+	switch typ {
+	case MANIFEST_TYPE_INT:
+		return strconv.ParseInt(s, 0, 64)
+	case MANIFEST_TYPE_UINT:
+		return strconv.ParseUint(s, 0, 64)
+	case MANIFEST_TYPE_BOOL:
+		return strconv.ParseBool(s)
+	case MANIFEST_TYPE_FLOAT:
+		return strconv.ParseFloat(s, 64)
+	case MANIFEST_TYPE_BYTES:
+		return hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	case MANIFEST_TYPE_STRING:
+		return s, nil
+	default:
+		return nil, util.FmtNewtError("Unknown type \"%s\"", typ)
+	}
+}
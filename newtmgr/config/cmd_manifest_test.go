@@ -0,0 +1,174 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
+)
+
+const testManifestYaml = `
+groups:
+  - name: sensor
+    id: 100
+    help: Read and configure on-board sensors
+    commands:
+      - name: read
+        id: 0
+        op: read
+        help: Read a sample
+        request:
+          chan: uint
+        response:
+          rc: int
+          value: int
+      - name: calibrate
+        id: 1
+        op: write
+        request:
+          chan: uint
+          data: bytes
+`
+
+func writeTestManifest(t *testing.T, dir string, text string) string {
+	path := filepath.Join(dir, "manifest.yml")
+	if err := ioutil.WriteFile(path, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadCmdManifestRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cmd_manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	m, err := ReadCmdManifest(writeTestManifest(t, dir, testManifestYaml),
+		nil)
+	if err != nil {
+		t.Fatalf("ReadCmdManifest: %v", err)
+	}
+
+	if len(m.Groups) != 1 || len(m.Groups[0].Commands) != 2 {
+		t.Fatalf("unexpected manifest shape: %+v", m)
+	}
+	g := m.Groups[0]
+	if g.Name != "sensor" || g.Id != 100 {
+		t.Errorf("group = %s/%d, want sensor/100", g.Name, g.Id)
+	}
+	wc := g.Commands[1]
+	if wc.Name != "calibrate" || wc.Id != 1 ||
+		wc.NmpOp() != nmp.NMP_OP_WRITE {
+
+		t.Errorf("command = %+v, want calibrate/1/write", wc)
+	}
+	if wc.Request["data"] != MANIFEST_TYPE_BYTES {
+		t.Errorf("data type = %q, want %q", wc.Request["data"],
+			MANIFEST_TYPE_BYTES)
+	}
+
+	out, err := yaml.Marshal(m)
+	if err != nil {
+		t.Fatalf("yaml.Marshal: %v", err)
+	}
+	m2, err := ReadCmdManifest(writeTestManifest(t, dir, string(out)), nil)
+	if err != nil {
+		t.Fatalf("ReadCmdManifest (reload): %v", err)
+	}
+	if !reflect.DeepEqual(m, m2) {
+		t.Errorf("round trip mismatch:\n got %+v\nwant %+v", m2, m)
+	}
+}
+
+func TestCmdManifestValidate(t *testing.T) {
+	if err := nmp.RegisterGenericRsp(nmp.NMP_OP_READ, 200, 7); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := func(name string, id uint8, op string) ManifestCmd {
+		return ManifestCmd{Name: name, Id: id, Op: op}
+	}
+	grp := func(name string, id uint16, cmds ...ManifestCmd) ManifestGroup {
+		return ManifestGroup{Name: name, Id: id, Commands: cmds}
+	}
+
+	tests := []struct {
+		name   string
+		groups []ManifestGroup
+		errSub string
+	}{
+		{"ok", []ManifestGroup{
+			grp("a", 64, cmd("x", 0, "read"), cmd("y", 0, "write")),
+		}, ""},
+		{"system group", []ManifestGroup{
+			grp("a", 1, cmd("x", 0, "read")),
+		}, "reserved id"},
+		{"reserved name", []ManifestGroup{
+			grp("image", 64),
+		}, "built-in command"},
+		{"duplicate group id", []ManifestGroup{
+			grp("a", 64), grp("b", 64),
+		}, "share id"},
+		{"duplicate command id", []ManifestGroup{
+			grp("a", 64, cmd("x", 3, "read"), cmd("y", 3, "read")),
+		}, "duplicate read command id"},
+		{"bad op", []ManifestGroup{
+			grp("a", 64, cmd("x", 0, "get")),
+		}, "op must be"},
+		{"bad field type", []ManifestGroup{
+			grp("a", 64, ManifestCmd{Name: "x", Op: "read",
+				Request: map[string]string{"f": "complex"}}),
+		}, "unknown type"},
+		{"built-in handler", []ManifestGroup{
+			grp("a", 200, cmd("x", 7, "read")),
+		}, "already handled"},
+		{"built-in handler other op", []ManifestGroup{
+			grp("a", 200, cmd("x", 7, "write")),
+		}, ""},
+	}
+
+	reserved := map[string]bool{"image": true}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &CmdManifest{Groups: tt.groups}
+			err := m.Validate(reserved)
+			if tt.errSub == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errSub) {
+				t.Fatalf("error = %v, want one containing %q", err,
+					tt.errSub)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package config
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"mynewt.apache.org/newt/util"
+)
+
+// Operations permitted in a sequence file step.
+const (
+	SEQ_OP_UPLOAD      = "upload"
+	SEQ_OP_RESET       = "reset"
+	SEQ_OP_WAIT        = "wait"
+	SEQ_OP_TEST        = "test"
+	SEQ_OP_CONFIRM     = "confirm"
+	SEQ_OP_CONFIG_SET  = "config-set"
+	SEQ_OP_FS_UPLOAD   = "fs-upload"
+	SEQ_OP_FS_DOWNLOAD = "fs-download"
+)
+
+var seqOps = map[string]bool{
+	SEQ_OP_UPLOAD:      true,
+	SEQ_OP_RESET:       true,
+	SEQ_OP_WAIT:        true,
+	SEQ_OP_TEST:        true,
+	SEQ_OP_CONFIRM:     true,
+	SEQ_OP_CONFIG_SET:  true,
+	SEQ_OP_FS_UPLOAD:   true,
+	SEQ_OP_FS_DOWNLOAD: true,
+}
+
+// SequenceStep is a single operation in a sequence file.  Which of the
+// remaining fields apply depends on Op:
+//
+//	upload:      File; optionally Image and Upgrade.
+//	reset:       none.
+//	wait:        optionally Timeout (seconds).
+//	test:        optionally Hash; defaults to the last uploaded image.
+//	confirm:     optionally Hash; defaults to the running image.
+//	config-set:  Name and Value; optionally Save.
+//	fs-upload:   File (local) and Name (on the device).
+//	fs-download: Name (on the device) and File (local).
+type SequenceStep struct {
+	Op              string  `yaml:"op"`
+	File            string  `yaml:"file"`
+	Name            string  `yaml:"name"`
+	Value           string  `yaml:"value"`
+	Hash            string  `yaml:"hash"`
+	Image           int     `yaml:"image"`
+	Upgrade         bool    `yaml:"upgrade"`
+	Save            bool    `yaml:"save"`
+	Timeout         float64 `yaml:"timeout"`
+	ContinueOnError bool    `yaml:"continue-on-error"`
+}
+
+// Sequence is an ordered list of operations to run against one device.
+type Sequence struct {
+	Steps []SequenceStep `yaml:"steps"`
+}
+
+// HashBytes decodes the step's hash; nil if none was specified.
+func (ss *SequenceStep) HashBytes() ([]byte, error) {
+	if ss.Hash == "" {
+		return nil, nil
+	}
+	return hex.DecodeString(strings.TrimPrefix(ss.Hash, "0x"))
+}
+
+func einvalSequence(f string, args ...interface{}) error {
+	return util.FmtNewtError("Invalid sequence file; "+f, args...)
+}
+
+// Validate checks that every step names a known operation and specifies the
+// fields that operation requires.
+func (sq *Sequence) Validate() error {
+	if len(sq.Steps) == 0 {
+		return einvalSequence("no steps")
+	}
+
+	for i, ss := range sq.Steps {
+		where := i + 1
+
+		if !seqOps[ss.Op] {
+			return einvalSequence("step %d: unknown op \"%s\"", where, ss.Op)
+		}
+
+		switch ss.Op {
+		case SEQ_OP_UPLOAD:
+			if ss.File == "" {
+				return einvalSequence("step %d: %s requires a file",
+					where, ss.Op)
+			}
+			if ss.Image < 0 {
+				return einvalSequence("step %d: invalid image number %d",
+					where, ss.Image)
+			}
+
+		case SEQ_OP_WAIT:
+			if ss.Timeout < 0 {
+				return einvalSequence("step %d: invalid timeout", where)
+			}
+
+		case SEQ_OP_CONFIG_SET:
+			if ss.Name == "" {
+				return einvalSequence("step %d: %s requires a name",
+					where, ss.Op)
+			}
+
+		case SEQ_OP_FS_UPLOAD, SEQ_OP_FS_DOWNLOAD:
+			if ss.File == "" || ss.Name == "" {
+				return einvalSequence("step %d: %s requires a file and "+
+					"a name", where, ss.Op)
+			}
+		}
+
+		if _, err := ss.HashBytes(); err != nil {
+			return einvalSequence("step %d: invalid hash \"%s\"",
+				where, ss.Hash)
+		}
+	}
+
+	return nil
+}
+
+// ReadSequence loads and validates a sequence file.
+func ReadSequence(path string) (*Sequence, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, util.ChildNewtError(err)
+	}
+
+	sq := &Sequence{}
+	if err := yaml.Unmarshal(data, sq); err != nil {
+		return nil, util.FmtNewtError("Failed to parse sequence file "+
+			"%s: %s", path, err.Error())
+	}
+
+	if err := sq.Validate(); err != nil {
+		return nil, err
+	}
+
+	return sq, nil
+}
@@ -0,0 +1,136 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadSequence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sequence")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "seq.yml")
+	text := `
+steps:
+  - op: upload
+    file: bin/slinky.img
+    image: 1
+    upgrade: true
+  - op: test
+    hash: 0x0102
+  - op: reset
+  - op: wait
+    timeout: 2.5
+    continue-on-error: true
+  - op: config-set
+    name: id/serial
+    value: "1234"
+    save: true
+`
+	if err := ioutil.WriteFile(path, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sq, err := ReadSequence(path)
+	if err != nil {
+		t.Fatalf("ReadSequence: %v", err)
+	}
+
+	want := []SequenceStep{
+		{Op: SEQ_OP_UPLOAD, File: "bin/slinky.img", Image: 1, Upgrade: true},
+		{Op: SEQ_OP_TEST, Hash: "0x0102"},
+		{Op: SEQ_OP_RESET},
+		{Op: SEQ_OP_WAIT, Timeout: 2.5, ContinueOnError: true},
+		{Op: SEQ_OP_CONFIG_SET, Name: "id/serial", Value: "1234",
+			Save: true},
+	}
+	if len(sq.Steps) != len(want) {
+		t.Fatalf("have %d steps, want %d", len(sq.Steps), len(want))
+	}
+	for i := range want {
+		if sq.Steps[i] != want[i] {
+			t.Errorf("step %d = %+v, want %+v", i+1, sq.Steps[i], want[i])
+		}
+	}
+
+	hash, err := sq.Steps[1].HashBytes()
+	if err != nil || string(hash) != "\x01\x02" {
+		t.Errorf("HashBytes = %x, %v", hash, err)
+	}
+}
+
+func TestSequenceValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		steps  []SequenceStep
+		errSub string
+	}{
+		{"empty", nil, "no steps"},
+		{"unknown op", []SequenceStep{{Op: "format"}}, "unknown op"},
+		{"upload without file", []SequenceStep{{Op: SEQ_OP_UPLOAD}},
+			"requires a file"},
+		{"negative image", []SequenceStep{
+			{Op: SEQ_OP_UPLOAD, File: "a.img", Image: -1},
+		}, "invalid image number"},
+		{"negative timeout", []SequenceStep{
+			{Op: SEQ_OP_WAIT, Timeout: -1},
+		}, "invalid timeout"},
+		{"config-set without name", []SequenceStep{
+			{Op: SEQ_OP_CONFIG_SET, Value: "1"},
+		}, "requires a name"},
+		{"fs-upload without name", []SequenceStep{
+			{Op: SEQ_OP_FS_UPLOAD, File: "a"},
+		}, "requires a file and a name"},
+		{"bad hash", []SequenceStep{
+			{Op: SEQ_OP_CONFIRM, Hash: "xyz"},
+		}, "invalid hash"},
+		{"error names step", []SequenceStep{
+			{Op: SEQ_OP_RESET}, {Op: "bogus"},
+		}, "step 2"},
+		{"ok", []SequenceStep{
+			{Op: SEQ_OP_RESET},
+			{Op: SEQ_OP_FS_DOWNLOAD, File: "a", Name: "/b"},
+		}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&Sequence{Steps: tt.steps}).Validate()
+			if tt.errSub == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errSub) {
+				t.Fatalf("error = %v, want one containing %q", err,
+					tt.errSub)
+			}
+		})
+	}
+}
@@ -34,9 +34,19 @@ type CoreConvert struct {
 	Source    *os.File
 	Target    *os.File
 	ImageHash []byte
-	elfHdr    *elf.Header32
-	phdrs     []*elf.Prog32
-	data      [][]byte
+
+	// Filled in by Parse.
+	Regs []uint32
+	Mem  []CoreDumpRegion
+
+	elfHdr *elf.Header32
+	phdrs  []*elf.Prog32
+	data   [][]byte
+}
+
+type CoreDumpRegion struct {
+	Addr uint32
+	Data []byte
 }
 
 const (
@@ -49,6 +59,14 @@ const (
 	COREDUMP_MAGIC = 0x690c47c3
 )
 
+// Register names in the order Mynewt saves them for Cortex-M targets.
+var CoreDumpRegNames = []string{
+	"r0", "r1", "r2", "r3", "r4", "r5", "r6", "r7",
+	"r8", "r9", "r10", "r11", "r12", "sp", "lr", "pc", "xpsr",
+}
+
+const COREDUMP_REG_PC = 15
+
 type CoreDumpHdr struct {
 	Magic uint32
 	Size  uint32
@@ -65,29 +83,30 @@ func NewCoreConvert() *CoreConvert {
 	return &CoreConvert{}
 }
 
-func (cc *CoreConvert) readHdr() error {
+func (cc *CoreConvert) readHdr() (*CoreDumpHdr, error) {
 	var hdr CoreDumpHdr
 
 	hdr_buf := make([]byte, binary.Size(hdr))
 	if hdr_buf == nil {
-		return util.NewNewtError("Out of memory")
+		return nil, util.NewNewtError("Out of memory")
 	}
 
 	cnt, err := cc.Source.Read(hdr_buf)
 	if err != nil {
-		return util.NewNewtError(fmt.Sprintf("Error reading: %s", err.Error()))
+		return nil, util.NewNewtError(fmt.Sprintf("Error reading: %s",
+			err.Error()))
 	}
 	if cnt != binary.Size(hdr) {
-		return util.NewNewtError("Short read")
+		return nil, util.NewNewtError("Short read")
 	}
 
 	hdr.Magic = binary.LittleEndian.Uint32(hdr_buf[0:4])
 	hdr.Size = binary.LittleEndian.Uint32(hdr_buf[4:8])
 
 	if hdr.Magic != COREDUMP_MAGIC {
-		return util.NewNewtError("Source file is not corefile")
+		return nil, util.NewNewtError("Source file is not corefile")
 	}
-	return nil
+	return &hdr, nil
 }
 
 func (cc *CoreConvert) readTlv() (*CoreDumpTlv, error) {
@@ -246,16 +265,19 @@ func (cc *CoreConvert) setProgHdrOff() {
 	}
 }
 
-func (cc *CoreConvert) Convert() error {
-	if cc.Source == nil || cc.Target == nil {
+// Parse reads and validates the core dump in Source, filling in ImageHash,
+// Regs and Mem.  The size in the header must match the dump's length.
+func (cc *CoreConvert) Parse() error {
+	if cc.Source == nil {
 		return util.NewNewtError("Missing file parameters")
 	}
 
-	err := cc.readHdr()
+	hdr, err := cc.readHdr()
 	if err != nil {
 		return err
 	}
 
+	size := binary.Size(hdr)
 	for {
 		tlv, err := cc.readTlv()
 		if err != nil {
@@ -273,8 +295,11 @@ func (cc *CoreConvert) Convert() error {
 		if cnt != int(tlv.Len) {
 			return util.NewNewtError("Short file")
 		}
+		size += binary.Size(tlv) + cnt
+
 		switch tlv.Type {
 		case COREDUMP_TLV_MEM:
+			cc.Mem = append(cc.Mem, CoreDumpRegion{tlv.Off, data_buf})
 			cc.makeProgHdr(tlv.Off, data_buf)
 		case COREDUMP_TLV_IMAGE:
 			cc.ImageHash = data_buf
@@ -282,11 +307,50 @@ func (cc *CoreConvert) Convert() error {
 			if tlv.Len%4 != 0 {
 				return util.NewNewtError("Invalid register area size")
 			}
+			for off := 0; off < cnt; off += 4 {
+				cc.Regs = append(cc.Regs,
+					binary.LittleEndian.Uint32(data_buf[off:off+4]))
+			}
 			cc.makeRegInfo(data_buf)
 		default:
 			return util.NewNewtError("Unknown TLV type")
 		}
 	}
+
+	if size != int(hdr.Size) {
+		return util.FmtNewtError(
+			"Core dump size mismatch; header=%d actual=%d", hdr.Size, size)
+	}
+
+	return nil
+}
+
+// Pc returns the program counter at the time of the fault, if the register
+// area is large enough to contain it.
+func (cc *CoreConvert) Pc() (uint32, bool) {
+	if len(cc.Regs) <= COREDUMP_REG_PC {
+		return 0, false
+	}
+	return cc.Regs[COREDUMP_REG_PC], true
+}
+
+func (cc *CoreConvert) RegName(idx int) string {
+	if idx < len(CoreDumpRegNames) {
+		return CoreDumpRegNames[idx]
+	}
+	return fmt.Sprintf("reg%d", idx)
+}
+
+func (cc *CoreConvert) Convert() error {
+	if cc.Source == nil || cc.Target == nil {
+		return util.NewNewtError("Missing file parameters")
+	}
+
+	err := cc.Parse()
+	if err != nil {
+		return err
+	}
+
 	cc.makeElfHdr()
 	if err != nil {
 		return err
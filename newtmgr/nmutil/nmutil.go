@@ -36,6 +36,8 @@ type ToolInfoType struct {
 }
 
 var Timeout float64
+var ConnTimeout float64
+var DeviceTimeout float64
 var Tries int
 var ConnProfile string
 var DeviceName string
@@ -46,11 +48,47 @@ var ConnString string
 var ConnExtra string
 var ToolInfo ToolInfoType
 var HciIdx int
+var QueryMtu bool
+var UdpDscp int
+var UdpProxy string
+var SerialSniff bool
+var StartTries int
+var Progress string
+
+// Request signing: algorithm name, key file, and key ID.
+var AuthAlg string
+var AuthKeyFile string
+var AuthKeyId int
+
+// Extra NMP header flag bits set on every outgoing request (--nmp-flags).
+var NmpFlags uint8
+
+// If set, confirmation prompts are skipped as if the user answered yes.
+var AssumeYes bool
+
+// Returns the connection-establishment timeout, in seconds.  Falls back to
+// the general timeout if no connect timeout was specified.
+func ConnTimeoutSecs() float64 {
+	if ConnTimeout > 0 {
+		return ConnTimeout
+	}
+	return Timeout
+}
+
+// Returns the per-request response timeout, in seconds.  Falls back to the
+// general timeout if no device timeout was specified.
+func DeviceTimeoutSecs() float64 {
+	if DeviceTimeout > 0 {
+		return DeviceTimeout
+	}
+	return Timeout
+}
 
 func TxOptions() sesn.TxOptions {
 	return sesn.TxOptions{
-		Timeout: time.Duration(Timeout * float64(time.Second)),
-		Tries:   Tries,
+		Timeout:  time.Duration(DeviceTimeoutSecs() * float64(time.Second)),
+		Tries:    Tries,
+		NmpFlags: NmpFlags,
 	}
 }
 
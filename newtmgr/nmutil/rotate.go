@@ -0,0 +1,115 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package nmutil
+
+import (
+	"fmt"
+	"os"
+)
+
+// RotatingFile is an append-only file that is rotated once it reaches a
+// size limit.  The current file is renamed to <path>.1, any existing <path>.1
+// to <path>.2, and so on; files beyond Keep are deleted.  Each Write is kept
+// intact in a single file: rotation only happens between writes.
+type RotatingFile struct {
+	Path    string
+	MaxSize int64
+	Keep    int
+
+	f    *os.File
+	size int64
+}
+
+// OpenRotatingFile opens path for appending.  A maxSize of 0 disables
+// rotation.
+func OpenRotatingFile(path string, maxSize int64,
+	keep int) (*RotatingFile, error) {
+
+	rf := &RotatingFile{
+		Path:    path,
+		MaxSize: maxSize,
+		Keep:    keep,
+	}
+
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+func (rf *RotatingFile) open() error {
+	f, err := os.OpenFile(rf.Path, os.O_WRONLY|os.O_CREATE|os.O_APPEND,
+		0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	rf.f = f
+	rf.size = info.Size()
+	return nil
+}
+
+func (rf *RotatingFile) backupName(n int) string {
+	return fmt.Sprintf("%s.%d", rf.Path, n)
+}
+
+func (rf *RotatingFile) rotate() error {
+	if err := rf.f.Close(); err != nil {
+		return err
+	}
+
+	if rf.Keep > 0 {
+		os.Remove(rf.backupName(rf.Keep))
+		for i := rf.Keep - 1; i >= 1; i-- {
+			os.Rename(rf.backupName(i), rf.backupName(i+1))
+		}
+		if err := os.Rename(rf.Path, rf.backupName(1)); err != nil {
+			return err
+		}
+	} else {
+		if err := os.Remove(rf.Path); err != nil {
+			return err
+		}
+	}
+
+	return rf.open()
+}
+
+func (rf *RotatingFile) Write(b []byte) (int, error) {
+	if rf.MaxSize > 0 && rf.size > 0 && rf.size+int64(len(b)) > rf.MaxSize {
+		if err := rf.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := rf.f.Write(b)
+	rf.size += int64(n)
+	return n, err
+}
+
+func (rf *RotatingFile) Close() error {
+	return rf.f.Close()
+}
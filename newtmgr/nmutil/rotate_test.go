@@ -0,0 +1,162 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package nmutil
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRotatingFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		maxSize int64
+		keep    int
+		writes  []string
+
+		// Expected contents of the current file followed by each backup,
+		// newest first.
+		want []string
+	}{
+		{
+			name:    "no rotation",
+			maxSize: 0,
+			keep:    2,
+			writes:  []string{"aaaa", "bbbb", "cccc"},
+			want:    []string{"aaaabbbbcccc"},
+		},
+		{
+			name:    "within limit",
+			maxSize: 8,
+			keep:    2,
+			writes:  []string{"aaaa", "bbbb"},
+			want:    []string{"aaaabbbb"},
+		},
+		{
+			name:    "rotate",
+			maxSize: 8,
+			keep:    2,
+			writes:  []string{"aaaa", "bbbb", "cccc"},
+			want:    []string{"cccc", "aaaabbbb"},
+		},
+		{
+			name:    "drop oldest",
+			maxSize: 4,
+			keep:    2,
+			writes:  []string{"aaaa", "bbbb", "cccc", "dddd"},
+			want:    []string{"dddd", "cccc", "bbbb"},
+		},
+		{
+			name:    "no backups",
+			maxSize: 4,
+			keep:    0,
+			writes:  []string{"aaaa", "bbbb"},
+			want:    []string{"bbbb"},
+		},
+		{
+			name:    "oversized write kept whole",
+			maxSize: 4,
+			keep:    1,
+			writes:  []string{"aa", "bbbbbbbb"},
+			want:    []string{"bbbbbbbb", "aa"},
+		},
+	}
+
+	for _, tt := range tests {
+		dir, err := ioutil.TempDir("", "newtmgr_rotate")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "log.txt")
+
+		rf, err := OpenRotatingFile(path, tt.maxSize, tt.keep)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, w := range tt.writes {
+			if _, err := rf.Write([]byte(w)); err != nil {
+				t.Fatalf("%s: write failed: %v", tt.name, err)
+			}
+		}
+		if err := rf.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		var got []string
+		for i := 0; ; i++ {
+			name := path
+			if i > 0 {
+				name = rf.backupName(i)
+			}
+			b, err := ioutil.ReadFile(name)
+			if os.IsNotExist(err) {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, string(b))
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: files = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRotatingFileReopen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "newtmgr_rotate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "log.txt")
+
+	if err := ioutil.WriteFile(path, []byte("aaaaaa"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The existing contents count towards the size limit.
+	rf, err := OpenRotatingFile(path, 8, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rf.Write([]byte("bbbb")); err != nil {
+		t.Fatal(err)
+	}
+	rf.Close()
+
+	for name, want := range map[string]string{
+		path:        "bbbb",
+		path + ".1": "aaaaaa",
+	} {
+		b, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != want {
+			t.Errorf("%s = %q, want %q", name, b, want)
+		}
+	}
+}
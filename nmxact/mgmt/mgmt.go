@@ -23,21 +23,46 @@ import (
 	"fmt"
 
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmxutil"
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/omp"
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
 )
 
+// MaxReqSize returns the size, in bytes, of the largest encoded management
+// request the session can send.  Sessions that fragment requests
+// (CoapIsTcp) are only limited by the NMP length field.
+func MaxReqSize(s sesn.Sesn) int {
+	if s.CoapIsTcp() {
+		return nmp.NMP_MAX_MSG_SIZE
+	}
+	return s.MtuOut()
+}
+
+// CheckReqSize encodes a request as the session would and verifies that it
+// fits in MaxReqSize.  A *nmxutil.ReqSizeError is returned if it does not.
+func CheckReqSize(s sesn.Sesn, m *nmp.NmpMsg) error {
+	b, err := EncodeMgmt(s, m)
+	if err != nil {
+		return err
+	}
+
+	if limit := MaxReqSize(s); len(b) > limit {
+		return nmxutil.NewReqSizeError(len(b), limit)
+	}
+	return nil
+}
+
 func EncodeMgmt(s sesn.Sesn, m *nmp.NmpMsg) ([]byte, error) {
 	switch s.MgmtProto() {
 	case sesn.MGMT_PROTO_NMP:
-		return nmp.EncodeNmpPlain(m)
+		return nmp.EncodeNmp(m, sesn.SesnNmpAuth(s))
 
 	case sesn.MGMT_PROTO_OMP:
 		txCb, _ := s.Filters()
 		if s.CoapIsTcp() == true {
-			return omp.EncodeOmpTcp(txCb, m)
+			return omp.EncodeOmpTcp(txCb, sesn.SesnOmpRes(s), m)
 		} else {
-			return omp.EncodeOmpDgram(txCb, m)
+			return omp.EncodeOmpDgram(txCb, sesn.SesnOmpRes(s), m)
 		}
 
 	default:
@@ -0,0 +1,100 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package mgmt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmxutil"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
+)
+
+// A plain NMP session with a fixed outgoing MTU.
+type sizeSesn struct {
+	sesn.Sesn
+	mtu int
+	tcp bool
+}
+
+func (s *sizeSesn) MgmtProto() sesn.MgmtProto { return sesn.MGMT_PROTO_NMP }
+func (s *sizeSesn) CoapIsTcp() bool           { return s.tcp }
+func (s *sizeSesn) MtuOut() int               { return s.mtu }
+
+func echoMsg(payloadLen int) *nmp.NmpMsg {
+	r := nmp.NewEchoReq()
+	r.Payload = strings.Repeat("x", payloadLen)
+	return r.Msg()
+}
+
+func TestCheckReqSize(t *testing.T) {
+	tests := []struct {
+		name       string
+		payloadLen int
+		tcp        bool
+		mtuDelta   int // MTU relative to the encoded request size.
+		wantErr    bool
+	}{
+		{"fits with room", 10, false, 20, false},
+		{"exact fit", 10, false, 0, false},
+		{"one byte over", 10, false, -1, true},
+		{"large over", 500, false, -400, true},
+		{"fragmenting session", 500, true, -400, false},
+	}
+
+	for _, tt := range tests {
+		m := echoMsg(tt.payloadLen)
+		b, err := EncodeMgmt(&sizeSesn{}, m)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		s := &sizeSesn{mtu: len(b) + tt.mtuDelta, tcp: tt.tcp}
+		err = CheckReqSize(s, m)
+		if !tt.wantErr {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", tt.name, err)
+			}
+			continue
+		}
+
+		if !nmxutil.IsReqSize(err) {
+			t.Errorf("%s: err = %v, want ReqSizeError", tt.name, err)
+			continue
+		}
+		rerr := err.(*nmxutil.ReqSizeError)
+		if rerr.Size != len(b) || rerr.Limit != s.mtu {
+			t.Errorf("%s: size=%d limit=%d, want size=%d limit=%d",
+				tt.name, rerr.Size, rerr.Limit, len(b), s.mtu)
+		}
+	}
+}
+
+func TestMaxReqSize(t *testing.T) {
+	if n := MaxReqSize(&sizeSesn{mtu: 252}); n != 252 {
+		t.Errorf("datagram MaxReqSize = %d, want 252", n)
+	}
+	n := MaxReqSize(&sizeSesn{mtu: 252, tcp: true})
+	if n != nmp.NMP_MAX_MSG_SIZE {
+		t.Errorf("fragmenting MaxReqSize = %d, want %d", n,
+			nmp.NMP_MAX_MSG_SIZE)
+	}
+}
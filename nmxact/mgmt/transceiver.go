@@ -47,6 +47,20 @@ type Transceiver struct {
 
 	txFilter nmcoap.TxMsgFilter
 
+	// OMP resource path; empty for the default.
+	ompRes string
+
+	// Request signing configuration; nil if NMP requests are not signed.
+	nmpAuth *nmp.NmpAuth
+
+	// Largest NMP payload the device advertised; 0 if unknown.
+	mtuPref int
+
+	// Number of requests awaiting a response, and the most allowed.
+	outstanding    int
+	maxOutstanding int
+	outstandingMtx sync.Mutex
+
 	isTcp bool
 	proto sesn.MgmtProto
 	wg    sync.WaitGroup
@@ -56,9 +70,10 @@ func NewTransceiver(txFilter nmcoap.TxMsgFilter, rxFilter nmcoap.RxMsgFilter, is
 	mgmtProto sesn.MgmtProto, logDepth int) (*Transceiver, error) {
 
 	t := &Transceiver{
-		txFilter: txFilter,
-		isTcp:    isTcp,
-		proto:    mgmtProto,
+		txFilter:       txFilter,
+		isTcp:          isTcp,
+		proto:          mgmtProto,
+		maxOutstanding: sesn.SESN_DEF_MAX_OUTSTANDING,
 	}
 
 	if mgmtProto == sesn.MGMT_PROTO_NMP {
@@ -74,23 +89,48 @@ func NewTransceiver(txFilter nmcoap.TxMsgFilter, rxFilter nmcoap.RxMsgFilter, is
 	return t, nil
 }
 
+// Reserves a slot for a request that will await a response.  Each successful
+// call must be paired with a call to releaseOutstanding.
+func (t *Transceiver) acquireOutstanding() error {
+	t.outstandingMtx.Lock()
+	defer t.outstandingMtx.Unlock()
+
+	if t.outstanding >= t.maxOutstanding {
+		return nmxutil.NewOutstandingLimitError(t.maxOutstanding)
+	}
+	t.outstanding++
+	return nil
+}
+
+func (t *Transceiver) releaseOutstanding() {
+	t.outstandingMtx.Lock()
+	defer t.outstandingMtx.Unlock()
+
+	t.outstanding--
+}
+
 func (t *Transceiver) txRxNmp(txCb TxFn, req *nmp.NmpMsg, mtu int,
 	timeout time.Duration) (nmp.NmpRsp, error) {
 
+	if err := t.acquireOutstanding(); err != nil {
+		return nil, err
+	}
+	defer t.releaseOutstanding()
+
 	nl, err := t.nd.AddListener(req.Hdr.Seq)
 	if err != nil {
 		return nil, err
 	}
 	defer t.nd.RemoveListener(req.Hdr.Seq)
 
-	b, err := nmp.EncodeNmpPlain(req)
+	b, err := nmp.EncodeNmp(req, t.nmpAuth)
 	if err != nil {
 		return nil, err
 	}
 
 	log.Debugf("Tx NMP request: %s", hex.Dump(b))
 	if t.isTcp == false && len(b) > mtu {
-		return nil, fmt.Errorf("Request too big")
+		return nil, nmxutil.NewReqSizeError(len(b), mtu)
 	}
 	frags := nmxutil.Fragment(b, mtu)
 	for _, frag := range frags {
@@ -122,14 +162,14 @@ func (t *Transceiver) txRxNmpAsync(txCb TxFn, req *nmp.NmpMsg, mtu int,
 		return err
 	}
 
-	b, err := nmp.EncodeNmpPlain(req)
+	b, err := nmp.EncodeNmp(req, t.nmpAuth)
 	if err != nil {
 		return err
 	}
 
 	log.Debugf("Tx NMP async request: seq %d %s", req.Hdr.Seq, hex.Dump(b))
 	if t.isTcp == false && len(b) > mtu {
-		return fmt.Errorf("Request too big")
+		return nmxutil.NewReqSizeError(len(b), mtu)
 	}
 	frags := nmxutil.Fragment(b, mtu)
 	for _, frag := range frags {
@@ -165,6 +205,11 @@ func (t *Transceiver) txRxNmpAsync(txCb TxFn, req *nmp.NmpMsg, mtu int,
 func (t *Transceiver) txRxOmp(txCb TxFn, req *nmp.NmpMsg, mtu int,
 	timeout time.Duration) (nmp.NmpRsp, error) {
 
+	if err := t.acquireOutstanding(); err != nil {
+		return nil, err
+	}
+	defer t.releaseOutstanding()
+
 	nl, err := t.od.AddNmpListener(req.Hdr.Seq)
 	if err != nil {
 		return nil, err
@@ -173,9 +218,9 @@ func (t *Transceiver) txRxOmp(txCb TxFn, req *nmp.NmpMsg, mtu int,
 
 	var b []byte
 	if t.isTcp {
-		b, err = omp.EncodeOmpTcp(t.txFilter, req)
+		b, err = omp.EncodeOmpTcp(t.txFilter, t.ompRes, req)
 	} else {
-		b, err = omp.EncodeOmpDgram(t.txFilter, req)
+		b, err = omp.EncodeOmpDgram(t.txFilter, t.ompRes, req)
 	}
 	if err != nil {
 		return nil, err
@@ -184,7 +229,7 @@ func (t *Transceiver) txRxOmp(txCb TxFn, req *nmp.NmpMsg, mtu int,
 	log.Debugf("Tx OMP request: %s", hex.Dump(b))
 
 	if t.isTcp == false && len(b) > mtu {
-		return nil, fmt.Errorf("Request too big")
+		return nil, nmxutil.NewReqSizeError(len(b), mtu)
 	}
 	frags := nmxutil.Fragment(b, mtu)
 	for _, frag := range frags {
@@ -219,9 +264,9 @@ func (t *Transceiver) txRxOmpAsync(txCb TxFn, req *nmp.NmpMsg, mtu int,
 
 	var b []byte
 	if t.isTcp {
-		b, err = omp.EncodeOmpTcp(t.txFilter, req)
+		b, err = omp.EncodeOmpTcp(t.txFilter, t.ompRes, req)
 	} else {
-		b, err = omp.EncodeOmpDgram(t.txFilter, req)
+		b, err = omp.EncodeOmpDgram(t.txFilter, t.ompRes, req)
 	}
 	if err != nil {
 		return err
@@ -230,7 +275,7 @@ func (t *Transceiver) txRxOmpAsync(txCb TxFn, req *nmp.NmpMsg, mtu int,
 	log.Debugf("Tx OMP request: %v %s", seq, hex.Dump(b))
 
 	if t.isTcp == false && len(b) > mtu {
-		return fmt.Errorf("Request too big")
+		return nmxutil.NewReqSizeError(len(b), mtu)
 	}
 	frags := nmxutil.Fragment(b, mtu)
 	for _, frag := range frags {
@@ -277,11 +322,38 @@ func (t *Transceiver) TxRxMgmt(txCb TxFn, req *nmp.NmpMsg, mtu int,
 func (t *Transceiver) TxRxMgmtAsync(txCb TxFn, req *nmp.NmpMsg, mtu int,
 	timeout time.Duration, ch chan nmp.NmpRsp, errc chan error) error {
 
+	if err := t.acquireOutstanding(); err != nil {
+		return err
+	}
+
+	// The slot is released once the response or error has been delivered,
+	// or right away if the request could not be sent.
+	rspc := make(chan nmp.NmpRsp, 1)
+	rerrc := make(chan error, 1)
+
+	var err error
 	if t.nd != nil {
-		return t.txRxNmpAsync(txCb, req, mtu, timeout, ch, errc)
+		err = t.txRxNmpAsync(txCb, req, mtu, timeout, rspc, rerrc)
 	} else {
-		return t.txRxOmpAsync(txCb, req, mtu, timeout, ch, errc)
+		err = t.txRxOmpAsync(txCb, req, mtu, timeout, rspc, rerrc)
 	}
+	if err != nil {
+		t.releaseOutstanding()
+		return err
+	}
+
+	go func() {
+		select {
+		case rsp := <-rspc:
+			t.releaseOutstanding()
+			ch <- rsp
+		case err := <-rerrc:
+			t.releaseOutstanding()
+			errc <- err
+		}
+	}()
+
+	return nil
 }
 
 func (t *Transceiver) TxCoap(txCb TxFn, req coap.Message, mtu int) error {
@@ -352,6 +424,14 @@ func (t *Transceiver) ErrorAll(err error) {
 	t.od.ErrorAll(err)
 }
 
+// SetReassemblyTimeout bounds the time spent waiting for the remaining
+// fragments of a plain NMP response.  It has no effect on OMP transceivers.
+func (t *Transceiver) SetReassemblyTimeout(tmo time.Duration) {
+	if t.nd != nil {
+		t.nd.SetReassemblyTimeout(tmo)
+	}
+}
+
 func (t *Transceiver) AbortRx(seq uint8) {
 	t.ErrorOne(seq, fmt.Errorf("rx aborted"))
 }
@@ -360,6 +440,67 @@ func (t *Transceiver) Stop() {
 	t.od.Stop()
 }
 
+// SetOmpRes sets the CoAP resource that OMP requests are sent to.  An empty
+// string selects the default.
+func (t *Transceiver) SetOmpRes(res string) {
+	t.ompRes = res
+}
+
+// SetMaxOutstanding limits the number of requests that may await a response
+// at once.  A value of 0 or less selects sesn.SESN_DEF_MAX_OUTSTANDING.
+func (t *Transceiver) SetMaxOutstanding(max int) {
+	if max <= 0 {
+		max = sesn.SESN_DEF_MAX_OUTSTANDING
+	}
+
+	t.outstandingMtx.Lock()
+	defer t.outstandingMtx.Unlock()
+
+	t.maxOutstanding = max
+}
+
+// SetNmpAuth configures signing of outgoing NMP requests.  nil disables
+// signing.
+func (t *Transceiver) SetNmpAuth(auth *nmp.NmpAuth) {
+	t.nmpAuth = auth
+}
+
+// QueryMtuPref asks the device for the largest NMP message it accepts and
+// remembers it for LimitMtu.  txRx is the owning session's TxRxMgmt method.
+// Devices that don't support the query, or that don't advertise a size, leave
+// the transport's MTU in effect.
+func (t *Transceiver) QueryMtuPref(
+	txRx func(m *nmp.NmpMsg, timeout time.Duration) (nmp.NmpRsp, error),
+	timeout time.Duration) {
+
+	t.mtuPref = 0
+
+	req := nmp.NewMcumgrParamsReq()
+	r, err := txRx(req.Msg(), timeout)
+	if err != nil {
+		log.Debugf("Device MTU query failed: %s", err.Error())
+		return
+	}
+
+	rsp, ok := r.(*nmp.McumgrParamsRsp)
+	if !ok || rsp.Rc != 0 || rsp.BufSize <= nmp.NMP_HDR_SIZE {
+		log.Debugf("Device doesn't advertise an MTU")
+		return
+	}
+
+	t.mtuPref = rsp.BufSize - nmp.NMP_HDR_SIZE
+	log.Debugf("Device advertises a max NMP payload of %d", t.mtuPref)
+}
+
+// LimitMtu caps a transport's MTU at the size advertised by the device, if
+// any.
+func (t *Transceiver) LimitMtu(mtu int) int {
+	if t.mtuPref > 0 && t.mtuPref < mtu {
+		return t.mtuPref
+	}
+	return mtu
+}
+
 func (t *Transceiver) MgmtProto() sesn.MgmtProto {
 	return t.proto
 }
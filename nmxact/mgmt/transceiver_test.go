@@ -0,0 +1,152 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package mgmt
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmxutil"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
+)
+
+// Builds the device's response to an echo request with the given sequence
+// number: {"rc": 0}.
+func echoRspBytes(seq uint8) []byte {
+	body := []byte{0xa1, 0x62, 'r', 'c', 0x00}
+	hdr := nmp.NmpHdr{
+		Op:    nmp.NMP_OP_WRITE_RSP,
+		Len:   uint16(len(body)),
+		Group: nmp.NMP_GROUP_DEFAULT,
+		Seq:   seq,
+		Id:    nmp.NMP_ID_DEF_ECHO,
+	}
+	return append(hdr.Bytes(), body...)
+}
+
+func TestTransceiverMaxOutstanding(t *testing.T) {
+	const max = 3
+	const mtu = 512
+	const timeout = time.Minute
+
+	txvr, err := NewTransceiver(nil, nil, false, sesn.MGMT_PROTO_NMP, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer txvr.Stop()
+	txvr.SetMaxOutstanding(max)
+
+	var mtx sync.Mutex
+	var sent []uint8
+	txCb := func(b []byte) error {
+		hdr, err := nmp.DecodeNmpHdr(b)
+		if err != nil {
+			return err
+		}
+
+		mtx.Lock()
+		defer mtx.Unlock()
+		sent = append(sent, hdr.Seq)
+		return nil
+	}
+	numSent := func() int {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return len(sent)
+	}
+
+	ch := make(chan nmp.NmpRsp, max+1)
+	errc := make(chan error, max+1)
+
+	// Fill every slot.
+	for i := 0; i < max; i++ {
+		m := nmp.NewEchoReq().Msg()
+		err := txvr.TxRxMgmtAsync(txCb, m, mtu, timeout, ch, errc)
+		if err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+	}
+
+	// Further requests are rejected before anything is sent.
+	err = txvr.TxRxMgmtAsync(txCb, nmp.NewEchoReq().Msg(), mtu, timeout,
+		ch, errc)
+	if !nmxutil.IsOutstandingLimit(err) {
+		t.Fatalf("async request over limit: err = %v", err)
+	}
+	_, err = txvr.TxRxMgmt(txCb, nmp.NewEchoReq().Msg(), mtu, timeout)
+	if !nmxutil.IsOutstandingLimit(err) {
+		t.Fatalf("sync request over limit: err = %v", err)
+	}
+	if n := numSent(); n != max {
+		t.Fatalf("%d requests sent, want %d", n, max)
+	}
+
+	// A response frees its request's slot.
+	mtx.Lock()
+	seq := sent[0]
+	mtx.Unlock()
+	txvr.DispatchNmpRsp(echoRspBytes(seq))
+
+	select {
+	case rsp := <-ch:
+		if rsp.Hdr().Seq != seq {
+			t.Fatalf("response for seq %d, want %d", rsp.Hdr().Seq, seq)
+		}
+	case err := <-errc:
+		t.Fatalf("unexpected error: %v", err)
+	case <-time.After(time.Second):
+		t.Fatalf("response not delivered")
+	}
+
+	// A request that can't be sent gives its slot back immediately.
+	failCb := func(b []byte) error { return errors.New("tx failed") }
+	err = txvr.TxRxMgmtAsync(failCb, nmp.NewEchoReq().Msg(), mtu, timeout,
+		ch, errc)
+	if nmxutil.IsOutstandingLimit(err) || err == nil {
+		t.Fatalf("request that fails to send: err = %v", err)
+	}
+
+	err = txvr.TxRxMgmtAsync(txCb, nmp.NewEchoReq().Msg(), mtu, timeout,
+		ch, errc)
+	if err != nil {
+		t.Fatalf("request after response: %v", err)
+	}
+
+	// An error delivered to the pending requests frees their slots.
+	txvr.ErrorAll(errors.New("closed"))
+	for i := 0; i < max; i++ {
+		select {
+		case <-errc:
+		case <-time.After(time.Second):
+			t.Fatalf("only %d of %d errors delivered", i, max)
+		}
+	}
+
+	for i := 0; i < max; i++ {
+		m := nmp.NewEchoReq().Msg()
+		err := txvr.TxRxMgmtAsync(txCb, m, mtu, timeout, ch, errc)
+		if err != nil {
+			t.Fatalf("request %d after errors: %v", i, err)
+		}
+	}
+}
@@ -52,6 +52,7 @@ type LoraSesn struct {
 	tgtListener   *Listener
 	wg            sync.WaitGroup
 	stopChan      chan struct{}
+	timer         sesn.ConnTimer
 }
 
 type mtechLoraTx struct {
@@ -88,11 +89,17 @@ func (s *LoraSesn) Open() error {
 			"Attempt to open an already-open Lora session")
 	}
 
+	s.timer.Reset()
+	start := time.Now()
+
 	txvr, err := mgmt.NewTransceiver(s.cfg.TxFilter, s.cfg.RxFilter, false,
 		s.cfg.MgmtProto, 3)
 	if err != nil {
 		return err
 	}
+	txvr.SetOmpRes(s.cfg.OmpRes)
+	txvr.SetNmpAuth(s.cfg.NmpAuth)
+	txvr.SetMaxOutstanding(s.cfg.MaxOutstanding)
 	s.txvr = txvr
 	s.stopChan = make(chan struct{})
 
@@ -139,6 +146,8 @@ func (s *LoraSesn) Open() error {
 
 	if s.cfg.MgmtProto == sesn.MGMT_PROTO_COAP_SERVER {
 		s.isOpen = true
+		s.timer.Add(sesn.CONN_PHASE_CONNECT, start)
+		s.xport.sesnList.Add(s, s.cfg.Lora.Addr)
 		return nil
 	}
 	s.wg.Add(1)
@@ -166,6 +175,8 @@ func (s *LoraSesn) Open() error {
 		}
 	}()
 	s.isOpen = true
+	s.timer.Add(sesn.CONN_PHASE_CONNECT, start)
+	s.xport.sesnList.Add(s, s.cfg.Lora.Addr)
 	return nil
 }
 
@@ -313,7 +324,13 @@ func (s *LoraSesn) TxRxMgmt(m *nmp.NmpMsg,
 	txFunc := func(b []byte) error {
 		return s.sendFragments(b)
 	}
-	return s.txvr.TxRxMgmt(txFunc, m, s.MtuOut(), timeout)
+
+	start := time.Now()
+	rsp, err := s.txvr.TxRxMgmt(txFunc, m, s.MtuOut(), timeout)
+	if err == nil {
+		s.timer.RspRcvd(start)
+	}
+	return rsp, err
 }
 
 func (s *LoraSesn) TxRxMgmtAsync(m *nmp.NmpMsg,
@@ -355,6 +372,22 @@ func (s *LoraSesn) MgmtProto() sesn.MgmtProto {
 	return s.cfg.MgmtProto
 }
 
+func (s *LoraSesn) OmpRes() string {
+	return s.cfg.OmpRes
+}
+
+func (s *LoraSesn) Tag() string {
+	return s.cfg.Tag
+}
+
+func (s *LoraSesn) ConnectTimings() sesn.ConnTimings {
+	return s.timer.Timings()
+}
+
+func (s *LoraSesn) NmpAuth() *nmp.NmpAuth {
+	return s.cfg.NmpAuth
+}
+
 func (s *LoraSesn) CoapIsTcp() bool {
 	return false
 }
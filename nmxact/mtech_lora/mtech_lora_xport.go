@@ -36,6 +36,7 @@ import (
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/lora"
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmxutil"
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/xport"
 )
 
 type LoraConfig struct {
@@ -58,6 +59,7 @@ type LoraXport struct {
 	tgtMap   *ListenerSlice
 	exitChan chan int
 	joinCb   LoraJoinedCb
+	sesnList xport.SesnList
 }
 
 type LoraXportCfg struct {
@@ -99,7 +101,6 @@ func NewXportCfg() *LoraXportCfg {
 }
 
 func NewLoraXport(cfg *LoraXportCfg) *LoraXport {
-	log.SetLevel(log.DebugLevel)
 	return &LoraXport{
 		cfg:      cfg,
 		msgMap:   NewListenerMap(),
@@ -143,6 +144,12 @@ func (lx *LoraXport) BuildSesn(cfg sesn.SesnCfg) (sesn.Sesn, error) {
 	return NewLoraSesn(cfg, lx)
 }
 
+// Sessions describes each open session built by the transport, including
+// sessions accepted in server mode.
+func (lx *LoraXport) Sessions() []xport.SesnInfo {
+	return lx.sesnList.Infos("lora")
+}
+
 func (lx *LoraXport) acceptServerSesn(sl *Listener, dev string, port uint8) (*LoraSesn, error) {
 	sc := sesn.NewSesnCfg()
 	sc.MgmtProto = sesn.MGMT_PROTO_COAP_SERVER
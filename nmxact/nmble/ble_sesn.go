@@ -63,13 +63,23 @@ func (s *BleSesn) Open() error {
 	}
 	defer s.bx.ReleaseMaster()
 
-	return s.Ns.Open()
+	if err := s.Ns.Open(); err != nil {
+		return err
+	}
+
+	s.bx.sesnList.Add(s, s.cfg.PeerSpec.Ble.String())
+	return nil
 }
 
 func (s *BleSesn) OpenConnected(
 	connHandle uint16, eventListener *Listener) error {
 
-	return s.Ns.OpenConnected(connHandle, eventListener)
+	if err := s.Ns.OpenConnected(connHandle, eventListener); err != nil {
+		return err
+	}
+
+	s.bx.sesnList.Add(s, s.cfg.PeerSpec.Ble.String())
+	return nil
 }
 
 func (s *BleSesn) Close() error {
@@ -88,6 +98,22 @@ func (s *BleSesn) MtuOut() int {
 	return s.Ns.MtuOut()
 }
 
+func (s *BleSesn) OmpRes() string {
+	return s.Ns.OmpRes()
+}
+
+func (s *BleSesn) Tag() string {
+	return s.Ns.Tag()
+}
+
+func (s *BleSesn) NmpAuth() *nmp.NmpAuth {
+	return s.Ns.NmpAuth()
+}
+
+func (s *BleSesn) ConnectTimings() sesn.ConnTimings {
+	return s.Ns.ConnectTimings()
+}
+
 func (s *BleSesn) CoapIsTcp() bool {
 	return s.Ns.CoapIsTcp()
 }
@@ -743,6 +743,55 @@ func BuildMgmtChrs(mgmtProto sesn.MgmtProto) (BleMgmtChrs, error) {
 	return mgmtChrs, nil
 }
 
+// ApplyMgmtUuids replaces the management service and characteristic UUIDs in
+// mgmtChrs with any non-empty overrides.  If only reqChr is specified, it is
+// used for responses as well.
+func ApplyMgmtUuids(mgmtChrs *BleMgmtChrs,
+	svc string, reqChr string, rspChr string) error {
+
+	if svc == "" && reqChr == "" && rspChr == "" {
+		return nil
+	}
+
+	if reqChr != "" && rspChr == "" {
+		rspChr = reqChr
+	}
+
+	parse := func(what string, s string, dflt BleUuid) (BleUuid, error) {
+		if s == "" {
+			return dflt, nil
+		}
+		u, err := ParseUuid(s)
+		if err != nil {
+			return u, fmt.Errorf("invalid management %s UUID: %s", what, s)
+		}
+		return u, nil
+	}
+
+	req := *mgmtChrs.NmpReqChr
+	rsp := *mgmtChrs.NmpRspChr
+
+	var err error
+	if req.SvcUuid, err = parse("service", svc, req.SvcUuid); err != nil {
+		return err
+	}
+	rsp.SvcUuid = req.SvcUuid
+	if req.ChrUuid, err = parse("request characteristic", reqChr,
+		req.ChrUuid); err != nil {
+
+		return err
+	}
+	if rsp.ChrUuid, err = parse("response characteristic", rspChr,
+		rsp.ChrUuid); err != nil {
+
+		return err
+	}
+
+	mgmtChrs.NmpReqChr = &req
+	mgmtChrs.NmpRspChr = &rsp
+	return nil
+}
+
 func IsSecErr(err error) bool {
 	bhdErr := nmxutil.ToBleHost(err)
 	if bhdErr == nil {
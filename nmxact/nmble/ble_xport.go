@@ -31,6 +31,7 @@ import (
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmxutil"
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/task"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/xport"
 	"mynewt.apache.org/newt/util/unixchild"
 )
 
@@ -104,6 +105,9 @@ type BleXport struct {
 	// Map of open sessions (key: connection handle).
 	sesns map[uint16]*NakedSesn
 
+	// Open sessions built by the transport, for Sessions().
+	sesnList xport.SesnList
+
 	// Protects `enabled`.
 	mtx sync.Mutex
 }
@@ -405,6 +409,11 @@ func (bx *BleXport) BuildSesn(cfg sesn.SesnCfg) (sesn.Sesn, error) {
 	return NewBleSesn(bx, cfg)
 }
 
+// Sessions describes each open session built by the transport.
+func (bx *BleXport) Sessions() []xport.SesnInfo {
+	return bx.sesnList.Infos("ble")
+}
+
 func (bx *BleXport) Start() error {
 	initialize := func() error {
 		bx.mtx.Lock()
@@ -75,6 +75,8 @@ type NakedSesn struct {
 	shuttingDown bool
 
 	smIo SmIo
+
+	timer sesn.ConnTimer
 }
 
 func (s *NakedSesn) init() error {
@@ -90,6 +92,9 @@ func (s *NakedSesn) init() error {
 	if err != nil {
 		return err
 	}
+	txvr.SetOmpRes(s.cfg.OmpRes)
+	txvr.SetNmpAuth(s.cfg.NmpAuth)
+	txvr.SetMaxOutstanding(s.cfg.MaxOutstanding)
 	s.txvr = txvr
 
 	s.tq.Stop(fmt.Errorf("Ensuring task is stopped"))
@@ -106,6 +111,11 @@ func NewNakedSesn(bx *BleXport, cfg sesn.SesnCfg) (*NakedSesn, error) {
 	if err != nil {
 		return nil, err
 	}
+	err = ApplyMgmtUuids(&mgmtChrs, cfg.Ble.MgmtSvcUuid,
+		cfg.Ble.MgmtReqChrUuid, cfg.Ble.MgmtRspChrUuid)
+	if err != nil {
+		return nil, err
+	}
 
 	s := &NakedSesn{
 		cfg:      cfg,
@@ -221,10 +231,17 @@ func (s *NakedSesn) Open() error {
 		return err
 	}
 
+	s.timer.Reset()
+	s.timer.Set(sesn.CONN_PHASE_SCAN, s.cfg.Ble.ScanTime)
+
 	var err error
 	for i := 0; i < s.cfg.Ble.Central.ConnTries; i++ {
 		var retry bool
 
+		if i > 0 {
+			s.cfg.Ble.Central.ConnBackoff.Wait(i - 1)
+		}
+
 		retry, err = s.openOnce()
 		if err != nil {
 			s.shutdown(err)
@@ -248,6 +265,10 @@ func (s *NakedSesn) Open() error {
 	s.state = NS_STATE_OPEN
 	s.mtx.Unlock()
 
+	if s.cfg.MtuQueryTimeout != 0 {
+		s.txvr.QueryMtuPref(s.TxRxMgmt, s.cfg.MtuQueryTimeout)
+	}
+
 	return nil
 }
 
@@ -340,7 +361,11 @@ func (s *NakedSesn) TxRxMgmt(m *nmp.NmpMsg,
 			}
 		}
 
+		start := time.Now()
 		rsp, err = s.txvr.TxRxMgmt(txRaw, m, s.MtuOut(), timeout)
+		if err == nil {
+			s.timer.RspRcvd(start)
+		}
 		return err
 	}
 
@@ -433,7 +458,23 @@ func (s *NakedSesn) MtuIn() int {
 }
 
 func (s *NakedSesn) MtuOut() int {
-	return util.IntMin(s.MtuIn(), BLE_ATT_ATTR_MAX_LEN)
+	return s.txvr.LimitMtu(util.IntMin(s.MtuIn(), BLE_ATT_ATTR_MAX_LEN))
+}
+
+func (s *NakedSesn) OmpRes() string {
+	return s.cfg.OmpRes
+}
+
+func (s *NakedSesn) Tag() string {
+	return s.cfg.Tag
+}
+
+func (s *NakedSesn) ConnectTimings() sesn.ConnTimings {
+	return s.timer.Timings()
+}
+
+func (s *NakedSesn) NmpAuth() *nmp.NmpAuth {
+	return s.cfg.NmpAuth
 }
 
 func (s *NakedSesn) CoapIsTcp() bool {
@@ -468,11 +509,13 @@ func (s *NakedSesn) openOnce() (bool, error) {
 	// Listen for disconnect in the background.
 	s.disconnectListen()
 
-	if err := s.conn.Connect(
+	start := time.Now()
+	err := s.conn.Connect(
 		s.cfg.Ble.OwnAddrType,
 		s.cfg.PeerSpec.Ble,
-		s.cfg.Ble.Central.ConnTimeout); err != nil {
-
+		s.cfg.Ble.Central.ConnTimeout)
+	s.timer.Add(sesn.CONN_PHASE_CONNECT, start)
+	if err != nil {
 		// An ENOTCONN error code implies the "conn_find" request failed
 		// because the connection dropped immediately after being established.
 		// If this happened, retry the connect procedure.
@@ -481,7 +524,10 @@ func (s *NakedSesn) openOnce() (bool, error) {
 		return retry, err
 	}
 
-	if err := s.conn.ExchangeMtu(); err != nil {
+	start = time.Now()
+	err = s.conn.ExchangeMtu()
+	s.timer.Add(sesn.CONN_PHASE_MTU, start)
+	if err != nil {
 		// An ENOTCONN error code implies the connection dropped before the
 		// first ACL data transmission.  If this happened, retry the connect
 		// procedure.
@@ -490,6 +536,7 @@ func (s *NakedSesn) openOnce() (bool, error) {
 		return retry, err
 	}
 
+	start = time.Now()
 	if err := s.conn.DiscoverSvcs(); err != nil {
 		return false, err
 	}
@@ -501,6 +548,7 @@ func (s *NakedSesn) openOnce() (bool, error) {
 			}
 		}
 	}
+	s.timer.Add(sesn.CONN_PHASE_DISCOVER, start)
 
 	// Listen for incoming notifications in the background.
 	s.notifyListen()
@@ -509,9 +557,11 @@ func (s *NakedSesn) openOnce() (bool, error) {
 	s.smIoDemandListen()
 
 	if s.cfg.Ble.EncryptWhen == BLE_ENCRYPT_ALWAYS {
+		start = time.Now()
 		if err := s.initiateSecurity(); err != nil {
 			return false, err
 		}
+		s.timer.Add(sesn.CONN_PHASE_SECURITY, start)
 	}
 
 	return false, nil
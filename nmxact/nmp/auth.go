@@ -0,0 +1,165 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package nmp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+)
+
+// Signed requests carry an authentication trailer after the CBOR body and
+// have NMP_FLAG_AUTH set in the header.  The header's length field covers
+// both the body and the trailer:
+//
+// [header][body][alg:1][key id:1][sig len:1][sig]
+//
+// The signature is calculated over everything that precedes it, i.e., the
+// header (with its final flags and length), the body, and the first three
+// bytes of the trailer.
+
+const NMP_FLAG_AUTH = 0x01
+
+const NMP_AUTH_TRAILER_HDR_SIZE = 3
+
+type NmpAuthAlg uint8
+
+const (
+	NMP_AUTH_ALG_NONE        NmpAuthAlg = 0
+	NMP_AUTH_ALG_HMAC_SHA256 NmpAuthAlg = 1
+	NMP_AUTH_ALG_ECDSA_P256  NmpAuthAlg = 2
+)
+
+var nmpAuthAlgNameMap = map[NmpAuthAlg]string{
+	NMP_AUTH_ALG_NONE:        "none",
+	NMP_AUTH_ALG_HMAC_SHA256: "hmac-sha256",
+	NMP_AUTH_ALG_ECDSA_P256:  "ecdsa-p256",
+}
+
+func NmpAuthAlgToString(alg NmpAuthAlg) string {
+	return nmpAuthAlgNameMap[alg]
+}
+
+func NmpAuthAlgFromString(s string) (NmpAuthAlg, error) {
+	for k, v := range nmpAuthAlgNameMap {
+		if s == v {
+			return k, nil
+		}
+	}
+	return NMP_AUTH_ALG_NONE, fmt.Errorf("Invalid auth algorithm: %s", s)
+}
+
+// NmpAuth describes how outgoing requests are signed.  HmacKey is used with
+// NMP_AUTH_ALG_HMAC_SHA256; EcdsaKey with NMP_AUTH_ALG_ECDSA_P256.
+type NmpAuth struct {
+	Alg      NmpAuthAlg
+	KeyId    uint8
+	HmacKey  []byte
+	EcdsaKey *ecdsa.PrivateKey
+}
+
+// SigLen returns the length of the signatures a produces.  ECDSA signatures
+// are encoded as the fixed-width concatenation r || s.
+func (a *NmpAuth) SigLen() (int, error) {
+	switch a.Alg {
+	case NMP_AUTH_ALG_HMAC_SHA256:
+		return sha256.Size, nil
+
+	case NMP_AUTH_ALG_ECDSA_P256:
+		return 64, nil
+
+	default:
+		return 0, fmt.Errorf("Unsupported auth algorithm: %d", a.Alg)
+	}
+}
+
+func (a *NmpAuth) sign(data []byte) ([]byte, error) {
+	switch a.Alg {
+	case NMP_AUTH_ALG_HMAC_SHA256:
+		if len(a.HmacKey) == 0 {
+			return nil, fmt.Errorf("HMAC auth configured without a key")
+		}
+		mac := hmac.New(sha256.New, a.HmacKey)
+		mac.Write(data)
+		return mac.Sum(nil), nil
+
+	case NMP_AUTH_ALG_ECDSA_P256:
+		if a.EcdsaKey == nil || a.EcdsaKey.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("ECDSA auth requires a P-256 private key")
+		}
+		digest := sha256.Sum256(data)
+		r, s, err := ecdsa.Sign(rand.Reader, a.EcdsaKey, digest[:])
+		if err != nil {
+			return nil, err
+		}
+		sig := make([]byte, 64)
+		padBigInt(sig[:32], r)
+		padBigInt(sig[32:], s)
+		return sig, nil
+
+	default:
+		return nil, fmt.Errorf("Unsupported auth algorithm: %d", a.Alg)
+	}
+}
+
+// padBigInt writes n into dst as a big-endian integer, left-padded with
+// zeros.
+func padBigInt(dst []byte, n *big.Int) {
+	b := n.Bytes()
+	copy(dst[len(dst)-len(b):], b)
+}
+
+// EncodeNmpAuth encodes a request and appends an authentication trailer.
+func EncodeNmpAuth(nmr *NmpMsg, auth *NmpAuth) ([]byte, error) {
+	sigLen, err := auth.SigLen()
+	if err != nil {
+		return nil, err
+	}
+
+	bb, err := BodyBytes(nmr.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	nmr.Hdr.Flags |= NMP_FLAG_AUTH
+	nmr.Hdr.Len = uint16(len(bb) + NMP_AUTH_TRAILER_HDR_SIZE + sigLen)
+
+	data := append(nmr.Hdr.Bytes(), bb...)
+	data = append(data, byte(auth.Alg), auth.KeyId, byte(sigLen))
+
+	sig, err := auth.sign(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(data, sig...), nil
+}
+
+// EncodeNmp encodes a request, signing it if auth is non-nil.
+func EncodeNmp(nmr *NmpMsg, auth *NmpAuth) ([]byte, error) {
+	if auth == nil || auth.Alg == NMP_AUTH_ALG_NONE {
+		return EncodeNmpPlain(nmr)
+	}
+	return EncodeNmpAuth(nmr, auth)
+}
@@ -0,0 +1,160 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package nmp
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"testing"
+)
+
+// An echo request with payload "hi", signed with HMAC-SHA256 under key ID 7.
+const (
+	authTestHdr     = "0201002900004200"
+	authTestBody    = "a16164626869"
+	authTestTrailer = "010720"
+	authTestHmacKey = "nmp-test-key"
+	authTestHmacSig = "c82518cc7868580d693805b213b9884831df92da" +
+		"08aa2d6a801a99714c5dcaac"
+)
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("bad hex string %s: %s", s, err.Error())
+	}
+	return b
+}
+
+func authTestEchoMsg() *NmpMsg {
+	return &NmpMsg{
+		Hdr: NmpHdr{
+			Op:  NMP_OP_WRITE,
+			Seq: 0x42,
+		},
+		Body: &EchoReq{Payload: "hi"},
+	}
+}
+
+func TestNmpAuthHmacVector(t *testing.T) {
+	auth := &NmpAuth{
+		Alg:     NMP_AUTH_ALG_HMAC_SHA256,
+		KeyId:   7,
+		HmacKey: []byte(authTestHmacKey),
+	}
+
+	data := mustDecodeHex(t, authTestHdr+authTestBody+authTestTrailer)
+	sig, err := auth.sign(data)
+	if err != nil {
+		t.Fatalf("sign failed: %s", err.Error())
+	}
+
+	exp := mustDecodeHex(t, authTestHmacSig)
+	if !bytes.Equal(sig, exp) {
+		t.Fatalf("wrong signature; have=%x want=%x", sig, exp)
+	}
+}
+
+func TestEncodeNmpAuthHmac(t *testing.T) {
+	auth := &NmpAuth{
+		Alg:     NMP_AUTH_ALG_HMAC_SHA256,
+		KeyId:   7,
+		HmacKey: []byte(authTestHmacKey),
+	}
+
+	b, err := EncodeNmpAuth(authTestEchoMsg(), auth)
+	if err != nil {
+		t.Fatalf("encode failed: %s", err.Error())
+	}
+
+	exp := mustDecodeHex(t,
+		authTestHdr+authTestBody+authTestTrailer+authTestHmacSig)
+	if !bytes.Equal(b, exp) {
+		t.Fatalf("wrong encoding;\nhave=%x\nwant=%x", b, exp)
+	}
+}
+
+func TestEncodeNmpAuthEcdsa(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("key generation failed: %s", err.Error())
+	}
+
+	auth := &NmpAuth{
+		Alg:      NMP_AUTH_ALG_ECDSA_P256,
+		KeyId:    3,
+		EcdsaKey: key,
+	}
+
+	b, err := EncodeNmpAuth(authTestEchoMsg(), auth)
+	if err != nil {
+		t.Fatalf("encode failed: %s", err.Error())
+	}
+
+	body := mustDecodeHex(t, authTestBody)
+	sigOff := NMP_HDR_SIZE + len(body) + NMP_AUTH_TRAILER_HDR_SIZE
+	if len(b) != sigOff+64 {
+		t.Fatalf("wrong length; have=%d want=%d", len(b), sigOff+64)
+	}
+
+	hdr, err := DecodeNmpHdr(b)
+	if err != nil {
+		t.Fatalf("header decode failed: %s", err.Error())
+	}
+	if hdr.Flags&NMP_FLAG_AUTH == 0 {
+		t.Errorf("auth flag not set; flags=0x%02x", hdr.Flags)
+	}
+	if int(hdr.Len) != len(b)-NMP_HDR_SIZE {
+		t.Errorf("wrong header length; have=%d want=%d",
+			hdr.Len, len(b)-NMP_HDR_SIZE)
+	}
+
+	trailer := b[sigOff-NMP_AUTH_TRAILER_HDR_SIZE : sigOff]
+	if !bytes.Equal(trailer, []byte{byte(NMP_AUTH_ALG_ECDSA_P256), 3, 64}) {
+		t.Errorf("wrong trailer header: %x", trailer)
+	}
+
+	digest := sha256.Sum256(b[:sigOff])
+	r := new(big.Int).SetBytes(b[sigOff : sigOff+32])
+	s := new(big.Int).SetBytes(b[sigOff+32:])
+	if !ecdsa.Verify(&key.PublicKey, digest[:], r, s) {
+		t.Fatalf("signature does not verify")
+	}
+}
+
+func TestNmpAuthEcdsaWrongCurve(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatalf("key generation failed: %s", err.Error())
+	}
+
+	auth := &NmpAuth{
+		Alg:      NMP_AUTH_ALG_ECDSA_P256,
+		EcdsaKey: key,
+	}
+	if _, err := EncodeNmpAuth(authTestEchoMsg(), auth); err == nil {
+		t.Fatalf("P-384 key accepted")
+	}
+}
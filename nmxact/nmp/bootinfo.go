@@ -0,0 +1,81 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package nmp
+
+import ()
+
+// Boot loader modes reported by MCUboot.
+const (
+	BOOT_MODE_UNKNOWN         = -1
+	BOOT_MODE_SINGLE          = 0
+	BOOT_MODE_SWAP_SCRATCH    = 1
+	BOOT_MODE_OVERWRITE_ONLY  = 2
+	BOOT_MODE_SWAP_MOVE       = 3
+	BOOT_MODE_DIRECT_XIP      = 4
+	BOOT_MODE_DIRECT_XIP_REVT = 5
+	BOOT_MODE_RAM_LOAD        = 6
+)
+
+var BootModeNameMap = map[int]string{
+	BOOT_MODE_SINGLE:          "single",
+	BOOT_MODE_SWAP_SCRATCH:    "swap-scratch",
+	BOOT_MODE_OVERWRITE_ONLY:  "overwrite-only",
+	BOOT_MODE_SWAP_MOVE:       "swap-move",
+	BOOT_MODE_DIRECT_XIP:      "direct-xip",
+	BOOT_MODE_DIRECT_XIP_REVT: "direct-xip-revert",
+	BOOT_MODE_RAM_LOAD:        "ram-load",
+}
+
+func BootModeToString(mode int) string {
+	name := BootModeNameMap[mode]
+	if name == "" {
+		name = "unknown"
+	}
+	return name
+}
+
+type BootloaderInfoReq struct {
+	NmpBase `codec:"-"`
+	Query   string `codec:"query,omitempty"`
+}
+
+type BootloaderInfoRsp struct {
+	NmpBase
+	Rc          int    `codec:"rc"`
+	Bootloader  string `codec:"bootloader"`
+	Mode        int    `codec:"mode"`
+	NoDowngrade bool   `codec:"no-downgrade"`
+}
+
+func NewBootloaderInfoReq() *BootloaderInfoReq {
+	r := &BootloaderInfoReq{}
+	fillNmpReq(r, NMP_OP_READ, NMP_GROUP_DEFAULT, NMP_ID_DEF_BOOTLOADER_INFO)
+	return r
+}
+
+func (r *BootloaderInfoReq) Msg() *NmpMsg { return MsgFromReq(r) }
+
+func NewBootloaderInfoRsp() *BootloaderInfoRsp {
+	return &BootloaderInfoRsp{
+		Mode: BOOT_MODE_UNKNOWN,
+	}
+}
+
+func (r *BootloaderInfoRsp) Msg() *NmpMsg { return MsgFromReq(r) }
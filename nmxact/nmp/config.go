@@ -59,6 +59,10 @@ type ConfigWriteReq struct {
 	Name string `codec:"name,omitempty"`
 	Val  string `codec:"val,omitempty"`
 	Save bool   `codec:"save,omitempty"`
+
+	// If non-nil, sent as the value in place of Val, encoded with its own
+	// CBOR type (e.g., int64, bool, or []byte).
+	TypedVal interface{} `codec:"-"`
 }
 
 type ConfigWriteRsp struct {
@@ -72,10 +76,65 @@ func NewConfigWriteReq() *ConfigWriteReq {
 	return r
 }
 
-func (r *ConfigWriteReq) Msg() *NmpMsg { return MsgFromReq(r) }
+func (r *ConfigWriteReq) Msg() *NmpMsg {
+	if r.TypedVal == nil {
+		return MsgFromReq(r)
+	}
+
+	body := map[string]interface{}{
+		"val": r.TypedVal,
+	}
+	if r.Name != "" {
+		body["name"] = r.Name
+	}
+	if r.Save {
+		body["save"] = true
+	}
+
+	return &NmpMsg{
+		*r.Hdr(),
+		body,
+	}
+}
 
 func NewConfigWriteRsp() *ConfigWriteRsp {
 	return &ConfigWriteRsp{}
 }
 
 func (r *ConfigWriteRsp) Msg() *NmpMsg { return MsgFromReq(r) }
+
+//////////////////////////////////////////////////////////////////////////////
+// $list                                                                    //
+//////////////////////////////////////////////////////////////////////////////
+
+// ConfigListReq requests one page of config values starting at Off.
+type ConfigListReq struct {
+	NmpBase     `codec:"-"`
+	Off     int `codec:"off"`
+}
+
+// ConfigListRsp carries one page of config values.  Next is the offset of
+// the following page, or -1 if this is the last one.  A device that does
+// not paginate omits Next and returns everything in a single response.
+type ConfigListRsp struct {
+	NmpBase
+	Rc   int               `codec:"rc"`
+	Vals map[string]string `codec:"vals"`
+	Next int               `codec:"next"`
+}
+
+func NewConfigListReq() *ConfigListReq {
+	r := &ConfigListReq{}
+	fillNmpReq(r, NMP_OP_READ, NMP_GROUP_CONFIG, NMP_ID_CONFIG_LIST)
+	return r
+}
+
+func (r *ConfigListReq) Msg() *NmpMsg { return MsgFromReq(r) }
+
+func NewConfigListRsp() *ConfigListRsp {
+	return &ConfigListRsp{
+		Next: -1,
+	}
+}
+
+func (r *ConfigListRsp) Msg() *NmpMsg { return MsgFromReq(r) }
@@ -53,6 +53,8 @@ func mpStatRspCtor() NmpRsp        { return NewMempoolStatRsp() }
 func dateTimeReadRspCtor() NmpRsp  { return NewDateTimeReadRsp() }
 func dateTimeWriteRspCtor() NmpRsp { return NewDateTimeWriteRsp() }
 func resetRspCtor() NmpRsp         { return NewResetRsp() }
+func bootInfoRspCtor() NmpRsp      { return NewBootloaderInfoRsp() }
+func mcumgrParamsRspCtor() NmpRsp  { return NewMcumgrParamsRsp() }
 func imageUploadRspCtor() NmpRsp   { return NewImageUploadRsp() }
 func imageStateRspCtor() NmpRsp    { return NewImageStateRsp() }
 func coreListRspCtor() NmpRsp      { return NewCoreListRsp() }
@@ -71,39 +73,51 @@ func runTestRspCtor() NmpRsp       { return NewRunTestRsp() }
 func runListRspCtor() NmpRsp       { return NewRunListRsp() }
 func fsDownloadRspCtor() NmpRsp    { return NewFsDownloadRsp() }
 func fsUploadRspCtor() NmpRsp      { return NewFsUploadRsp() }
+func fsStatRspCtor() NmpRsp        { return NewFsStatRsp() }
+func fsHashRspCtor() NmpRsp        { return NewFsHashRsp() }
+func fsMountsRspCtor() NmpRsp      { return NewFsMountsRsp() }
 func configReadRspCtor() NmpRsp    { return NewConfigReadRsp() }
 func configWriteRspCtor() NmpRsp   { return NewConfigWriteRsp() }
+func configListRspCtor() NmpRsp    { return NewConfigListRsp() }
 func shellExecRspCtor() NmpRsp     { return NewShellExecRsp() }
+func memReadRspCtor() NmpRsp       { return NewMemReadRsp() }
+func memWriteRspCtor() NmpRsp      { return NewMemWriteRsp() }
 
 var rspCtorMap = map[Ogi]rspCtor{
-	{op_wr, gr_def, NMP_ID_DEF_ECHO}:         echoRspCtor,
-	{op_rr, gr_def, NMP_ID_DEF_TASKSTAT}:     taskStatRspCtor,
-	{op_rr, gr_def, NMP_ID_DEF_MPSTAT}:       mpStatRspCtor,
-	{op_rr, gr_def, NMP_ID_DEF_DATETIME_STR}: dateTimeReadRspCtor,
-	{op_wr, gr_def, NMP_ID_DEF_DATETIME_STR}: dateTimeWriteRspCtor,
-	{op_wr, gr_def, NMP_ID_DEF_RESET}:        resetRspCtor,
-	{op_wr, gr_img, NMP_ID_IMAGE_UPLOAD}:     imageUploadRspCtor,
-	{op_rr, gr_img, NMP_ID_IMAGE_STATE}:      imageStateRspCtor,
-	{op_wr, gr_img, NMP_ID_IMAGE_STATE}:      imageStateRspCtor,
-	{op_rr, gr_img, NMP_ID_IMAGE_CORELIST}:   coreListRspCtor,
-	{op_rr, gr_img, NMP_ID_IMAGE_CORELOAD}:   coreLoadRspCtor,
-	{op_wr, gr_img, NMP_ID_IMAGE_CORELOAD}:   coreEraseRspCtor,
-	{op_wr, gr_img, NMP_ID_IMAGE_ERASE}:      imageEraseRspCtor,
-	{op_rr, gr_sta, NMP_ID_STAT_READ}:        statReadRspCtor,
-	{op_rr, gr_sta, NMP_ID_STAT_LIST}:        statListRspCtor,
-	{op_rr, gr_log, NMP_ID_LOG_SHOW}:         logReadRspCtor,
-	{op_rr, gr_log, NMP_ID_LOG_LIST}:         logListRspCtor,
-	{op_rr, gr_log, NMP_ID_LOG_MODULE_LIST}:  logModuleListRspCtor,
-	{op_rr, gr_log, NMP_ID_LOG_LEVEL_LIST}:   logLevelListRspCtor,
-	{op_wr, gr_log, NMP_ID_LOG_CLEAR}:        logClearRspCtor,
-	{op_wr, gr_cra, NMP_ID_CRASH_TRIGGER}:    crashRspCtor,
-	{op_wr, gr_run, NMP_ID_RUN_TEST}:         runTestRspCtor,
-	{op_rr, gr_run, NMP_ID_RUN_LIST}:         runListRspCtor,
-	{op_rr, gr_fil, NMP_ID_FS_FILE}:          fsDownloadRspCtor,
-	{op_wr, gr_fil, NMP_ID_FS_FILE}:          fsUploadRspCtor,
-	{op_rr, gr_cfg, NMP_ID_CONFIG_VAL}:       configReadRspCtor,
-	{op_wr, gr_cfg, NMP_ID_CONFIG_VAL}:       configWriteRspCtor,
-	{op_wr, gr_she, NMP_ID_SHELL_EXEC}:       shellExecRspCtor,
+	{op_wr, gr_def, NMP_ID_DEF_ECHO}:            echoRspCtor,
+	{op_rr, gr_def, NMP_ID_DEF_TASKSTAT}:        taskStatRspCtor,
+	{op_rr, gr_def, NMP_ID_DEF_MPSTAT}:          mpStatRspCtor,
+	{op_rr, gr_def, NMP_ID_DEF_DATETIME_STR}:    dateTimeReadRspCtor,
+	{op_wr, gr_def, NMP_ID_DEF_DATETIME_STR}:    dateTimeWriteRspCtor,
+	{op_wr, gr_def, NMP_ID_DEF_RESET}:           resetRspCtor,
+	{op_rr, gr_def, NMP_ID_DEF_BOOTLOADER_INFO}: bootInfoRspCtor,
+	{op_rr, gr_def, NMP_ID_DEF_MCUMGR_PARAMS}:   mcumgrParamsRspCtor,
+	{op_wr, gr_img, NMP_ID_IMAGE_UPLOAD}:        imageUploadRspCtor,
+	{op_rr, gr_img, NMP_ID_IMAGE_STATE}:         imageStateRspCtor,
+	{op_wr, gr_img, NMP_ID_IMAGE_STATE}:         imageStateRspCtor,
+	{op_rr, gr_img, NMP_ID_IMAGE_CORELIST}:      coreListRspCtor,
+	{op_rr, gr_img, NMP_ID_IMAGE_CORELOAD}:      coreLoadRspCtor,
+	{op_wr, gr_img, NMP_ID_IMAGE_CORELOAD}:      coreEraseRspCtor,
+	{op_wr, gr_img, NMP_ID_IMAGE_ERASE}:         imageEraseRspCtor,
+	{op_rr, gr_sta, NMP_ID_STAT_READ}:           statReadRspCtor,
+	{op_rr, gr_sta, NMP_ID_STAT_LIST}:           statListRspCtor,
+	{op_rr, gr_log, NMP_ID_LOG_SHOW}:            logReadRspCtor,
+	{op_rr, gr_log, NMP_ID_LOG_LIST}:            logListRspCtor,
+	{op_rr, gr_log, NMP_ID_LOG_MODULE_LIST}:     logModuleListRspCtor,
+	{op_rr, gr_log, NMP_ID_LOG_LEVEL_LIST}:      logLevelListRspCtor,
+	{op_wr, gr_log, NMP_ID_LOG_CLEAR}:           logClearRspCtor,
+	{op_wr, gr_cra, NMP_ID_CRASH_TRIGGER}:       crashRspCtor,
+	{op_wr, gr_run, NMP_ID_RUN_TEST}:            runTestRspCtor,
+	{op_rr, gr_run, NMP_ID_RUN_LIST}:            runListRspCtor,
+	{op_rr, gr_fil, NMP_ID_FS_FILE}:             fsDownloadRspCtor,
+	{op_wr, gr_fil, NMP_ID_FS_FILE}:             fsUploadRspCtor,
+	{op_rr, gr_fil, NMP_ID_FS_STAT}:             fsStatRspCtor,
+	{op_rr, gr_fil, NMP_ID_FS_HASH}:             fsHashRspCtor,
+	{op_rr, gr_fil, NMP_ID_FS_MOUNTS}:           fsMountsRspCtor,
+	{op_rr, gr_cfg, NMP_ID_CONFIG_VAL}:          configReadRspCtor,
+	{op_wr, gr_cfg, NMP_ID_CONFIG_VAL}:          configWriteRspCtor,
+	{op_rr, gr_cfg, NMP_ID_CONFIG_LIST}:         configListRspCtor,
+	{op_wr, gr_she, NMP_ID_SHELL_EXEC}:          shellExecRspCtor,
 }
 
 func DecodeRspBody(hdr *NmpHdr, body []byte) (NmpRsp, error) {
@@ -118,16 +132,51 @@ func DecodeRspBody(hdr *NmpHdr, body []byte) (NmpRsp, error) {
 	dec := codec.NewDecoderBytes(body, cborCodec)
 
 	if err := dec.Decode(r); err != nil {
-		return nil, fmt.Errorf("Invalid response: %s", err.Error())
+		return nil, newRspDecodeError(hdr, body, err)
+	}
+
+	if rc, ok := r.(rspChecker); ok {
+		derr := newRspDecodeError(hdr, body, nil)
+		if derr.Fields != nil {
+			if err := rc.checkFields(derr.Fields); err != nil {
+				derr.Err = err
+				return nil, derr
+			}
+		}
 	}
 
 	r.SetHdr(hdr)
+
+	var st rspStatus
+	dec = codec.NewDecoderBytes(body, cborCodec)
+	if err := dec.Decode(&st); err == nil && st.Rc != NMP_ERR_OK {
+		if es, ok := r.(rspErrSetter); ok {
+			es.setErr(&NmpRcError{
+				Rc:    st.Rc,
+				Op:    hdr.Op,
+				Group: hdr.Group,
+				Id:    hdr.Id,
+			})
+		}
+	}
+
 	return r, nil
 }
 
-func RegisterResponseHandler (ogi Ogi, f rspCtor) {
-	cb := rspCtorMap[ogi]
-	if cb == nil {
-		rspCtorMap[ogi] = f
+// RegisterResponseHandler arranges for responses matching ogi to be decoded
+// with f.  It fails if ogi already has a handler.
+func RegisterResponseHandler(ogi Ogi, f rspCtor) error {
+	if rspCtorMap[ogi] != nil {
+		return fmt.Errorf("NMP response handler already registered: "+
+			"op=%d group=%d id=%d", ogi.Op, ogi.Group, ogi.Id)
 	}
+
+	rspCtorMap[ogi] = f
+	return nil
+}
+
+// ResponseHandlerRegistered indicates whether responses matching ogi have a
+// handler.
+func ResponseHandlerRegistered(ogi Ogi) bool {
+	return rspCtorMap[ogi] != nil
 }
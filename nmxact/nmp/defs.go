@@ -27,12 +27,15 @@ const (
 )
 
 const (
-	NMP_ERR_OK       = 0
-	NMP_ERR_EUNKNOWN = 1
-	NMP_ERR_ENOMEM   = 2
-	NMP_ERR_EINVAL   = 3
-	NMP_ERR_ETIMEOUT = 4
-	NMP_ERR_ENOENT   = 5
+	NMP_ERR_OK        = 0
+	NMP_ERR_EUNKNOWN  = 1
+	NMP_ERR_ENOMEM    = 2
+	NMP_ERR_EINVAL    = 3
+	NMP_ERR_ETIMEOUT  = 4
+	NMP_ERR_ENOENT    = 5
+	NMP_ERR_EBADSTATE = 6
+	NMP_ERR_EMSGSIZE  = 7
+	NMP_ERR_ENOTSUP   = 8
 )
 
 // First 64 groups are reserved for system level newtmgr commands.
@@ -54,12 +57,14 @@ const (
 
 // Default group (0).
 const (
-	NMP_ID_DEF_ECHO           = 0
-	NMP_ID_DEF_CONS_ECHO_CTRL = 1
-	NMP_ID_DEF_TASKSTAT       = 2
-	NMP_ID_DEF_MPSTAT         = 3
-	NMP_ID_DEF_DATETIME_STR   = 4
-	NMP_ID_DEF_RESET          = 5
+	NMP_ID_DEF_ECHO            = 0
+	NMP_ID_DEF_CONS_ECHO_CTRL  = 1
+	NMP_ID_DEF_TASKSTAT        = 2
+	NMP_ID_DEF_MPSTAT          = 3
+	NMP_ID_DEF_DATETIME_STR    = 4
+	NMP_ID_DEF_RESET           = 5
+	NMP_ID_DEF_MCUMGR_PARAMS   = 6
+	NMP_ID_DEF_BOOTLOADER_INFO = 8
 )
 
 // Image group (1).
@@ -79,7 +84,8 @@ const (
 
 // Config group (3).
 const (
-	NMP_ID_CONFIG_VAL = 0
+	NMP_ID_CONFIG_VAL  = 0
+	NMP_ID_CONFIG_LIST = 1
 )
 
 // Log group (4).
@@ -106,9 +112,21 @@ const (
 // File system group (8).
 const (
 	NMP_ID_FS_FILE = 0
+	NMP_ID_FS_STAT = 1
+	NMP_ID_FS_HASH = 2
+
+	// Not part of the standard file system group; only available on
+	// firmware that implements it.
+	NMP_ID_FS_MOUNTS = 5
 )
 
 // Shell group (8).
 const (
 	NMP_ID_SHELL_EXEC = 0
 )
+
+// Memory group.  It is product-specific, not part of upstream mcumgr, so its
+// group number is chosen at run time.
+const (
+	NMP_ID_MEMORY_ACCESS = 0
+)
@@ -80,6 +80,21 @@ func NewDispatcher(logDepth int) *Dispatcher {
 	}
 }
 
+// SetReassemblyTimeout limits how long a fragmented response may take to
+// arrive in full.  On expiry, the waiting listener receives a
+// ReassemblyTimeoutError.
+func (d *Dispatcher) SetReassemblyTimeout(tmo time.Duration) {
+	d.reassembler.SetTimeout(tmo, func(hdr *NmpHdr) {
+		err := nmxutil.FmtReassemblyTimeoutError(
+			"NMP reassembly timeout (%s)", tmo.String())
+		if hdr != nil {
+			d.ErrorOne(hdr.Seq, err)
+		} else {
+			d.ErrorAll(err)
+		}
+	})
+}
+
 func (d *Dispatcher) AddListener(seq uint8) (*Listener, error) {
 	nmxutil.LogAddNmpListener(d.logDepth, seq)
 
@@ -145,7 +160,13 @@ func (d *Dispatcher) DispatchRsp(r NmpRsp) bool {
 
 // Returns true if the response was dispatched.
 func (d *Dispatcher) Dispatch(data []byte) bool {
-	pkt := d.reassembler.RxFrag(data)
+	pkt, err := d.reassembler.RxFrag(data)
+	if err != nil {
+		// The packet's header can't be trusted, so fail every pending
+		// request rather than only the one it claims to answer.
+		d.ErrorAll(err)
+		return false
+	}
 	if pkt == nil {
 		return false
 	}
@@ -154,6 +175,12 @@ func (d *Dispatcher) Dispatch(data []byte) bool {
 	if err != nil {
 		log.Debugf("Failure decoding NMP rsp: %s\npacket=\n%s", err.Error(),
 			hex.Dump(data))
+
+		// A malformed body still identifies its request; fail that request
+		// now with the decode error rather than letting it time out.
+		if derr, ok := err.(*RspDecodeError); ok {
+			d.ErrorOne(derr.Hdr.Seq, err)
+		}
 		return false
 	}
 
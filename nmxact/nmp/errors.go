@@ -0,0 +1,149 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package nmp
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ugorji/go/codec"
+)
+
+// ErrNmpNotSupported indicates that the device does not implement the
+// requested NMP operation.  Errors carrying a device status code match it via
+// errors.Is when the code denotes a missing capability; a caller can use this
+// to fall back to an alternate command.
+var ErrNmpNotSupported = errors.New("NMP operation not supported by device")
+
+// NmpRcError wraps a non-zero status code reported by a device.
+type NmpRcError struct {
+	Rc    int
+	Op    uint8
+	Group uint16
+	Id    uint8
+}
+
+func NewNmpRcError(rc int) *NmpRcError {
+	return &NmpRcError{Rc: rc}
+}
+
+func (e *NmpRcError) Error() string {
+	return fmt.Sprintf("NMP request failed: op=%d group=%d id=%d rc=%d (%s)",
+		e.Op, e.Group, e.Id, e.Rc, RcString(e.Rc))
+}
+
+func (e *NmpRcError) Is(target error) bool {
+	return target == ErrNmpNotSupported && RcNotSupported(e.Rc)
+}
+
+// RspDecodeError indicates that a response had a valid header but a body
+// that did not match the expected response type.  Fields holds the body as
+// a generic CBOR map, or nil if it is not a map with string keys.
+type RspDecodeError struct {
+	Hdr    NmpHdr
+	Fields map[string]interface{}
+	Err    error
+}
+
+func newRspDecodeError(hdr *NmpHdr, body []byte, err error) *RspDecodeError {
+	e := &RspDecodeError{
+		Hdr: *hdr,
+		Err: err,
+	}
+
+	m := map[string]interface{}{}
+	dec := codec.NewDecoderBytes(body, new(codec.CborHandle))
+	if dec.Decode(&m) == nil {
+		e.Fields = m
+	}
+
+	return e
+}
+
+func (e *RspDecodeError) Error() string {
+	return fmt.Sprintf("Invalid response: op=%d group=%d id=%d seq=%d: %s; "+
+		"fields=%v", e.Hdr.Op, e.Hdr.Group, e.Hdr.Id, e.Hdr.Seq,
+		e.Err.Error(), e.Fields)
+}
+
+func (e *RspDecodeError) Unwrap() error {
+	return e.Err
+}
+
+// RcNotSupported indicates whether a device status code means that the
+// device lacks the requested operation.
+func RcNotSupported(rc int) bool {
+	switch rc {
+	case NMP_ERR_ENOTSUP:
+		return true
+	default:
+		return false
+	}
+}
+
+// RcErr converts a device status code to an error; nil for NMP_ERR_OK.
+func RcErr(rc int) error {
+	if rc == NMP_ERR_OK {
+		return nil
+	}
+	return NewNmpRcError(rc)
+}
+
+// RcString returns the name of a device status code.
+func RcString(rc int) string {
+	switch rc {
+	case NMP_ERR_OK:
+		return "ok"
+	case NMP_ERR_EUNKNOWN:
+		return "eunknown"
+	case NMP_ERR_ENOMEM:
+		return "enomem"
+	case NMP_ERR_EINVAL:
+		return "einval"
+	case NMP_ERR_ETIMEOUT:
+		return "etimeout"
+	case NMP_ERR_ENOENT:
+		return "enoent"
+	case NMP_ERR_EBADSTATE:
+		return "ebadstate"
+	case NMP_ERR_EMSGSIZE:
+		return "emsgsize"
+	case NMP_ERR_ENOTSUP:
+		return "enotsup"
+	default:
+		return "unknown"
+	}
+}
+
+// rspErrSetter is implemented by every response that embeds NmpBase.
+type rspErrSetter interface {
+	setErr(err error)
+}
+
+// rspChecker is implemented by responses that need more validation than
+// decoding provides, such as requiring a field that has a usable zero value.
+type rspChecker interface {
+	checkFields(fields map[string]interface{}) error
+}
+
+// rspStatus extracts the generic status field that all NMP responses carry.
+type rspStatus struct {
+	Rc int `codec:"rc"`
+}
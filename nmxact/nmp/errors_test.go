@@ -0,0 +1,148 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package nmp
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestRcErrNotSupported(t *testing.T) {
+	tests := []struct {
+		rc     int
+		notSup bool
+	}{
+		{NMP_ERR_EUNKNOWN, false},
+		{NMP_ERR_ENOMEM, false},
+		{NMP_ERR_EINVAL, false},
+		{NMP_ERR_ETIMEOUT, false},
+		{NMP_ERR_ENOENT, false},
+		{NMP_ERR_ENOTSUP, true},
+	}
+
+	for _, test := range tests {
+		err := RcErr(test.rc)
+		if err == nil {
+			t.Fatalf("rc=%d: no error", test.rc)
+		}
+
+		if errors.Is(err, ErrNmpNotSupported) != test.notSup {
+			t.Errorf("rc=%d: errors.Is(ErrNmpNotSupported)=%v, want %v",
+				test.rc, !test.notSup, test.notSup)
+		}
+
+		// The match must survive wrapping.
+		werr := fmt.Errorf("command failed: %w", err)
+		if errors.Is(werr, ErrNmpNotSupported) != test.notSup {
+			t.Errorf("rc=%d: wrapped errors.Is(ErrNmpNotSupported)=%v, "+
+				"want %v", test.rc, !test.notSup, test.notSup)
+		}
+
+		var rcErr *NmpRcError
+		if !errors.As(werr, &rcErr) || rcErr.Rc != test.rc {
+			t.Errorf("rc=%d: errors.As did not find the status code",
+				test.rc)
+		}
+	}
+
+	if err := RcErr(NMP_ERR_OK); err != nil {
+		t.Errorf("rc=0: unexpected error: %s", err.Error())
+	}
+}
+
+func TestDecodeRspNotSupported(t *testing.T) {
+	hdr := &NmpHdr{
+		Op:    NMP_OP_WRITE_RSP,
+		Group: NMP_GROUP_DEFAULT,
+		Id:    NMP_ID_DEF_ECHO,
+	}
+
+	// {"rc": 8}
+	r, err := DecodeRspBody(hdr, []byte{0xa1, 0x62, 'r', 'c', 0x08})
+	if err != nil {
+		t.Fatalf("decode failed: %s", err.Error())
+	}
+
+	rerr := r.(*EchoRsp).Err()
+	if !errors.Is(rerr, ErrNmpNotSupported) {
+		t.Fatalf("response error does not match ErrNmpNotSupported: %v",
+			rerr)
+	}
+
+	var rcErr *NmpRcError
+	if !errors.As(rerr, &rcErr) {
+		t.Fatalf("response error is not an NmpRcError: %v", rerr)
+	}
+	if rcErr.Op != hdr.Op || rcErr.Group != hdr.Group || rcErr.Id != hdr.Id {
+		t.Errorf("wrong op/group/id in error: %+v", rcErr)
+	}
+}
+
+func TestDecodeImageUploadRsp(t *testing.T) {
+	hdr := &NmpHdr{
+		Op:    NMP_OP_WRITE_RSP,
+		Group: NMP_GROUP_IMAGE,
+		Id:    NMP_ID_IMAGE_UPLOAD,
+		Seq:   42,
+	}
+
+	tests := []struct {
+		name    string
+		body    []byte
+		wantErr bool
+		wantOff uint32
+	}{
+		// {"rc": 0, "off": 100}
+		{"ok", []byte{0xa2, 0x62, 'r', 'c', 0x00,
+			0x63, 'o', 'f', 'f', 0x18, 0x64}, false, 100},
+		// {"rc": 3}
+		{"device error", []byte{0xa1, 0x62, 'r', 'c', 0x03}, false, 0},
+		// {"rc": 0}
+		{"missing off", []byte{0xa1, 0x62, 'r', 'c', 0x00}, true, 0},
+		// {}
+		{"empty", []byte{0xa0}, true, 0},
+		// {"off": "x"}
+		{"wrong type", []byte{0xa1, 0x63, 'o', 'f', 'f', 0x61, 'x'}, true, 0},
+	}
+
+	for _, tt := range tests {
+		r, err := DecodeRspBody(hdr, tt.body)
+		if !tt.wantErr {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", tt.name, err)
+				continue
+			}
+			if off := r.(*ImageUploadRsp).Off; off != tt.wantOff {
+				t.Errorf("%s: off = %d, want %d", tt.name, off, tt.wantOff)
+			}
+			continue
+		}
+
+		var derr *RspDecodeError
+		if !errors.As(err, &derr) {
+			t.Errorf("%s: err = %v, want RspDecodeError", tt.name, err)
+			continue
+		}
+		if derr.Hdr != *hdr || derr.Fields == nil || derr.Err == nil {
+			t.Errorf("%s: incomplete decode error: %+v", tt.name, derr)
+		}
+	}
+}
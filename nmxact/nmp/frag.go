@@ -20,24 +20,99 @@
 package nmp
 
 import (
+	"sync"
+	"time"
+
 	log "github.com/sirupsen/logrus"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmxutil"
 )
 
+// Called when a partial packet is abandoned due to the reassembly timeout.
+// hdr is nil if not enough of the packet arrived to decode its header.
+type ReassemblyTimeoutFn func(hdr *NmpHdr)
+
 type Reassembler struct {
 	cur []byte
+
+	timeout   time.Duration
+	timeoutCb ReassemblyTimeoutFn
+	timer     *time.Timer
+	mtx       sync.Mutex
 }
 
 func NewReassembler() *Reassembler {
 	return &Reassembler{}
 }
 
-func (r *Reassembler) RxFrag(frag []byte) []byte {
+// SetTimeout configures how long a partially received packet is retained
+// before it is discarded.  A timeout of 0 disables the limit.
+func (r *Reassembler) SetTimeout(tmo time.Duration, cb ReassemblyTimeoutFn) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.timeout = tmo
+	r.timeoutCb = cb
+}
+
+func (r *Reassembler) stopTimer() {
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+}
+
+func (r *Reassembler) startTimer() {
+	var t *time.Timer
+	t = time.AfterFunc(r.timeout, func() {
+		r.mtx.Lock()
+		if r.timer != t {
+			// Packet completed or was discarded before the timer fired.
+			r.mtx.Unlock()
+			return
+		}
+
+		hdr, _ := DecodeNmpHdr(r.cur)
+		log.Debugf("nmp reassembly timeout; discarding %d bytes", len(r.cur))
+		r.cur = nil
+		r.timer = nil
+		cb := r.timeoutCb
+		r.mtx.Unlock()
+
+		if cb != nil {
+			cb(hdr)
+		}
+	})
+	r.timer = t
+}
+
+// RxFrag adds a fragment to the packet being reassembled.  It returns the
+// packet once it is complete, or an RspSizeError if the fragments exceed the
+// length declared in the packet's header; the packet is discarded in that
+// case.
+func (r *Reassembler) RxFrag(frag []byte) ([]byte, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if len(r.cur) == 0 && r.timeout > 0 {
+		r.startTimer()
+	}
+
+	first := len(r.cur) < NMP_HDR_SIZE
 	r.cur = append(r.cur, frag...)
 
 	hdr, err := DecodeNmpHdr(r.cur)
 	if err != nil {
 		// Incomplete header.
-		return nil
+		return nil, nil
+	}
+
+	// Once the header is available, size the buffer for the full packet so
+	// that subsequent fragments don't cause it to be reallocated.
+	if first && NMP_HDR_SIZE+int(hdr.Len) > len(r.cur) {
+		buf := make([]byte, len(r.cur), NMP_HDR_SIZE+int(hdr.Len))
+		copy(buf, r.cur)
+		r.cur = buf
 	}
 
 	actualLen := len(r.cur) - NMP_HDR_SIZE
@@ -46,16 +121,20 @@ func (r *Reassembler) RxFrag(frag []byte) []byte {
 		log.Debugf("received invalid nmp packet; hdr.len=%d actualLen=%d",
 			hdr.Len, actualLen)
 		r.cur = nil
-		return nil
+		r.stopTimer()
+		return nil, nmxutil.FmtRspSizeError(NMP_HDR_SIZE+int(hdr.Len),
+			"NMP response exceeds its declared length; seq=%d hdr.len=%d "+
+				"actualLen=%d", hdr.Seq, hdr.Len, actualLen)
 	}
 
 	if actualLen < int(hdr.Len) {
 		// More fragments to come.
-		return nil
+		return nil, nil
 	}
 
 	// Packet complete
 	pkt := r.cur
 	r.cur = nil
-	return pkt
+	r.stopTimer()
+	return pkt, nil
 }
@@ -0,0 +1,167 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package nmp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmxutil"
+)
+
+// {"rc": 0}
+var testEchoRspBody = []byte{0xa1, 0x62, 'r', 'c', 0x00}
+
+func testRspPkt(seq uint8, declLen int, body []byte) []byte {
+	hdr := NmpHdr{
+		Op:    NMP_OP_WRITE_RSP,
+		Len:   uint16(declLen),
+		Group: NMP_GROUP_DEFAULT,
+		Seq:   seq,
+		Id:    NMP_ID_DEF_ECHO,
+	}
+	return append(hdr.Bytes(), body...)
+}
+
+func TestReassemblerRxFrag(t *testing.T) {
+	full := testRspPkt(1, len(testEchoRspBody), testEchoRspBody)
+	long := testRspPkt(1, len(testEchoRspBody)-2, testEchoRspBody)
+
+	tests := []struct {
+		name     string
+		frags    [][]byte
+		complete bool
+		sizeErr  bool
+	}{
+		{"single", [][]byte{full}, true, false},
+		{"split header", [][]byte{full[:3], full[3:]}, true, false},
+		{"split body", [][]byte{full[:10], full[10:]}, true, false},
+		{"incomplete", [][]byte{full[:10]}, false, false},
+		{"overflow", [][]byte{long}, false, true},
+		{"overflow split", [][]byte{long[:9], long[9:]}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewReassembler()
+
+			var pkt []byte
+			var err error
+			for i, frag := range tt.frags {
+				pkt, err = r.RxFrag(frag)
+				if i < len(tt.frags)-1 && (pkt != nil || err != nil) {
+					t.Fatalf("frag %d: early result pkt=%x err=%v",
+						i, pkt, err)
+				}
+			}
+
+			if tt.sizeErr {
+				if !nmxutil.IsRspSize(err) {
+					t.Fatalf("err = %v, want RspSizeError", err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if (pkt != nil) != tt.complete {
+				t.Fatalf("pkt = %x, complete want %v", pkt, tt.complete)
+			}
+			if tt.complete && string(pkt) != string(full) {
+				t.Errorf("pkt = %x, want %x", pkt, full)
+			}
+
+			// A discarded packet must not affect the next one.
+			if tt.sizeErr {
+				pkt, err = r.RxFrag(full)
+				if err != nil || string(pkt) != string(full) {
+					t.Errorf("next packet: pkt=%x err=%v", pkt, err)
+				}
+			}
+		})
+	}
+}
+
+func TestDispatchOversized(t *testing.T) {
+	d := NewDispatcher(0)
+	nl, err := d.AddListener(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.RemoveListener(7)
+
+	pkt := testRspPkt(7, 1, testEchoRspBody)
+	if d.Dispatch(pkt) {
+		t.Fatalf("oversized packet was dispatched")
+	}
+
+	select {
+	case err := <-nl.ErrChan:
+		if !nmxutil.IsRspSize(err) {
+			t.Fatalf("err = %v, want RspSizeError", err)
+		}
+	case rsp := <-nl.RspChan:
+		t.Fatalf("unexpected response: %+v", rsp)
+	case <-time.After(time.Second):
+		t.Fatalf("listener was not notified")
+	}
+}
+
+func TestDispatchReassemblyTimeout(t *testing.T) {
+	d := NewDispatcher(0)
+	d.SetReassemblyTimeout(50 * time.Millisecond)
+
+	nl, err := d.AddListener(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.RemoveListener(3)
+
+	// Deliver the first and last fragments but drop the middle one.
+	pkt := testRspPkt(3, len(testEchoRspBody), testEchoRspBody)
+	d.Dispatch(pkt[:9])
+	d.Dispatch(pkt[11:])
+
+	select {
+	case err := <-nl.ErrChan:
+		if !nmxutil.IsReassemblyTimeout(err) {
+			t.Fatalf("err = %v, want ReassemblyTimeoutError", err)
+		}
+	case rsp := <-nl.RspChan:
+		t.Fatalf("unexpected response: %+v", rsp)
+	case <-time.After(time.Second):
+		t.Fatalf("reassembly did not time out")
+	}
+
+	// The partial packet must be gone; a complete retransmission decodes
+	// on its own.
+	if !d.Dispatch(pkt) {
+		t.Fatalf("retransmitted packet was not dispatched")
+	}
+	select {
+	case rsp := <-nl.RspChan:
+		if rsp.Hdr().Seq != 3 {
+			t.Errorf("response seq = %d, want 3", rsp.Hdr().Seq)
+		}
+	case err := <-nl.ErrChan:
+		t.Fatalf("unexpected error: %v", err)
+	case <-time.After(time.Second):
+		t.Fatalf("no response")
+	}
+}
@@ -84,3 +84,111 @@ func NewFsUploadRsp() *FsUploadRsp {
 }
 
 func (r *FsUploadRsp) Msg() *NmpMsg { return MsgFromReq(r) }
+
+//////////////////////////////////////////////////////////////////////////////
+// $stat                                                                    //
+//////////////////////////////////////////////////////////////////////////////
+
+type FsStatReq struct {
+	NmpBase     `codec:"-"`
+	Name string `codec:"name"`
+}
+
+// FsStatRsp reports the length of a file.  Mtime is only present if the
+// device's file system records modification times.
+type FsStatRsp struct {
+	NmpBase
+	Rc    int    `codec:"rc"`
+	Len   uint32 `codec:"len"`
+	Mtime *int64 `codec:"mtime,omitempty"`
+}
+
+func NewFsStatReq() *FsStatReq {
+	r := &FsStatReq{}
+	fillNmpReq(r, NMP_OP_READ, NMP_GROUP_FS, NMP_ID_FS_STAT)
+	return r
+}
+
+func (r *FsStatReq) Msg() *NmpMsg { return MsgFromReq(r) }
+
+func NewFsStatRsp() *FsStatRsp {
+	return &FsStatRsp{}
+}
+
+func (r *FsStatRsp) Msg() *NmpMsg { return MsgFromReq(r) }
+
+//////////////////////////////////////////////////////////////////////////////
+// $hash                                                                    //
+//////////////////////////////////////////////////////////////////////////////
+
+const FS_HASH_SHA256 = "sha256"
+
+// FsHashReq requests a digest of Len bytes of a file starting at Off.  A Len
+// of zero covers the remainder of the file.
+type FsHashReq struct {
+	NmpBase     `codec:"-"`
+	Name string `codec:"name"`
+	Type string `codec:"type,omitempty"`
+	Off  uint32 `codec:"off"`
+	Len  uint32 `codec:"len,omitempty"`
+}
+
+type FsHashRsp struct {
+	NmpBase
+	Rc     int    `codec:"rc"`
+	Type   string `codec:"type"`
+	Off    uint32 `codec:"off"`
+	Len    uint32 `codec:"len"`
+	Output []byte `codec:"output"`
+}
+
+func NewFsHashReq() *FsHashReq {
+	r := &FsHashReq{}
+	fillNmpReq(r, NMP_OP_READ, NMP_GROUP_FS, NMP_ID_FS_HASH)
+	return r
+}
+
+func (r *FsHashReq) Msg() *NmpMsg { return MsgFromReq(r) }
+
+func NewFsHashRsp() *FsHashRsp {
+	return &FsHashRsp{}
+}
+
+func (r *FsHashRsp) Msg() *NmpMsg { return MsgFromReq(r) }
+
+//////////////////////////////////////////////////////////////////////////////
+// $mounts                                                                  //
+//////////////////////////////////////////////////////////////////////////////
+
+// FsMountEntry describes a mounted file system.  Size and Free are in bytes
+// and are zero if the file system does not report them.
+type FsMountEntry struct {
+	Path string `codec:"path"`
+	Type string `codec:"type"`
+	Size uint64 `codec:"size,omitempty"`
+	Free uint64 `codec:"free,omitempty"`
+}
+
+type FsMountsReq struct {
+	NmpBase `codec:"-"`
+}
+
+type FsMountsRsp struct {
+	NmpBase
+	Rc     int            `codec:"rc"`
+	Mounts []FsMountEntry `codec:"mounts"`
+}
+
+func NewFsMountsReq() *FsMountsReq {
+	r := &FsMountsReq{}
+	fillNmpReq(r, NMP_OP_READ, NMP_GROUP_FS, NMP_ID_FS_MOUNTS)
+	return r
+}
+
+func (r *FsMountsReq) Msg() *NmpMsg { return MsgFromReq(r) }
+
+func NewFsMountsRsp() *FsMountsRsp {
+	return &FsMountsRsp{}
+}
+
+func (r *FsMountsRsp) Msg() *NmpMsg { return MsgFromReq(r) }
@@ -0,0 +1,95 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package nmp
+
+import (
+	"github.com/ugorji/go/codec"
+)
+
+// GenericReq is a request whose body is assembled at run time.  It is used
+// for commands without a dedicated request type, such as those in
+// user-defined groups.
+type GenericReq struct {
+	NmpBase
+	Fields map[string]interface{}
+}
+
+// GenericRsp holds a decoded response body as a generic map.
+type GenericRsp struct {
+	NmpBase
+	Fields map[string]interface{}
+}
+
+func NewGenericReq(op uint8, group uint16, id uint8) *GenericReq {
+	r := &GenericReq{
+		Fields: map[string]interface{}{},
+	}
+	fillNmpReq(r, op, group, id)
+	return r
+}
+
+func (r *GenericReq) Msg() *NmpMsg {
+	return &NmpMsg{
+		*r.Hdr(),
+		r.Fields,
+	}
+}
+
+func NewGenericRsp() *GenericRsp {
+	return &GenericRsp{}
+}
+
+func (r *GenericRsp) Msg() *NmpMsg {
+	return &NmpMsg{
+		*r.Hdr(),
+		r.Fields,
+	}
+}
+
+func (r *GenericRsp) CodecEncodeSelf(e *codec.Encoder) {
+	e.MustEncode(r.Fields)
+}
+
+func (r *GenericRsp) CodecDecodeSelf(d *codec.Decoder) {
+	d.MustDecode(&r.Fields)
+
+	// OMP responses carry the NMP header inline.
+	delete(r.Fields, "_h")
+}
+
+// Rc returns the response's "rc" field, or 0 if it is absent.
+func (r *GenericRsp) Rc() int {
+	switch v := r.Fields["rc"].(type) {
+	case int64:
+		return int(v)
+	case uint64:
+		return int(v)
+	default:
+		return 0
+	}
+}
+
+// RegisterGenericRsp arranges for responses to the given request to be
+// decoded as GenericRsp.  op is the request opcode (read or write).  It fails
+// if the request already has a response handler.
+func RegisterGenericRsp(op uint8, group uint16, id uint8) error {
+	return RegisterResponseHandler(Ogi{op + 1, group, id},
+		func() NmpRsp { return NewGenericRsp() })
+}
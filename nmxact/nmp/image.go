@@ -19,6 +19,10 @@
 
 package nmp
 
+import (
+	"fmt"
+)
+
 //////////////////////////////////////////////////////////////////////////////
 // $upload                                                                  //
 //////////////////////////////////////////////////////////////////////////////
@@ -30,6 +34,7 @@ type ImageUploadReq struct {
 	Len      uint32 `codec:"len,omitempty"`
 	DataSha  []byte `codec:"sha,omitempty"`
 	Upgrade  bool   `codec:"upgrade,omitempty"`
+	Slot     *uint8 `codec:"slot,omitempty"`
 	Data     []byte `codec:"data"`
 }
 
@@ -59,6 +64,19 @@ func NewImageUploadRsp() *ImageUploadRsp {
 
 func (r *ImageUploadRsp) Msg() *NmpMsg { return MsgFromReq(r) }
 
+// A successful upload response must say which offset the device expects
+// next; a missing offset would otherwise decode as zero and restart the
+// upload.
+func (r *ImageUploadRsp) checkFields(fields map[string]interface{}) error {
+	if rc, ok := fields["rc"]; ok && fmt.Sprintf("%v", rc) != "0" {
+		return nil
+	}
+	if _, ok := fields["off"]; !ok {
+		return fmt.Errorf("missing \"off\" field")
+	}
+	return nil
+}
+
 //////////////////////////////////////////////////////////////////////////////
 // $state                                                                   //
 //////////////////////////////////////////////////////////////////////////////
@@ -99,6 +117,57 @@ type ImageStateEntry struct {
 	Permanent bool   `codec:"permanent"`
 }
 
+// SwapType is the MCUboot swap that will take place on the next reset, as
+// far as it concerns a particular slot.
+type SwapType int
+
+const (
+	SWAP_TYPE_NONE SwapType = iota
+	SWAP_TYPE_TEST
+	SWAP_TYPE_PERM
+	SWAP_TYPE_REVERT
+)
+
+var swapTypeNameMap = map[SwapType]string{
+	SWAP_TYPE_NONE:   "none",
+	SWAP_TYPE_TEST:   "test",
+	SWAP_TYPE_PERM:   "perm",
+	SWAP_TYPE_REVERT: "revert",
+}
+
+func (st SwapType) String() string {
+	str := swapTypeNameMap[st]
+	if str == "" {
+		return "Unknown!"
+	}
+	return str
+}
+
+func SwapTypeFromString(s string) (SwapType, error) {
+	for k, v := range swapTypeNameMap {
+		if s == v {
+			return k, nil
+		}
+	}
+	return SWAP_TYPE_NONE, fmt.Errorf("Invalid swap type: %s", s)
+}
+
+// SwapType derives the pending swap type from the entry's flags.  A pending
+// image is swapped in on the next reset, either for test or permanently.  A
+// running image that has not been confirmed is reverted on the next reset.
+func (e *ImageStateEntry) SwapType() SwapType {
+	switch {
+	case e.Pending && e.Permanent:
+		return SWAP_TYPE_PERM
+	case e.Pending:
+		return SWAP_TYPE_TEST
+	case e.Active && !e.Confirmed:
+		return SWAP_TYPE_REVERT
+	default:
+		return SWAP_TYPE_NONE
+	}
+}
+
 type ImageStateReadReq struct {
 	NmpBase `codec:"-"`
 }
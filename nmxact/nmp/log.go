@@ -261,6 +261,9 @@ type LogClearReq struct {
 type LogClearRsp struct {
 	NmpBase
 	Rc int `codec:"rc"`
+
+	// Number of entries removed; nil if the device doesn't report it.
+	Cleared *int `codec:"cleared,omitempty"`
 }
 
 func NewLogClearReq() *LogClearReq {
@@ -0,0 +1,115 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package nmp
+
+import (
+	"reflect"
+)
+
+// The memory group gives raw access to device memory and peripheral
+// registers.  It is only present in firmware built with debug support, and
+// it has no fixed group number: each product picks one of the per-user
+// groups.  Width is the access size in bytes (1, 2, 4, or 8); the address
+// and length must be multiples of it.
+
+// registerMemRsp registers f for ogi unless ogi is already decoded as the
+// same response type.
+func registerMemRsp(ogi Ogi, f rspCtor) error {
+	cb := rspCtorMap[ogi]
+	if cb != nil && reflect.TypeOf(cb()) == reflect.TypeOf(f()) {
+		return nil
+	}
+
+	return RegisterResponseHandler(ogi, f)
+}
+
+// RegisterMemRsps arranges for memory group responses to be decoded when the
+// group has the given number.  It fails if another command already uses the
+// group's ids.
+func RegisterMemRsps(group uint16) error {
+	rd := Ogi{NMP_OP_READ_RSP, group, NMP_ID_MEMORY_ACCESS}
+	wr := Ogi{NMP_OP_WRITE_RSP, group, NMP_ID_MEMORY_ACCESS}
+
+	if err := registerMemRsp(rd, memReadRspCtor); err != nil {
+		return err
+	}
+	return registerMemRsp(wr, memWriteRspCtor)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// $read                                                                     //
+///////////////////////////////////////////////////////////////////////////////
+
+type MemReadReq struct {
+	NmpBase `codec:"-"`
+	Addr    uint64 `codec:"addr"`
+	Len     int    `codec:"len"`
+	Width   int    `codec:"width"`
+}
+
+type MemReadRsp struct {
+	NmpBase
+	Rc   int    `codec:"rc"`
+	Data []byte `codec:"data"`
+}
+
+func NewMemReadReq(group uint16) *MemReadReq {
+	r := &MemReadReq{}
+	fillNmpReq(r, NMP_OP_READ, group, NMP_ID_MEMORY_ACCESS)
+	return r
+}
+
+func (r *MemReadReq) Msg() *NmpMsg { return MsgFromReq(r) }
+
+func NewMemReadRsp() *MemReadRsp {
+	return &MemReadRsp{}
+}
+
+func (r *MemReadRsp) Msg() *NmpMsg { return MsgFromReq(r) }
+
+///////////////////////////////////////////////////////////////////////////////
+// $write                                                                    //
+///////////////////////////////////////////////////////////////////////////////
+
+type MemWriteReq struct {
+	NmpBase `codec:"-"`
+	Addr    uint64 `codec:"addr"`
+	Width   int    `codec:"width"`
+	Data    []byte `codec:"data"`
+}
+
+type MemWriteRsp struct {
+	NmpBase
+	Rc int `codec:"rc"`
+}
+
+func NewMemWriteReq(group uint16) *MemWriteReq {
+	r := &MemWriteReq{}
+	fillNmpReq(r, NMP_OP_WRITE, group, NMP_ID_MEMORY_ACCESS)
+	return r
+}
+
+func (r *MemWriteReq) Msg() *NmpMsg { return MsgFromReq(r) }
+
+func NewMemWriteRsp() *MemWriteRsp {
+	return &MemWriteRsp{}
+}
+
+func (r *MemWriteRsp) Msg() *NmpMsg { return MsgFromReq(r) }
@@ -32,6 +32,9 @@ import (
 
 const NMP_HDR_SIZE = 8
 
+// Largest possible NMP message; the header's length field is 16 bits.
+const NMP_MAX_MSG_SIZE = NMP_HDR_SIZE + 0xffff
+
 type NmpHdr struct {
 	Op    uint8 /* 3 bits of opcode */
 	Flags uint8
@@ -63,6 +66,7 @@ type NmpRsp interface {
 
 type NmpBase struct {
 	hdr NmpHdr `codec:"-"`
+	err error  `codec:"-"`
 }
 
 func (b *NmpBase) Hdr() *NmpHdr {
@@ -73,6 +77,17 @@ func (b *NmpBase) SetHdr(h *NmpHdr) {
 	b.hdr = *h
 }
 
+// Err returns an *NmpRcError if the device reported a non-zero status in
+// this response, or nil otherwise.  Use errors.Is(err, ErrNmpNotSupported) to
+// detect an unsupported operation.
+func (b *NmpBase) Err() error {
+	return b.err
+}
+
+func (b *NmpBase) setErr(err error) {
+	b.err = err
+}
+
 func MsgFromReq(r NmpReq) *NmpMsg {
 	return &NmpMsg{
 		*r.Hdr(),
@@ -0,0 +1,49 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package nmp
+
+import ()
+
+type McumgrParamsReq struct {
+	NmpBase `codec:"-"`
+}
+
+// BufSize is the largest NMP message, header included, that the device can
+// receive.  0 means the device doesn't advertise a preference.
+type McumgrParamsRsp struct {
+	NmpBase
+	Rc       int `codec:"rc"`
+	BufSize  int `codec:"buf_size"`
+	BufCount int `codec:"buf_count"`
+}
+
+func NewMcumgrParamsReq() *McumgrParamsReq {
+	r := &McumgrParamsReq{}
+	fillNmpReq(r, NMP_OP_READ, NMP_GROUP_DEFAULT, NMP_ID_DEF_MCUMGR_PARAMS)
+	return r
+}
+
+func (r *McumgrParamsReq) Msg() *NmpMsg { return MsgFromReq(r) }
+
+func NewMcumgrParamsRsp() *McumgrParamsRsp {
+	return &McumgrParamsRsp{}
+}
+
+func (r *McumgrParamsRsp) Msg() *NmpMsg { return MsgFromReq(r) }
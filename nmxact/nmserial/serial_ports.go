@@ -0,0 +1,94 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package nmserial
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+type PortInfo struct {
+	DevPath string
+	// USB vendor and product IDs; empty if unknown or not a USB device.
+	Vid string
+	Pid string
+}
+
+var portGlobs = map[string][]string{
+	"linux":  {"/dev/ttyUSB*", "/dev/ttyACM*", "/dev/ttyAMA*"},
+	"darwin": {"/dev/cu.usbserial*", "/dev/cu.usbmodem*", "/dev/cu.SLAB*"},
+}
+
+// Reads a device's USB IDs from sysfs.  The tty's device link points at the
+// USB interface; the IDs are attributes of its parent.
+func linuxUsbIds(devPath string) (string, string) {
+	name := filepath.Base(devPath)
+	dir, err := filepath.EvalSymlinks("/sys/class/tty/" + name + "/device")
+	if err != nil {
+		return "", ""
+	}
+
+	for i := 0; i < 3 && dir != "/"; i++ {
+		vid, err1 := ioutil.ReadFile(dir + "/idVendor")
+		pid, err2 := ioutil.ReadFile(dir + "/idProduct")
+		if err1 == nil && err2 == nil {
+			return strings.TrimSpace(string(vid)),
+				strings.TrimSpace(string(pid))
+		}
+		dir = filepath.Dir(dir)
+	}
+
+	return "", ""
+}
+
+// ListPorts returns the serial ports that could plausibly be attached to a
+// Mynewt device.  Ports are not opened.
+func ListPorts() ([]PortInfo, error) {
+	var paths []string
+
+	if runtime.GOOS == "windows" {
+		for i := 1; i <= 32; i++ {
+			paths = append(paths, fmt.Sprintf("COM%d", i))
+		}
+	} else {
+		for _, g := range portGlobs[runtime.GOOS] {
+			matches, err := filepath.Glob(g)
+			if err != nil {
+				return nil, err
+			}
+			paths = append(paths, matches...)
+		}
+		sort.Strings(paths)
+	}
+
+	infos := make([]PortInfo, len(paths))
+	for i, p := range paths {
+		infos[i].DevPath = p
+		if runtime.GOOS == "linux" {
+			infos[i].Vid, infos[i].Pid = linuxUsbIds(p)
+		}
+	}
+
+	return infos, nil
+}
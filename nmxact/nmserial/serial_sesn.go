@@ -49,6 +49,8 @@ type SerialSesn struct {
 	msgChan  chan []byte
 	connChan chan *SerialSesn
 	stopChan chan struct{}
+
+	timer sesn.ConnTimer
 }
 
 func NewSerialSesn(sx *SerialXport, cfg sesn.SesnCfg) (*SerialSesn, error) {
@@ -62,6 +64,9 @@ func NewSerialSesn(sx *SerialXport, cfg sesn.SesnCfg) (*SerialSesn, error) {
 	if err != nil {
 		return nil, err
 	}
+	txvr.SetOmpRes(cfg.OmpRes)
+	txvr.SetNmpAuth(cfg.NmpAuth)
+	txvr.SetMaxOutstanding(cfg.MaxOutstanding)
 	s.txvr = txvr
 
 	return s, nil
@@ -76,12 +81,18 @@ func (s *SerialSesn) Open() error {
 			"Attempt to open an already-open serial session")
 	}
 
+	s.timer.Reset()
+	start := time.Now()
+
 	txvr, err := mgmt.NewTransceiver(s.cfg.TxFilter, s.cfg.RxFilter, false,
 		s.cfg.MgmtProto, 3)
 	if err != nil {
 		s.m.Unlock()
 		return err
 	}
+	txvr.SetOmpRes(s.cfg.OmpRes)
+	txvr.SetNmpAuth(s.cfg.NmpAuth)
+	txvr.SetMaxOutstanding(s.cfg.MaxOutstanding)
 	s.txvr = txvr
 	s.errChan = make(chan error)
 	s.msgChan = make(chan []byte, 16)
@@ -90,6 +101,8 @@ func (s *SerialSesn) Open() error {
 
 	s.isOpen = true
 	s.m.Unlock()
+	s.timer.Add(sesn.CONN_PHASE_CONNECT, start)
+	s.sx.sesnList.Add(s, s.sx.cfg.DevPath)
 	if s.cfg.MgmtProto == sesn.MGMT_PROTO_COAP_SERVER {
 		return nil
 	}
@@ -115,6 +128,10 @@ func (s *SerialSesn) Open() error {
 			}
 		}
 	}()
+
+	if s.cfg.MtuQueryTimeout != 0 {
+		s.txvr.QueryMtuPref(s.TxRxMgmt, s.cfg.MtuQueryTimeout)
+	}
 	return nil
 }
 
@@ -184,7 +201,7 @@ func (s *SerialSesn) MtuIn() int {
 func (s *SerialSesn) MtuOut() int {
 	// Mynewt commands have a default chunk buffer size of 512.  Account for
 	// base64 encoding.
-	return s.sx.cfg.Mtu*3/4 - omp.OMP_MSG_OVERHEAD
+	return s.txvr.LimitMtu(s.sx.cfg.Mtu*3/4 - omp.OMP_MSG_OVERHEAD)
 }
 
 func (s *SerialSesn) AbortRx(seq uint8) error {
@@ -210,7 +227,12 @@ func (s *SerialSesn) TxRxMgmt(m *nmp.NmpMsg,
 	}
 	defer s.sx.setRspSesn(nil)
 
-	return s.txvr.TxRxMgmt(txFn, m, s.MtuOut(), timeout)
+	start := time.Now()
+	rsp, err := s.txvr.TxRxMgmt(txFn, m, s.MtuOut(), timeout)
+	if err == nil {
+		s.timer.RspRcvd(start)
+	}
+	return rsp, err
 }
 
 func (s *SerialSesn) TxRxMgmtAsync(m *nmp.NmpMsg,
@@ -253,6 +275,22 @@ func (s *SerialSesn) MgmtProto() sesn.MgmtProto {
 	return s.cfg.MgmtProto
 }
 
+func (s *SerialSesn) OmpRes() string {
+	return s.cfg.OmpRes
+}
+
+func (s *SerialSesn) Tag() string {
+	return s.cfg.Tag
+}
+
+func (s *SerialSesn) ConnectTimings() sesn.ConnTimings {
+	return s.timer.Timings()
+}
+
+func (s *SerialSesn) NmpAuth() *nmp.NmpAuth {
+	return s.cfg.NmpAuth
+}
+
 func (s *SerialSesn) CoapIsTcp() bool {
 	return false
 }
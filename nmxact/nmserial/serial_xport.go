@@ -26,6 +26,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"sync"
 	"time"
 
@@ -34,7 +35,9 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/tarm/serial"
 
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmxutil"
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/xport"
 	"mynewt.apache.org/newt/util"
 )
 
@@ -44,15 +47,28 @@ type XportCfg struct {
 	Mtu         int
 	ReadTimeout time.Duration
 	WriteDelay  time.Duration
+
+	// Maximum time between chunks of a partially received frame.  Once it
+	// elapses, the partial frame is discarded with a ReassemblyTimeoutError
+	// and the receiver waits for the start of the next frame.  Expiry is
+	// noticed when the next chunk arrives or a read times out, so a hang is
+	// detected within FrameTimeout + ReadTimeout.  0 waits indefinitely.
+	FrameTimeout time.Duration
+
+	// If non-nil, receives every chunk of bytes read from the port before
+	// any framing or decoding, including data the decoder discards.  Called
+	// from the receive goroutine; it must not block.
+	RxTap func(data []byte)
 }
 
 var errTimeout error = errors.New("Timeout reading from serial connection")
 
 func NewXportCfg() *XportCfg {
 	return &XportCfg{
-		ReadTimeout: 10 * time.Second,
-		WriteDelay:  20000 * time.Microsecond,
-		Mtu:         128,
+		ReadTimeout:  10 * time.Second,
+		WriteDelay:   20000 * time.Microsecond,
+		FrameTimeout: 5 * time.Second,
+		Mtu:          128,
 	}
 }
 
@@ -65,11 +81,59 @@ type SerialXport struct {
 	sync.Mutex
 	closing bool
 
+	// Serializes Start and Stop.  This is separate from the embedded mutex,
+	// which the receive loop takes while Stop waits for it to exit.
+	startMtx sync.Mutex
+
 	reqSesn    *SerialSesn
 	acceptSesn *SerialSesn
 	rspSesn    *SerialSesn
+	sesnList   xport.SesnList
 
 	pkt *Packet
+
+	// When the last chunk of pkt arrived.
+	pktTime time.Time
+}
+
+// Passes a copy of everything read from r to tap.
+type tapReader struct {
+	r   io.Reader
+	tap func(data []byte)
+}
+
+func (t *tapReader) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	if n > 0 {
+		t.tap(append([]byte(nil), p[:n]...))
+	}
+	return n, err
+}
+
+// Indicates whether the partially received frame has gone too long without
+// a new chunk.
+func (sx *SerialXport) pktExpired() bool {
+	return sx.pkt != nil && sx.cfg.FrameTimeout > 0 &&
+		time.Since(sx.pktTime) > sx.cfg.FrameTimeout
+}
+
+// Discards the partially received frame.
+func (sx *SerialXport) dropPkt() error {
+	n := len(sx.pkt.GetBytes())
+	sx.pkt = nil
+
+	log.Debugf("serial frame timeout; discarding %d bytes", n)
+	return nmxutil.FmtReassemblyTimeoutError(
+		"Serial frame incomplete after %s; discarding %d bytes",
+		sx.cfg.FrameTimeout, n)
+}
+
+func (sx *SerialXport) newScanner() *bufio.Scanner {
+	var r io.Reader = sx.port
+	if sx.cfg.RxTap != nil {
+		r = &tapReader{r: r, tap: sx.cfg.RxTap}
+	}
+	return bufio.NewScanner(r)
 }
 
 func NewSerialXport(cfg *XportCfg) *SerialXport {
@@ -82,6 +146,12 @@ func (sx *SerialXport) BuildSesn(cfg sesn.SesnCfg) (sesn.Sesn, error) {
 	return NewSerialSesn(sx, cfg)
 }
 
+// Sessions describes each open session built by the transport, including
+// sessions accepted in server mode.
+func (sx *SerialXport) Sessions() []xport.SesnInfo {
+	return sx.sesnList.Infos("serial")
+}
+
 func (sx *SerialXport) acceptServerSesn(sl *SerialSesn) (*SerialSesn, error) {
 	sc := sesn.NewSesnCfg()
 	sc.MgmtProto = sesn.MGMT_PROTO_COAP_SERVER
@@ -103,9 +173,11 @@ func (sx *SerialXport) acceptServerSesn(sl *SerialSesn) (*SerialSesn, error) {
 }
 
 func (sx *SerialXport) Start() error {
+	sx.startMtx.Lock()
+	defer sx.startMtx.Unlock()
+
 	if sx.port != nil {
-		// Already started.
-		return nil
+		return nmxutil.NewXportError("Serial xport started twice")
 	}
 
 	c := &serial.Config{
@@ -114,16 +186,17 @@ func (sx *SerialXport) Start() error {
 		ReadTimeout: sx.cfg.ReadTimeout,
 	}
 
-	var err error
-	sx.port, err = serial.OpenPort(c)
+	port, err := serial.OpenPort(c)
 	if err != nil {
 		return err
 	}
 
-	err = sx.port.Flush()
+	err = port.Flush()
 	if err != nil {
+		port.Close()
 		return err
 	}
+	sx.port = port
 
 	sx.wg.Add(1)
 	go func() {
@@ -131,7 +204,7 @@ func (sx *SerialXport) Start() error {
 
 		// Most of the reading will be done line by line, use the
 		// bufio.Scanner to do this
-		sx.scanner = bufio.NewScanner(sx.port)
+		sx.scanner = sx.newScanner()
 
 		for {
 			msg, err := sx.Rx()
@@ -224,7 +297,16 @@ func (sx *SerialXport) setReqSesn(s *SerialSesn) error {
 }
 
 func (sx *SerialXport) Stop() error {
+	sx.startMtx.Lock()
+	defer sx.startMtx.Unlock()
+
+	if sx.port == nil {
+		return nmxutil.NewXportError("Serial xport stopped twice")
+	}
+
+	sx.Lock()
 	sx.closing = true
+	sx.Unlock()
 
 	err := sx.port.Close()
 	sx.wg.Wait()
@@ -233,7 +315,9 @@ func (sx *SerialXport) Stop() error {
 		sx.port = nil
 	}
 
+	sx.Lock()
 	sx.closing = false
+	sx.Unlock()
 
 	return err
 }
@@ -319,6 +403,12 @@ func (sx *SerialXport) Rx() ([]byte, error) {
 			continue
 		}
 
+		if line[0] == 4 && sx.pktExpired() {
+			// This continues a frame that is too old to be trusted.
+			// Drop both and resynchronize on the next frame start.
+			return nil, sx.dropPkt()
+		}
+
 		base64Data := string(line[2:])
 
 		data, err := base64.StdEncoding.DecodeString(base64Data)
@@ -345,6 +435,7 @@ func (sx *SerialXport) Rx() ([]byte, error) {
 			continue
 		}
 
+		sx.pktTime = time.Now()
 		full := sx.pkt.AddBytes(data)
 		if full {
 			if crc16.Crc16(sx.pkt.GetBytes()) != 0 {
@@ -368,7 +459,11 @@ func (sx *SerialXport) Rx() ([]byte, error) {
 		// Scanner hit EOF, so we'll need to create a new one.  This only
 		// happens on timeouts.
 		err = errTimeout
-		sx.scanner = bufio.NewScanner(sx.port)
+		sx.scanner = sx.newScanner()
+
+		if sx.pktExpired() {
+			err = sx.dropPkt()
+		}
 	}
 	return nil, err
 }
@@ -0,0 +1,129 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package nmserial
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmxutil"
+)
+
+// Opens a pseudo-terminal and returns its master side and the path of its
+// slave, which stands in for a serial device.
+func openTestPty(t *testing.T) (*os.File, string) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("no pseudo-terminal available: %v", err)
+	}
+
+	var unlock int32
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, master.Fd(),
+		syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock)))
+	if errno != 0 {
+		master.Close()
+		t.Skipf("cannot unlock pseudo-terminal: %v", errno)
+	}
+
+	var ptn uint32
+	_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, master.Fd(),
+		syscall.TIOCGPTN, uintptr(unsafe.Pointer(&ptn)))
+	if errno != 0 {
+		master.Close()
+		t.Skipf("cannot get pseudo-terminal number: %v", errno)
+	}
+
+	return master, fmt.Sprintf("/dev/pts/%d", ptn)
+}
+
+func newTestXport(t *testing.T) (*SerialXport, func()) {
+	master, slave := openTestPty(t)
+
+	cfg := NewXportCfg()
+	cfg.DevPath = slave
+	cfg.ReadTimeout = 50 * time.Millisecond
+
+	return NewSerialXport(cfg), func() { master.Close() }
+}
+
+func TestSerialXportStartTwice(t *testing.T) {
+	sx, done := newTestXport(t)
+	defer done()
+
+	if err := sx.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if err := sx.Start(); !nmxutil.IsXport(err) {
+		t.Errorf("second Start: err = %v, want XportError", err)
+	}
+
+	if err := sx.Stop(); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if err := sx.Stop(); !nmxutil.IsXport(err) {
+		t.Errorf("second Stop: err = %v, want XportError", err)
+	}
+
+	// The transport can be restarted once stopped.
+	if err := sx.Start(); err != nil {
+		t.Fatalf("restart: %v", err)
+	}
+	if err := sx.Stop(); err != nil {
+		t.Fatalf("Stop after restart: %v", err)
+	}
+}
+
+func TestSerialXportConcurrentStartStop(t *testing.T) {
+	sx, done := newTestXport(t)
+	defer done()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 64)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				// Each call either succeeds or finds the transport already
+				// in the requested state.
+				if err := sx.Start(); err != nil && !nmxutil.IsXport(err) {
+					errs <- err
+				}
+				if err := sx.Stop(); err != nil && !nmxutil.IsXport(err) {
+					errs <- err
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// Leave the transport stopped regardless of how the calls interleaved.
+	sx.Stop()
+}
@@ -0,0 +1,215 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package nmserial
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joaojeronimo/go-crc16"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmxutil"
+)
+
+func TestTapReader(t *testing.T) {
+	input := []byte("\x06\x09AAoAAQAAAAAAAQ==\n\x04\x14AAE=\nnoise")
+
+	tests := []struct {
+		name  string
+		bufSz int
+	}{
+		{"one byte", 1},
+		{"small", 7},
+		{"whole", 1024},
+	}
+
+	for _, tt := range tests {
+		var chunks [][]byte
+		tr := &tapReader{
+			r: bytes.NewReader(input),
+			tap: func(data []byte) {
+				chunks = append(chunks, data)
+			},
+		}
+
+		var got []byte
+		buf := make([]byte, tt.bufSz)
+		for {
+			n, err := tr.Read(buf)
+			got = append(got, buf[:n]...)
+
+			// The tap must have been given a copy.
+			for i := range buf {
+				buf[i] = 0
+			}
+
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if !bytes.Equal(got, input) {
+			t.Errorf("%s: read %q, want %q", tt.name, got, input)
+		}
+		if tapped := bytes.Join(chunks, nil); !bytes.Equal(tapped, input) {
+			t.Errorf("%s: tapped %q, want %q", tt.name, tapped, input)
+		}
+		for i, c := range chunks {
+			if len(c) == 0 || len(c) > tt.bufSz {
+				t.Errorf("%s: chunk %d has %d bytes", tt.name, i, len(c))
+			}
+		}
+	}
+}
+
+// Encodes payload as the lines of a serial frame, as Tx does, with at most
+// chunkLen base64 characters per line.  chunkLen must be a multiple of 4.
+func testFrameLines(payload []byte, chunkLen int) []string {
+	crc := make([]byte, 2)
+	binary.BigEndian.PutUint16(crc, crc16.Crc16(payload))
+	body := append(append([]byte(nil), payload...), crc...)
+
+	pkt := make([]byte, 2)
+	binary.BigEndian.PutUint16(pkt, uint16(len(body)))
+	b64 := base64.StdEncoding.EncodeToString(append(pkt, body...))
+
+	var lines []string
+	for off := 0; off < len(b64); off += chunkLen {
+		end := off + chunkLen
+		if end > len(b64) {
+			end = len(b64)
+		}
+
+		prefix := "\x04\x14"
+		if off == 0 {
+			prefix = "\x06\x09"
+		}
+		lines = append(lines, prefix+b64[off:end]+"\n")
+	}
+
+	return lines
+}
+
+func TestSerialRxFrameTimeout(t *testing.T) {
+	payload := []byte("a response that spans several serial lines")
+	frame := testFrameLines(payload, 16)
+	first := frame[0]
+	rest := strings.Join(frame[1:], "")
+	whole := strings.Join(frame, "")
+
+	// One call to Rx: the input available to it, preceded by a delay.
+	type rxStep struct {
+		delay time.Duration
+		input string
+		want  string // "data", "timeout" (read timeout) or "reassembly".
+	}
+
+	tests := []struct {
+		name         string
+		frameTimeout time.Duration
+		steps        []rxStep
+	}{
+		{
+			name:         "complete frame",
+			frameTimeout: 10 * time.Millisecond,
+			steps: []rxStep{
+				{0, whole, "data"},
+			},
+		},
+		{
+			name:         "continuation within timeout",
+			frameTimeout: time.Second,
+			steps: []rxStep{
+				{0, first, "timeout"},
+				{0, rest, "data"},
+			},
+		},
+		{
+			name:         "stale continuation",
+			frameTimeout: 10 * time.Millisecond,
+			steps: []rxStep{
+				{0, first, "timeout"},
+				{30 * time.Millisecond, rest, "reassembly"},
+				{0, whole, "data"},
+			},
+		},
+		{
+			name:         "stall detected on read timeout",
+			frameTimeout: 10 * time.Millisecond,
+			steps: []rxStep{
+				{0, first, "timeout"},
+				{30 * time.Millisecond, "", "reassembly"},
+				{0, "", "timeout"},
+				{0, whole, "data"},
+			},
+		},
+		{
+			name:         "no frame timeout",
+			frameTimeout: 0,
+			steps: []rxStep{
+				{0, first, "timeout"},
+				{30 * time.Millisecond, rest, "data"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		cfg := NewXportCfg()
+		cfg.FrameTimeout = tt.frameTimeout
+		sx := NewSerialXport(cfg)
+
+		for i, step := range tt.steps {
+			time.Sleep(step.delay)
+			sx.scanner = bufio.NewScanner(strings.NewReader(step.input))
+
+			data, err := sx.Rx()
+
+			var got string
+			switch {
+			case err == nil:
+				got = "data"
+				if !bytes.Equal(data, payload) {
+					t.Fatalf("%s: step %d: received %q, want %q",
+						tt.name, i, data, payload)
+				}
+			case err == errTimeout:
+				got = "timeout"
+			case nmxutil.IsReassemblyTimeout(err):
+				got = "reassembly"
+			default:
+				t.Fatalf("%s: step %d: unexpected error: %v", tt.name, i, err)
+			}
+
+			if got != step.want {
+				t.Fatalf("%s: step %d: got %s, want %s",
+					tt.name, i, got, step.want)
+			}
+		}
+	}
+}
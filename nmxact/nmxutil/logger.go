@@ -0,0 +1,78 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package nmxutil
+
+import (
+	"fmt"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Receives log events generated by nmxact.  kv is a list of alternating keys
+// and values that describe the event.  Applications that use structured
+// logging can install their own implementation with SetLogger().
+type Logger interface {
+	Log(level log.Level, msg string, kv ...interface{})
+}
+
+// The default logger; forwards events to the global logrus logger, with each
+// key-value pair converted to a logrus field.
+type LogrusLogger struct{}
+
+func (l *LogrusLogger) Log(level log.Level, msg string, kv ...interface{}) {
+	if !log.IsLevelEnabled(level) {
+		return
+	}
+
+	fields := make(log.Fields, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		fields[fmt.Sprintf("%v", kv[i])] = kv[i+1]
+	}
+
+	log.WithFields(fields).Log(level, msg)
+}
+
+var loggerMtx sync.Mutex
+var logger Logger = &LogrusLogger{}
+
+// Replaces the logger that nmxact reports events to.  A nil argument restores
+// the default logrus logger.
+func SetLogger(l Logger) {
+	loggerMtx.Lock()
+	defer loggerMtx.Unlock()
+
+	if l == nil {
+		l = &LogrusLogger{}
+	}
+	logger = l
+}
+
+func GetLogger() Logger {
+	loggerMtx.Lock()
+	defer loggerMtx.Unlock()
+
+	return logger
+}
+
+// Reports an event to the currently installed logger.
+func Log(level log.Level, msg string, kv ...interface{}) {
+	GetLogger().Log(level, msg, kv...)
+}
@@ -48,6 +48,137 @@ func IsRspTimeout(err error) bool {
 	return ok
 }
 
+// Indicates that a response was larger than the receive path can hold.  The
+// oversized response is discarded rather than truncated.
+type RspSizeError struct {
+	Text  string
+	Limit int
+}
+
+func NewRspSizeError(limit int, text string) *RspSizeError {
+	return &RspSizeError{
+		Limit: limit,
+		Text:  text,
+	}
+}
+
+func FmtRspSizeError(limit int, format string,
+	args ...interface{}) *RspSizeError {
+
+	return NewRspSizeError(limit, fmt.Sprintf(format, args...))
+}
+
+func (e *RspSizeError) Error() string {
+	return e.Text
+}
+
+func IsRspSize(err error) bool {
+	_, ok := err.(*RspSizeError)
+	return ok
+}
+
+// Indicates that an encoded request is larger than the session can send.
+// Size is the encoded size and Limit the maximum, both in bytes.
+type ReqSizeError struct {
+	Text  string
+	Size  int
+	Limit int
+}
+
+func NewReqSizeError(size int, limit int) *ReqSizeError {
+	return &ReqSizeError{
+		Text: fmt.Sprintf("Request too big: %d bytes; maximum is %d",
+			size, limit),
+		Size:  size,
+		Limit: limit,
+	}
+}
+
+func (e *ReqSizeError) Error() string {
+	return e.Text
+}
+
+func IsReqSize(err error) bool {
+	_, ok := err.(*ReqSizeError)
+	return ok
+}
+
+// Indicates that a request was rejected because the session already has the
+// maximum number of requests awaiting a response.
+type OutstandingLimitError struct {
+	Text  string
+	Limit int
+}
+
+func NewOutstandingLimitError(limit int) *OutstandingLimitError {
+	return &OutstandingLimitError{
+		Text: fmt.Sprintf("Too many outstanding requests; limit is %d",
+			limit),
+		Limit: limit,
+	}
+}
+
+func (e *OutstandingLimitError) Error() string {
+	return e.Text
+}
+
+func IsOutstandingLimit(err error) bool {
+	_, ok := err.(*OutstandingLimitError)
+	return ok
+}
+
+// Indicates that a fragmented response was not fully received within the
+// reassembly timeout.  The partial response is discarded.
+type ReassemblyTimeoutError struct {
+	Text string
+}
+
+func NewReassemblyTimeoutError(text string) *ReassemblyTimeoutError {
+	return &ReassemblyTimeoutError{
+		Text: text,
+	}
+}
+
+func FmtReassemblyTimeoutError(format string,
+	args ...interface{}) *ReassemblyTimeoutError {
+
+	return NewReassemblyTimeoutError(fmt.Sprintf(format, args...))
+}
+
+func (e *ReassemblyTimeoutError) Error() string {
+	return e.Text
+}
+
+func IsReassemblyTimeout(err error) bool {
+	_, ok := err.(*ReassemblyTimeoutError)
+	return ok
+}
+
+// Indicates that an upload was refused because the image is older than the
+// one the device is running.
+type DowngradeError struct {
+	Text string
+}
+
+func NewDowngradeError(text string) *DowngradeError {
+	return &DowngradeError{
+		Text: text,
+	}
+}
+
+func FmtDowngradeError(format string, args ...interface{}) *DowngradeError {
+	return NewDowngradeError(fmt.Sprintf(format, args...))
+}
+
+func (e *DowngradeError) Error() string {
+	return e.Text
+}
+
+func IsDowngrade(err error) bool {
+	_, ok := err.(*DowngradeError)
+	return ok
+}
+
 type BleSesnDisconnectError struct {
 	Text   string
 	Reason int
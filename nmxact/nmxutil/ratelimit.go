@@ -0,0 +1,81 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package nmxutil
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket that limits the aggregate number of bytes
+// sent per second.  It is safe for concurrent use, so a single limiter can
+// be shared by every session on a transport.
+type RateLimiter struct {
+	rate   float64 // Bytes per second.
+	burst  float64 // Maximum accumulated tokens.
+	tokens float64
+	last   time.Time
+	mtx    sync.Mutex
+}
+
+// NewRateLimiter creates a limiter allowing bytesPerSec bytes per second on
+// average and up to burst bytes at once.  A burst of 0 defaults to one
+// second's worth of tokens.
+func NewRateLimiter(bytesPerSec int, burst int) *RateLimiter {
+	if burst <= 0 {
+		burst = bytesPerSec
+	}
+
+	return &RateLimiter{
+		rate:   float64(bytesPerSec),
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// reserve takes n tokens from the bucket and returns how long the caller
+// must wait before transmitting.  The bucket is allowed to go negative so
+// that requests larger than the burst size still make progress.
+func (rl *RateLimiter) reserve(n int) time.Duration {
+	rl.mtx.Lock()
+	defer rl.mtx.Unlock()
+
+	now := time.Now()
+	rl.tokens += now.Sub(rl.last).Seconds() * rl.rate
+	if rl.tokens > rl.burst {
+		rl.tokens = rl.burst
+	}
+	rl.last = now
+
+	rl.tokens -= float64(n)
+	if rl.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-rl.tokens / rl.rate * float64(time.Second))
+}
+
+// Wait blocks until n bytes may be sent.
+func (rl *RateLimiter) Wait(n int) {
+	if d := rl.reserve(n); d > 0 {
+		time.Sleep(d)
+	}
+}
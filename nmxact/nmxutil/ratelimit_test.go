@@ -0,0 +1,97 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package nmxutil
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiterReserve(t *testing.T) {
+	// Reservations are made back to back, so refill between them is
+	// negligible compared to the tolerance.
+	const tolerance = 20 * time.Millisecond
+
+	tests := []struct {
+		name  string
+		rate  int
+		burst int
+		reqs  []int
+		waits []int // Milliseconds.
+	}{
+		{
+			name:  "within burst",
+			rate:  1000,
+			burst: 500,
+			reqs:  []int{200, 300},
+			waits: []int{0, 0},
+		},
+		{
+			name:  "beyond burst",
+			rate:  1000,
+			burst: 500,
+			reqs:  []int{500, 250, 250},
+			waits: []int{0, 250, 500},
+		},
+		{
+			name:  "larger than burst",
+			rate:  1000,
+			burst: 100,
+			reqs:  []int{600},
+			waits: []int{500},
+		},
+		{
+			name:  "default burst",
+			rate:  100,
+			burst: 0,
+			reqs:  []int{100, 10},
+			waits: []int{0, 100},
+		},
+	}
+
+	for _, tt := range tests {
+		rl := NewRateLimiter(tt.rate, tt.burst)
+		for i, n := range tt.reqs {
+			d := rl.reserve(n)
+			want := time.Duration(tt.waits[i]) * time.Millisecond
+			if d < want-tolerance || d > want+tolerance {
+				t.Errorf("%s: reserve #%d (%d bytes) = %v, want %v",
+					tt.name, i, n, d, want)
+			}
+		}
+	}
+}
+
+func TestRateLimiterRefill(t *testing.T) {
+	rl := NewRateLimiter(1000, 100)
+	if d := rl.reserve(100); d != 0 {
+		t.Fatalf("initial reserve = %v, want 0", d)
+	}
+
+	// 200ms at 1000 B/s more than refills the bucket; the surplus is capped
+	// at the burst size.
+	time.Sleep(200 * time.Millisecond)
+	if d := rl.reserve(100); d != 0 {
+		t.Fatalf("reserve after refill = %v, want 0", d)
+	}
+	if d := rl.reserve(50); d <= 0 {
+		t.Fatalf("reserve beyond burst = %v, want > 0", d)
+	}
+}
@@ -21,6 +21,7 @@ package omp
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/fatih/structs"
 	"github.com/runtimeco/go-coap"
@@ -93,15 +94,46 @@ func DecodeOmp(m coap.Message, rxFilter nmcoap.RxMsgFilter) (nmp.NmpRsp, error)
 	return rsp, nil
 }
 
+// ValidateResPath checks that res is an absolute CoAP URI path with no
+// empty segments, query, or fragment.
+func ValidateResPath(res string) error {
+	if !strings.HasPrefix(res, "/") || len(res) < 2 {
+		return fmt.Errorf("Invalid OMP resource \"%s\"; must be an "+
+			"absolute path", res)
+	}
+	if strings.ContainsAny(res, "?# \t\r\n") {
+		return fmt.Errorf("Invalid OMP resource \"%s\"; must not contain "+
+			"whitespace, query or fragment", res)
+	}
+	for _, seg := range strings.Split(res[1:], "/") {
+		if seg == "" || seg == "." || seg == ".." {
+			return fmt.Errorf("Invalid OMP resource \"%s\"; bad path "+
+				"segment \"%s\"", res, seg)
+		}
+	}
+
+	return nil
+}
+
 type encodeRecord struct {
 	m        coap.Message
 	hdrBytes []byte
 	fieldMap map[string]interface{}
 }
 
-func encodeOmpBase(txFilter nmcoap.TxMsgFilter, isTcp bool, nmr *nmp.NmpMsg) (encodeRecord, error) {
+// An empty res selects the default resource, nmxutil.OmpRes.
+func encodeOmpBase(txFilter nmcoap.TxMsgFilter, isTcp bool, res string,
+	nmr *nmp.NmpMsg) (encodeRecord, error) {
+
 	er := encodeRecord{}
 
+	if res == "" {
+		res = nmxutil.OmpRes
+	}
+	if err := ValidateResPath(res); err != nil {
+		return er, err
+	}
+
 	mp := coap.MessageParams{
 		Type:  coap.Confirmable,
 		Code:  coap.PUT,
@@ -114,15 +146,24 @@ func encodeOmpBase(txFilter nmcoap.TxMsgFilter, isTcp bool, nmr *nmp.NmpMsg) (en
 		er.m = coap.NewDgramMessage(mp)
 	}
 
-	er.m.SetPathString(nmxutil.OmpRes)
+	er.m.SetPathString(res)
 
 	payload := []byte{}
 	enc := codec.NewEncoderBytes(&payload, new(codec.CborHandle))
 
-	// Convert request struct to map, use "codec" tag which is compatible with "structs"
-	s := structs.New(nmr.Body)
-	s.TagName = "codec"
-	er.fieldMap = s.Map()
+	if m, ok := nmr.Body.(map[string]interface{}); ok {
+		// Generic requests already carry a map; copy it so that adding the
+		// header doesn't modify the caller's body.
+		er.fieldMap = make(map[string]interface{}, len(m)+1)
+		for k, v := range m {
+			er.fieldMap[k] = v
+		}
+	} else {
+		// Convert request struct to map, use "codec" tag which is compatible with "structs"
+		s := structs.New(nmr.Body)
+		s.TagName = "codec"
+		er.fieldMap = s.Map()
+	}
 
 	// Add the NMP header to the OMP response map.
 	er.hdrBytes = nmr.Hdr.Bytes()
@@ -144,8 +185,10 @@ func encodeOmpBase(txFilter nmcoap.TxMsgFilter, isTcp bool, nmr *nmp.NmpMsg) (en
 	return er, nil
 }
 
-func EncodeOmpTcp(txFilter nmcoap.TxMsgFilter, nmr *nmp.NmpMsg) ([]byte, error) {
-	er, err := encodeOmpBase(txFilter, true, nmr)
+func EncodeOmpTcp(txFilter nmcoap.TxMsgFilter, res string,
+	nmr *nmp.NmpMsg) ([]byte, error) {
+
+	er, err := encodeOmpBase(txFilter, true, res, nmr)
 	if err != nil {
 		return nil, err
 	}
@@ -158,8 +201,10 @@ func EncodeOmpTcp(txFilter nmcoap.TxMsgFilter, nmr *nmp.NmpMsg) ([]byte, error)
 	return data, nil
 }
 
-func EncodeOmpDgram(txFilter nmcoap.TxMsgFilter, nmr *nmp.NmpMsg) ([]byte, error) {
-	er, err := encodeOmpBase(txFilter, false, nmr)
+func EncodeOmpDgram(txFilter nmcoap.TxMsgFilter, res string,
+	nmr *nmp.NmpMsg) ([]byte, error) {
+
+	er, err := encodeOmpBase(txFilter, false, res, nmr)
 	if err != nil {
 		return nil, err
 	}
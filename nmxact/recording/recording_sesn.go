@@ -0,0 +1,183 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package recording
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/runtimeco/go-coap"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmcoap"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmxutil"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/omp"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
+)
+
+// RecordingSesn encodes each management request as the configured protocol
+// would put it on the wire and records it with its transport.  No response
+// is ever received: request functions fail with an XportError once the
+// request has been recorded.
+type RecordingSesn struct {
+	cfg  sesn.SesnCfg
+	rx   *RecordingXport
+	open bool
+}
+
+func NewRecordingSesn(rx *RecordingXport, cfg sesn.SesnCfg) *RecordingSesn {
+	return &RecordingSesn{
+		cfg: cfg,
+		rx:  rx,
+	}
+}
+
+func (s *RecordingSesn) Open() error {
+	if s.open {
+		return nmxutil.NewSesnAlreadyOpenError(
+			"Attempt to open an already-open recording session")
+	}
+
+	s.open = true
+	return nil
+}
+
+func (s *RecordingSesn) Close() error {
+	if !s.open {
+		return nmxutil.NewSesnClosedError(
+			"Attempt to close an unopened recording session")
+	}
+
+	s.open = false
+	return nil
+}
+
+func (s *RecordingSesn) IsOpen() bool {
+	return s.open
+}
+
+func (s *RecordingSesn) MtuIn() int {
+	return s.rx.Mtu
+}
+
+func (s *RecordingSesn) MtuOut() int {
+	return s.rx.Mtu
+}
+
+func (s *RecordingSesn) encode(m *nmp.NmpMsg) ([]byte, error) {
+	if s.cfg.MgmtProto == sesn.MGMT_PROTO_NMP {
+		return nmp.EncodeNmp(m, s.cfg.NmpAuth)
+	} else {
+		return omp.EncodeOmpDgram(s.cfg.TxFilter, s.cfg.OmpRes, m)
+	}
+}
+
+func (s *RecordingSesn) record(m *nmp.NmpMsg) error {
+	if !s.open {
+		return nmxutil.NewSesnClosedError(
+			"Attempt to transmit over closed recording session")
+	}
+
+	b, err := s.encode(m)
+	if err != nil {
+		return err
+	}
+	s.rx.Tx(b)
+
+	return nmxutil.NewXportError("Recording session receives no responses")
+}
+
+func (s *RecordingSesn) TxRxMgmt(m *nmp.NmpMsg,
+	timeout time.Duration) (nmp.NmpRsp, error) {
+
+	return nil, s.record(m)
+}
+
+func (s *RecordingSesn) TxRxMgmtAsync(m *nmp.NmpMsg,
+	timeout time.Duration, ch chan nmp.NmpRsp, errc chan error) error {
+
+	errc <- s.record(m)
+	return nil
+}
+
+func (s *RecordingSesn) AbortRx(seq uint8) error {
+	return nil
+}
+
+func (s *RecordingSesn) TxCoap(m coap.Message) error {
+	if !s.open {
+		return nmxutil.NewSesnClosedError(
+			"Attempt to transmit over closed recording session")
+	}
+
+	b, err := nmcoap.Encode(m)
+	if err != nil {
+		return err
+	}
+	return s.rx.Tx(b)
+}
+
+func (s *RecordingSesn) MgmtProto() sesn.MgmtProto {
+	return s.cfg.MgmtProto
+}
+
+func (s *RecordingSesn) ListenCoap(
+	mc nmcoap.MsgCriteria) (*nmcoap.Listener, error) {
+
+	return nil, fmt.Errorf("Op not supported by recording session")
+}
+
+func (s *RecordingSesn) StopListenCoap(mc nmcoap.MsgCriteria) {
+}
+
+func (s *RecordingSesn) OmpRes() string {
+	return s.cfg.OmpRes
+}
+
+func (s *RecordingSesn) Tag() string {
+	return s.cfg.Tag
+}
+
+func (s *RecordingSesn) NmpAuth() *nmp.NmpAuth {
+	return s.cfg.NmpAuth
+}
+
+func (s *RecordingSesn) CoapIsTcp() bool {
+	return false
+}
+
+func (s *RecordingSesn) RxAccept() (sesn.Sesn, *sesn.SesnCfg, error) {
+	return nil, nil, fmt.Errorf("Op not supported by recording session")
+}
+
+func (s *RecordingSesn) RxCoap(opt sesn.TxOptions) (coap.Message, error) {
+	return nil, fmt.Errorf("Op not supported by recording session")
+}
+
+func (s *RecordingSesn) Filters() (nmcoap.TxMsgFilter, nmcoap.RxMsgFilter) {
+	return s.cfg.TxFilter, s.cfg.RxFilter
+}
+
+func (s *RecordingSesn) SetFilters(txFilter nmcoap.TxMsgFilter,
+	rxFilter nmcoap.RxMsgFilter) {
+
+	s.cfg.TxFilter = txFilter
+	s.cfg.RxFilter = rxFilter
+}
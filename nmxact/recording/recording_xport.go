@@ -0,0 +1,87 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+// Package recording provides a transport that talks to no device.  It
+// captures the encoded form of every request sent over it, allowing the
+// on-wire encoding of a command to be compared against known-good bytes.
+package recording
+
+import (
+	"sync"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
+)
+
+// Maximum payload size reported by recording sessions.
+const DFLT_MTU = 512
+
+type RecordingXport struct {
+	// Value returned by the MtuIn() and MtuOut() methods of sessions built
+	// from this transport.
+	Mtu int
+
+	mtx    sync.Mutex
+	frames [][]byte
+}
+
+func NewRecordingXport() *RecordingXport {
+	return &RecordingXport{
+		Mtu: DFLT_MTU,
+	}
+}
+
+func (rx *RecordingXport) BuildSesn(cfg sesn.SesnCfg) (sesn.Sesn, error) {
+	return NewRecordingSesn(rx, cfg), nil
+}
+
+func (rx *RecordingXport) Start() error {
+	return nil
+}
+
+func (rx *RecordingXport) Stop() error {
+	return nil
+}
+
+// Tx records data as a single frame.
+func (rx *RecordingXport) Tx(data []byte) error {
+	rx.mtx.Lock()
+	defer rx.mtx.Unlock()
+
+	rx.frames = append(rx.frames, append([]byte(nil), data...))
+	return nil
+}
+
+// Frames returns the frames recorded so far, oldest first.  Each frame is
+// one complete encoded request; it is not split according to the MTU.
+func (rx *RecordingXport) Frames() [][]byte {
+	rx.mtx.Lock()
+	defer rx.mtx.Unlock()
+
+	frames := make([][]byte, len(rx.frames))
+	copy(frames, rx.frames)
+	return frames
+}
+
+// Reset discards all recorded frames.
+func (rx *RecordingXport) Reset() {
+	rx.mtx.Lock()
+	defer rx.mtx.Unlock()
+
+	rx.frames = nil
+}
@@ -0,0 +1,103 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package sesn
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+type JitterMode int
+
+const (
+	// Delays are exactly Base * 2^attempt, capped at Max.
+	JITTER_NONE JitterMode = iota
+
+	// Delays are uniformly distributed in [0, d].
+	JITTER_FULL
+
+	// Delays are uniformly distributed in [d/2, d].
+	JITTER_EQUAL
+)
+
+var jitterModeMap = map[JitterMode]string{
+	JITTER_NONE:  "none",
+	JITTER_FULL:  "full",
+	JITTER_EQUAL: "equal",
+}
+
+// Un-jittered delays saturate here, so that doubling never overflows and the
+// jitter range still fits in an int64.
+const backoffDelayLimit = time.Duration(math.MaxInt64 / 2)
+
+func (j JitterMode) String() string {
+	return jitterModeMap[j]
+}
+
+// BackoffCfg controls the delay between successive connection attempts.  The
+// zero value retries immediately.
+type BackoffCfg struct {
+	// Delay before the first retry; doubled for each subsequent one.
+	Base time.Duration
+
+	// Upper bound on the un-jittered delay.  0 means no bound.
+	Max time.Duration
+
+	Jitter JitterMode
+}
+
+// Delay returns how long to wait before retry number attempt (0-based).
+func (b BackoffCfg) Delay(attempt int) time.Duration {
+	if b.Base <= 0 {
+		return 0
+	}
+
+	d := b.Base
+	for i := 0; i < attempt; i++ {
+		if (b.Max > 0 && d >= b.Max) || d >= backoffDelayLimit {
+			break
+		}
+		d *= 2
+	}
+	if b.Max > 0 && d > b.Max {
+		d = b.Max
+	}
+	if d > backoffDelayLimit {
+		d = backoffDelayLimit
+	}
+
+	switch b.Jitter {
+	case JITTER_FULL:
+		return time.Duration(rand.Int63n(int64(d) + 1))
+	case JITTER_EQUAL:
+		half := d / 2
+		return half + time.Duration(rand.Int63n(int64(d-half)+1))
+	default:
+		return d
+	}
+}
+
+// Wait sleeps for the delay that precedes retry number attempt.
+func (b BackoffCfg) Wait(attempt int) {
+	if d := b.Delay(attempt); d > 0 {
+		time.Sleep(d)
+	}
+}
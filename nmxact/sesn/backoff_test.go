@@ -0,0 +1,108 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package sesn
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoffDelayNoJitter(t *testing.T) {
+	b := BackoffCfg{
+		Base: 100 * time.Millisecond,
+		Max:  time.Second,
+	}
+
+	exp := []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	}
+	for i, e := range exp {
+		if d := b.Delay(i); d != e {
+			t.Errorf("attempt %d: got %s, want %s", i, d, e)
+		}
+	}
+}
+
+func TestBackoffDelayZeroBase(t *testing.T) {
+	b := BackoffCfg{Jitter: JITTER_FULL}
+	if d := b.Delay(5); d != 0 {
+		t.Errorf("got %s, want 0", d)
+	}
+}
+
+func TestBackoffDelayJitterBounds(t *testing.T) {
+	b := BackoffCfg{
+		Base: 10 * time.Millisecond,
+		Max:  time.Second,
+	}
+
+	for attempt := 0; attempt < 10; attempt++ {
+		b.Jitter = JITTER_NONE
+		hi := b.Delay(attempt)
+
+		for i := 0; i < 100; i++ {
+			b.Jitter = JITTER_FULL
+			if d := b.Delay(attempt); d < 0 || d > hi {
+				t.Fatalf("full jitter, attempt %d: %s not in [0, %s]",
+					attempt, d, hi)
+			}
+
+			b.Jitter = JITTER_EQUAL
+			if d := b.Delay(attempt); d < hi/2 || d > hi {
+				t.Fatalf("equal jitter, attempt %d: %s not in [%s, %s]",
+					attempt, d, hi/2, hi)
+			}
+		}
+	}
+}
+
+// Without a bound, large attempt numbers must saturate rather than overflow.
+func TestBackoffDelayUnbounded(t *testing.T) {
+	for _, j := range []JitterMode{JITTER_NONE, JITTER_FULL, JITTER_EQUAL} {
+		b := BackoffCfg{
+			Base:   time.Second,
+			Jitter: j,
+		}
+
+		prev := time.Duration(0)
+		for attempt := 0; attempt < 200; attempt++ {
+			d := b.Delay(attempt)
+			if d < 0 || d > backoffDelayLimit {
+				t.Fatalf("%s jitter, attempt %d: %s out of range",
+					j, attempt, d)
+			}
+			if j == JITTER_NONE && d < prev {
+				t.Fatalf("attempt %d: %s less than previous %s",
+					attempt, d, prev)
+			}
+			prev = d
+		}
+	}
+
+	b := BackoffCfg{Base: time.Duration(1<<63 - 1)}
+	if d := b.Delay(0); d != backoffDelayLimit {
+		t.Errorf("huge base: got %s, want %s", d, backoffDelayLimit)
+	}
+}
@@ -0,0 +1,86 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package sesn
+
+import (
+	"sync"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
+)
+
+// Identifies an in-flight read: the session it is sent over, the NMP
+// operation, and the encoded request body.  The sequence number is
+// deliberately excluded.
+type coalesceKey struct {
+	s     Sesn
+	flags uint8
+	group uint16
+	id    uint8
+	body  string
+}
+
+// A read that is currently on the wire.  Callers that arrive while it is
+// outstanding wait for done and then share its result.
+type coalesceCall struct {
+	done chan struct{}
+	rsp  nmp.NmpRsp
+	err  error
+}
+
+var coalesceMtx sync.Mutex
+var coalesceMap = map[coalesceKey]*coalesceCall{}
+
+// txRxCoalesced performs a read request, sharing a single exchange with any
+// identical read that is already in flight on the same session.  Every caller
+// receives the same response object; callers must not modify it.
+func txRxCoalesced(s Sesn, m *nmp.NmpMsg, o TxOptions) (nmp.NmpRsp, error) {
+	body, err := nmp.BodyBytes(m.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	key := coalesceKey{
+		s:     s,
+		flags: m.Hdr.Flags,
+		group: m.Hdr.Group,
+		id:    m.Hdr.Id,
+		body:  string(body),
+	}
+
+	coalesceMtx.Lock()
+	if call := coalesceMap[key]; call != nil {
+		coalesceMtx.Unlock()
+		<-call.done
+		return call.rsp, call.err
+	}
+
+	call := &coalesceCall{done: make(chan struct{})}
+	coalesceMap[key] = call
+	coalesceMtx.Unlock()
+
+	call.rsp, call.err = txRxMgmt(s, m, o)
+
+	coalesceMtx.Lock()
+	delete(coalesceMap, key)
+	coalesceMtx.Unlock()
+
+	close(call.done)
+	return call.rsp, call.err
+}
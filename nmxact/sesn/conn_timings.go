@@ -0,0 +1,163 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package sesn
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+type ConnPhase int
+
+const (
+	// Discovering the peer by scanning.
+	CONN_PHASE_SCAN ConnPhase = iota
+
+	// Establishing the connection or opening the transport.
+	CONN_PHASE_CONNECT
+
+	// Negotiating the ATT MTU.
+	CONN_PHASE_MTU
+
+	// Discovering services and subscribing to the response characteristic.
+	CONN_PHASE_DISCOVER
+
+	// Encrypting the link.
+	CONN_PHASE_SECURITY
+
+	// Round trip of the first management request after the session opened.
+	CONN_PHASE_FIRST_RSP
+)
+
+var connPhaseMap = map[ConnPhase]string{
+	CONN_PHASE_SCAN:      "scan",
+	CONN_PHASE_CONNECT:   "connect",
+	CONN_PHASE_MTU:       "mtu",
+	CONN_PHASE_DISCOVER:  "discover",
+	CONN_PHASE_SECURITY:  "security",
+	CONN_PHASE_FIRST_RSP: "first-rsp",
+}
+
+func (p ConnPhase) String() string {
+	return connPhaseMap[p]
+}
+
+// ConnTimings records how long each phase of opening a session took.  Phases
+// that don't apply to a transport, or that weren't reached, are zero.  Time
+// spent in failed connection attempts is included.
+type ConnTimings struct {
+	Scan        time.Duration `json:"scan,omitempty"`
+	Connect     time.Duration `json:"connect,omitempty"`
+	MtuExchange time.Duration `json:"mtu,omitempty"`
+	Discover    time.Duration `json:"discover,omitempty"`
+	Security    time.Duration `json:"security,omitempty"`
+	FirstRsp    time.Duration `json:"first_rsp,omitempty"`
+
+	// When the most recent open began.
+	Started time.Time `json:"-"`
+}
+
+func (t *ConnTimings) phase(p ConnPhase) *time.Duration {
+	switch p {
+	case CONN_PHASE_SCAN:
+		return &t.Scan
+	case CONN_PHASE_CONNECT:
+		return &t.Connect
+	case CONN_PHASE_MTU:
+		return &t.MtuExchange
+	case CONN_PHASE_DISCOVER:
+		return &t.Discover
+	case CONN_PHASE_SECURITY:
+		return &t.Security
+	case CONN_PHASE_FIRST_RSP:
+		return &t.FirstRsp
+	default:
+		return nil
+	}
+}
+
+// Lists the phases that were recorded, e.g., "connect=12ms mtu=30ms".
+func (t ConnTimings) String() string {
+	var parts []string
+	for p := CONN_PHASE_SCAN; p <= CONN_PHASE_FIRST_RSP; p++ {
+		if d := *t.phase(p); d != 0 {
+			parts = append(parts, fmt.Sprintf("%s=%s", p, d))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// ConnTimer accumulates a session's ConnTimings.  It is safe for concurrent
+// use; its zero value is ready to use.
+type ConnTimer struct {
+	mtx sync.Mutex
+	t   ConnTimings
+}
+
+// Reset discards all recorded timings and marks the start of an open.
+// Sessions call this at the start of an open.
+func (ct *ConnTimer) Reset() {
+	ct.mtx.Lock()
+	defer ct.mtx.Unlock()
+
+	ct.t = ConnTimings{Started: time.Now()}
+}
+
+// Add adds the time elapsed since start to the specified phase.
+func (ct *ConnTimer) Add(p ConnPhase, start time.Time) {
+	d := time.Since(start)
+
+	ct.mtx.Lock()
+	defer ct.mtx.Unlock()
+
+	if f := ct.t.phase(p); f != nil {
+		*f += d
+	}
+}
+
+// Set overwrites the duration of the specified phase.
+func (ct *ConnTimer) Set(p ConnPhase, d time.Duration) {
+	ct.mtx.Lock()
+	defer ct.mtx.Unlock()
+
+	if f := ct.t.phase(p); f != nil {
+		*f = d
+	}
+}
+
+// RspRcvd records the round trip of a management request that started at
+// start, if it is the first since the timer was reset.
+func (ct *ConnTimer) RspRcvd(start time.Time) {
+	ct.mtx.Lock()
+	defer ct.mtx.Unlock()
+
+	if ct.t.FirstRsp == 0 {
+		ct.t.FirstRsp = time.Since(start)
+	}
+}
+
+func (ct *ConnTimer) Timings() ConnTimings {
+	ct.mtx.Lock()
+	defer ct.mtx.Unlock()
+
+	return ct.t
+}
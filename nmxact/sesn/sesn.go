@@ -38,6 +38,77 @@ type NotifyCb func(msg coap.Message, err error)
 type TxOptions struct {
 	Timeout time.Duration
 	Tries   int
+
+	// Extra NMP header flag bits to set on outgoing requests.  Intended for
+	// experimental features; leave zero unless the peer understands them.
+	// The flags of a response are available via rsp.Hdr().Flags.
+	NmpFlags uint8
+
+	// If true, an NMP read that is identical to one already in flight on the
+	// same session is not transmitted; instead, it shares the outstanding
+	// request's response.  Writes are never coalesced.
+	Coalesce bool
+
+	// Caller-assigned identifier included in log messages about the
+	// request and its response.
+	CorrelationId string
+}
+
+// Implemented by sessions that carry a caller-assigned tag (SesnCfg.Tag).
+type TaggedSesn interface {
+	Tag() string
+}
+
+// SesnTag retrieves a session's tag; empty if it has none.
+func SesnTag(s Sesn) string {
+	if ts, ok := s.(TaggedSesn); ok {
+		return ts.Tag()
+	}
+	return ""
+}
+
+// Implemented by sessions that send OMP requests to a configurable CoAP
+// resource (SesnCfg.OmpRes).
+type OmpResSesn interface {
+	OmpRes() string
+}
+
+// SesnOmpRes retrieves the CoAP resource path a session uses for OMP
+// requests.  An empty string indicates the default (nmxutil.OmpRes).
+func SesnOmpRes(s Sesn) string {
+	if rs, ok := s.(OmpResSesn); ok {
+		return rs.OmpRes()
+	}
+	return ""
+}
+
+// Implemented by sessions that can sign outgoing NMP requests
+// (SesnCfg.NmpAuth).
+type AuthSesn interface {
+	NmpAuth() *nmp.NmpAuth
+}
+
+// SesnNmpAuth retrieves the configuration a session uses to sign outgoing NMP
+// requests; nil if requests are not signed.
+func SesnNmpAuth(s Sesn) *nmp.NmpAuth {
+	if as, ok := s.(AuthSesn); ok {
+		return as.NmpAuth()
+	}
+	return nil
+}
+
+// Implemented by sessions that record how long each phase of opening took.
+type TimedSesn interface {
+	ConnectTimings() ConnTimings
+}
+
+// SesnConnectTimings retrieves the durations of the phases of a session's
+// most recent open; zero if the session doesn't record them.
+func SesnConnectTimings(s Sesn) ConnTimings {
+	if ts, ok := s.(TimedSesn); ok {
+		return ts.ConnectTimings()
+	}
+	return ConnTimings{}
 }
 
 func NewTxOptions() TxOptions {
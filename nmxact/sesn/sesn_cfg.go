@@ -25,6 +25,7 @@ import (
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/bledefs"
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/lora"
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmcoap"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
 )
 
 type MgmtProto int
@@ -45,6 +46,11 @@ func (r MgmtProto) String() string {
 	return mgmtProtoMap[r]
 }
 
+// Default limit on the number of management requests a session may have
+// awaiting a response at once.  It stays well below the 256 NMP sequence
+// numbers that responses are matched by.
+const SESN_DEF_MAX_OUTSTANDING = 64
+
 type OnCloseFn func(s Sesn, err error)
 
 type PeerSpec struct {
@@ -55,6 +61,9 @@ type PeerSpec struct {
 type SesnCfgBleCentral struct {
 	ConnTries   int
 	ConnTimeout time.Duration
+
+	// Delay between connection attempts.
+	ConnBackoff BackoffCfg
 	// XXX: Missing fields.
 }
 
@@ -65,8 +74,20 @@ type SesnCfgBle struct {
 	CloseTimeout time.Duration
 	WriteRsp     bool
 
+	// Management service and characteristic UUIDs, for firmware that
+	// doesn't use the standard ones.  Empty strings select the defaults for
+	// the session's management protocol.  If only MgmtReqChrUuid is set,
+	// responses are expected on the same characteristic.
+	MgmtSvcUuid    string
+	MgmtReqChrUuid string
+	MgmtRspChrUuid string
+
 	// Central configuration.
 	Central SesnCfgBleCentral
+
+	// Time spent discovering the peer before the session was created, if it
+	// was found by scanning.  Reported as the scan phase of ConnectTimings.
+	ScanTime time.Duration
 }
 
 type SesnCfgLora struct {
@@ -76,15 +97,54 @@ type SesnCfgLora struct {
 	Port        uint8
 }
 
+type SesnCfgUdp struct {
+	// How long to wait for the remaining fragments of a partially received
+	// response before discarding it.  0 means wait indefinitely.
+	ReassemblyTimeout time.Duration
+
+	// Differentiated services code point (0-63) to mark outgoing datagrams
+	// with.  0 leaves the socket's default (best effort).  Must be 0 for
+	// sessions that use their transport's shared socket.
+	Dscp int
+
+	// If non-empty, datagrams are relayed through this proxy, given as
+	// "socks5://[user:password@]host:port".  The proxy must support UDP
+	// ASSOCIATE.  Not supported for sessions on a shared socket.
+	Proxy string
+}
+
 type SesnCfg struct {
 	// General configuration.
 	MgmtProto MgmtProto
 	PeerSpec  PeerSpec
 	OnCloseCb OnCloseFn
 
+	// CoAP resource that OMP requests are sent to.  Empty selects the
+	// default, nmxutil.OmpRes.
+	OmpRes string
+
+	// If non-nil, plain NMP requests are signed as described by this
+	// configuration.  OMP requests are never signed.
+	NmpAuth *nmp.NmpAuth
+
+	// If non-zero, the session asks the device for its preferred maximum
+	// NMP message size when it opens, waiting this long for a reply, and
+	// fragments outgoing requests accordingly.
+	MtuQueryTimeout time.Duration
+
+	// Caller-assigned label included in log messages about the session's
+	// traffic and in transport session listings, e.g., a device name.
+	Tag string
+
+	// Maximum number of management requests that may await a response at
+	// once.  A request beyond the limit fails immediately with an
+	// nmxutil.OutstandingLimitError.  0 selects SESN_DEF_MAX_OUTSTANDING.
+	MaxOutstanding int
+
 	// Transport-specific configuration.
 	Ble  SesnCfgBle
 	Lora SesnCfgLora
+	Udp  SesnCfgUdp
 
 	// Filters
 	TxFilter nmcoap.TxMsgFilter
@@ -110,5 +170,9 @@ func NewSesnCfg() SesnCfg {
 			ConfirmedTx: false,
 			Port:        lora.COAP_LORA_PORT,
 		},
+		Udp: SesnCfgUdp{
+			ReassemblyTimeout: 5 * time.Second,
+		},
+		MaxOutstanding: SESN_DEF_MAX_OUTSTANDING,
 	}
 }
@@ -0,0 +1,71 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package sesn
+
+import (
+	"testing"
+	"time"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
+)
+
+// A session that implements none of the optional interfaces.
+type bareSesn struct {
+	Sesn
+}
+
+// A session that implements all of them.
+type fullSesn struct {
+	Sesn
+}
+
+var testAuth = &nmp.NmpAuth{KeyId: 3}
+
+func (s *fullSesn) OmpRes() string        { return "/omgr" }
+func (s *fullSesn) NmpAuth() *nmp.NmpAuth { return testAuth }
+func (s *fullSesn) ConnectTimings() ConnTimings {
+	return ConnTimings{Connect: time.Second}
+}
+
+func TestOptionalSesnInterfaces(t *testing.T) {
+	bare := &bareSesn{}
+	full := &fullSesn{}
+
+	if res := SesnOmpRes(bare); res != "" {
+		t.Errorf("bare OmpRes = %q, want empty", res)
+	}
+	if res := SesnOmpRes(full); res != "/omgr" {
+		t.Errorf("full OmpRes = %q, want /omgr", res)
+	}
+
+	if auth := SesnNmpAuth(bare); auth != nil {
+		t.Errorf("bare NmpAuth = %+v, want nil", auth)
+	}
+	if auth := SesnNmpAuth(full); auth != testAuth {
+		t.Errorf("full NmpAuth = %+v, want %+v", auth, testAuth)
+	}
+
+	if ct := SesnConnectTimings(bare); ct != (ConnTimings{}) {
+		t.Errorf("bare ConnectTimings = %+v, want zero", ct)
+	}
+	if ct := SesnConnectTimings(full); ct.Connect != time.Second {
+		t.Errorf("full ConnectTimings = %+v, want connect=1s", ct)
+	}
+}
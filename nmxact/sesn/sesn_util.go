@@ -23,15 +23,57 @@ import (
 	"time"
 
 	"github.com/runtimeco/go-coap"
+	log "github.com/sirupsen/logrus"
 
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmcoap"
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmxutil"
 )
 
+// mgmtLogKv lists the key-value pairs that identify a management request
+// in log messages.  The session tag and correlation ID are only included if
+// set.
+func mgmtLogKv(s Sesn, hdr nmp.NmpHdr, o TxOptions) []interface{} {
+	var kv []interface{}
+
+	if tag := SesnTag(s); tag != "" {
+		kv = append(kv, "tag", tag)
+	}
+	if o.CorrelationId != "" {
+		kv = append(kv, "corr", o.CorrelationId)
+	}
+
+	return append(kv,
+		"op", hdr.Op, "group", hdr.Group, "id", hdr.Id, "seq", hdr.Seq)
+}
+
 // TxRxMgmt sends a management command (NMP / OMP) and listens for the
 // response.
 func TxRxMgmt(s Sesn, m *nmp.NmpMsg, o TxOptions) (nmp.NmpRsp, error) {
+	m.Hdr.Flags |= o.NmpFlags
+
+	kv := mgmtLogKv(s, m.Hdr, o)
+	nmxutil.Log(log.DebugLevel, "Sending management request", kv...)
+
+	var r nmp.NmpRsp
+	var err error
+	if o.Coalesce && m.Hdr.Op == nmp.NMP_OP_READ {
+		r, err = txRxCoalesced(s, m, o)
+	} else {
+		r, err = txRxMgmt(s, m, o)
+	}
+
+	if err != nil {
+		nmxutil.Log(log.DebugLevel, "Management request failed",
+			append(kv, "err", err.Error())...)
+	} else {
+		nmxutil.Log(log.DebugLevel, "Received management response", kv...)
+	}
+
+	return r, err
+}
+
+func txRxMgmt(s Sesn, m *nmp.NmpMsg, o TxOptions) (nmp.NmpRsp, error) {
 	retries := o.Tries - 1
 	for i := 0; ; i++ {
 		r, err := s.TxRxMgmt(m, o.Timeout)
@@ -46,6 +88,11 @@ func TxRxMgmt(s Sesn, m *nmp.NmpMsg, o TxOptions) (nmp.NmpRsp, error) {
 }
 
 func TxRxMgmtAsync(s Sesn, m *nmp.NmpMsg, o TxOptions, ch chan nmp.NmpRsp, errc chan error) error {
+	m.Hdr.Flags |= o.NmpFlags
+
+	nmxutil.Log(log.DebugLevel, "Sending management request",
+		mgmtLogKv(s, m.Hdr, o)...)
+
 	retries := o.Tries - 1
 	for i := 0; ; i++ {
 		err := s.TxRxMgmtAsync(m, o.Timeout, ch, errc)
@@ -0,0 +1,255 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package sesn
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
+)
+
+// A session that records the header of each request and answers with rsp.
+// If release is non-nil, synchronous requests block until it is closed.
+type mgmtSesn struct {
+	Sesn
+
+	rsp     nmp.NmpRsp
+	release chan struct{}
+
+	mtx  sync.Mutex
+	sent []nmp.NmpHdr
+}
+
+func (s *mgmtSesn) record(m *nmp.NmpMsg) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.sent = append(s.sent, m.Hdr)
+}
+
+func (s *mgmtSesn) Sent() []nmp.NmpHdr {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return append([]nmp.NmpHdr(nil), s.sent...)
+}
+
+func (s *mgmtSesn) TxRxMgmt(m *nmp.NmpMsg,
+	timeout time.Duration) (nmp.NmpRsp, error) {
+
+	s.record(m)
+	if s.release != nil {
+		<-s.release
+	}
+	return s.rsp, nil
+}
+
+func (s *mgmtSesn) TxRxMgmtAsync(m *nmp.NmpMsg, timeout time.Duration,
+	ch chan nmp.NmpRsp, errc chan error) error {
+
+	s.record(m)
+	return nil
+}
+
+func TestTxRxMgmtNmpFlags(t *testing.T) {
+	tests := []struct {
+		hdrFlags uint8
+		optFlags uint8
+		want     uint8
+	}{
+		{0x00, 0x00, 0x00},
+		{0x00, 0x10, 0x10},
+		{0x01, 0x00, 0x01},
+		{0x01, 0x10, 0x11},
+		{0x10, 0x10, 0x10},
+	}
+
+	for _, tt := range tests {
+		for _, async := range []bool{false, true} {
+			s := &mgmtSesn{}
+			m := nmp.MsgFromReq(nmp.NewEchoReq())
+			m.Hdr.Flags = tt.hdrFlags
+
+			o := NewTxOptions()
+			o.NmpFlags = tt.optFlags
+
+			var err error
+			if async {
+				err = TxRxMgmtAsync(s, m, o, nil, nil)
+			} else {
+				_, err = TxRxMgmt(s, m, o)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sent := s.Sent()
+			if len(sent) != 1 || sent[0].Flags != tt.want {
+				t.Errorf("hdr=0x%02x opt=0x%02x async=%v: sent %+v, "+
+					"want flags 0x%02x",
+					tt.hdrFlags, tt.optFlags, async, sent, tt.want)
+			}
+		}
+	}
+}
+
+func echoReadMsg(payload string) *nmp.NmpMsg {
+	r := nmp.NewEchoReq()
+	r.Payload = payload
+	m := nmp.MsgFromReq(r)
+	m.Hdr.Op = nmp.NMP_OP_READ
+	return m
+}
+
+func TestTxRxMgmtCoalesce(t *testing.T) {
+	tests := []struct {
+		name     string
+		coalesce bool
+		second   func() *nmp.NmpMsg
+		wantSent int
+	}{
+		{
+			name:     "identical read",
+			coalesce: true,
+			second:   func() *nmp.NmpMsg { return echoReadMsg("a") },
+			wantSent: 1,
+		},
+		{
+			name:     "coalescing disabled",
+			coalesce: false,
+			second:   func() *nmp.NmpMsg { return echoReadMsg("a") },
+			wantSent: 2,
+		},
+		{
+			name:     "different body",
+			coalesce: true,
+			second:   func() *nmp.NmpMsg { return echoReadMsg("b") },
+			wantSent: 2,
+		},
+		{
+			name:     "different id",
+			coalesce: true,
+			second: func() *nmp.NmpMsg {
+				m := echoReadMsg("a")
+				m.Hdr.Id++
+				return m
+			},
+			wantSent: 2,
+		},
+		{
+			name:     "write",
+			coalesce: true,
+			second: func() *nmp.NmpMsg {
+				m := echoReadMsg("a")
+				m.Hdr.Op = nmp.NMP_OP_WRITE
+				return m
+			},
+			wantSent: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		s := &mgmtSesn{
+			rsp:     nmp.NewEchoRsp(),
+			release: make(chan struct{}),
+		}
+
+		o := NewTxOptions()
+		o.Coalesce = tt.coalesce
+
+		type result struct {
+			rsp nmp.NmpRsp
+			err error
+		}
+		resCh := make(chan result, 2)
+		send := func(m *nmp.NmpMsg) {
+			rsp, err := TxRxMgmt(s, m, o)
+			resCh <- result{rsp, err}
+		}
+
+		// Wait for the first request to go out so that the second one
+		// arrives while it is still in flight.
+		go send(echoReadMsg("a"))
+		for len(s.Sent()) == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		go send(tt.second())
+		time.Sleep(50 * time.Millisecond)
+		close(s.release)
+
+		for i := 0; i < 2; i++ {
+			res := <-resCh
+			if res.err != nil {
+				t.Fatalf("%s: unexpected error: %v", tt.name, res.err)
+			}
+			if res.rsp != s.rsp {
+				t.Errorf("%s: wrong response: %+v", tt.name, res.rsp)
+			}
+		}
+
+		if n := len(s.Sent()); n != tt.wantSent {
+			t.Errorf("%s: %d requests sent, want %d",
+				tt.name, n, tt.wantSent)
+		}
+	}
+}
+
+type taggedSesn struct {
+	Sesn
+	tag string
+}
+
+func (s *taggedSesn) Tag() string { return s.tag }
+
+func TestMgmtLogKv(t *testing.T) {
+	hdr := nmp.NmpHdr{Op: nmp.NMP_OP_READ, Group: 1, Id: 2, Seq: 3}
+	base := []interface{}{
+		"op", hdr.Op, "group", hdr.Group, "id", hdr.Id, "seq", hdr.Seq,
+	}
+
+	tests := []struct {
+		name string
+		s    Sesn
+		corr string
+		want []interface{}
+	}{
+		{"untagged", &bareSesn{}, "", base},
+		{"empty tag", &taggedSesn{}, "", base},
+		{"tag", &taggedSesn{tag: "dev1"}, "",
+			append([]interface{}{"tag", "dev1"}, base...)},
+		{"correlation id", &bareSesn{}, "req7",
+			append([]interface{}{"corr", "req7"}, base...)},
+		{"both", &taggedSesn{tag: "dev1"}, "req7",
+			append([]interface{}{"tag", "dev1", "corr", "req7"}, base...)},
+	}
+
+	for _, tt := range tests {
+		o := NewTxOptions()
+		o.CorrelationId = tt.corr
+
+		got := mgmtLogKv(tt.s, hdr, o)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: kv = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package udp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Client side of a SOCKS5 UDP association (RFC 1928).  Datagrams are sent to
+// the proxy's relay address, each prefixed with a header naming the real
+// destination; replies arrive from the relay with the same header naming the
+// real source.
+
+const SOCKS_DIAL_TIMEOUT = 10 * time.Second
+
+// Largest header the relay adds to a datagram (IPv6 address).
+const SOCKS_UDP_HDR_MAX = 4 + net.IPv6len + 2
+
+const (
+	socksVersion         = 5
+	socksAuthNone        = 0x00
+	socksAuthUserPass    = 0x02
+	socksAuthNoneAccept  = 0xff
+	socksCmdUdpAssociate = 0x03
+	socksAtypIpv4        = 0x01
+	socksAtypDomain      = 0x03
+	socksAtypIpv6        = 0x04
+)
+
+var socksReplyMap = map[byte]string{
+	1: "general SOCKS server failure",
+	2: "connection not allowed by ruleset",
+	3: "network unreachable",
+	4: "host unreachable",
+	5: "connection refused",
+	6: "TTL expired",
+	7: "command not supported",
+	8: "address type not supported",
+}
+
+type socksRelay struct {
+	// The association lasts as long as this control connection is open.
+	ctrl net.Conn
+	addr *net.UDPAddr
+}
+
+func (r *socksRelay) close() {
+	r.ctrl.Close()
+}
+
+// Checks that a proxy URL names a proxy that can carry UDP.
+func parseUdpProxy(proxyUrl string) (*url.URL, error) {
+	u, err := url.Parse(proxyUrl)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid proxy URL: %s", proxyUrl)
+	}
+	if u.Scheme != "socks5" {
+		return nil, fmt.Errorf("Unsupported proxy for UDP transport: %s "+
+			"(only socks5 can relay UDP)", proxyUrl)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("Proxy URL lacks a host: %s", proxyUrl)
+	}
+
+	return u, nil
+}
+
+func socksAuth(conn net.Conn, u *url.URL) error {
+	methods := []byte{socksAuthNone}
+	if u.User != nil {
+		methods = append(methods, socksAuthUserPass)
+	}
+
+	req := append([]byte{socksVersion, byte(len(methods))}, methods...)
+	if _, err := conn.Write(req); err != nil {
+		return err
+	}
+
+	rsp := make([]byte, 2)
+	if _, err := io.ReadFull(conn, rsp); err != nil {
+		return err
+	}
+	if rsp[0] != socksVersion {
+		return fmt.Errorf("not a SOCKS5 server")
+	}
+
+	switch rsp[1] {
+	case socksAuthNone:
+		return nil
+
+	case socksAuthUserPass:
+		user := u.User.Username()
+		pass, _ := u.User.Password()
+		if len(user) > 255 || len(pass) > 255 {
+			return fmt.Errorf("proxy credentials too long")
+		}
+
+		req := []byte{1, byte(len(user))}
+		req = append(req, user...)
+		req = append(req, byte(len(pass)))
+		req = append(req, pass...)
+		if _, err := conn.Write(req); err != nil {
+			return err
+		}
+
+		if _, err := io.ReadFull(conn, rsp); err != nil {
+			return err
+		}
+		if rsp[1] != 0 {
+			return fmt.Errorf("proxy rejected credentials")
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("proxy accepts none of the offered " +
+			"authentication methods")
+	}
+}
+
+// Reads an address in SOCKS format: ATYP, address, port.
+func socksReadAddr(r io.Reader, atyp byte) (*net.UDPAddr, error) {
+	var ip net.IP
+	switch atyp {
+	case socksAtypIpv4:
+		ip = make(net.IP, net.IPv4len)
+	case socksAtypIpv6:
+		ip = make(net.IP, net.IPv6len)
+	default:
+		return nil, fmt.Errorf("unsupported address type: %d", atyp)
+	}
+
+	if _, err := io.ReadFull(r, ip); err != nil {
+		return nil, err
+	}
+
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(r, port); err != nil {
+		return nil, err
+	}
+
+	return &net.UDPAddr{
+		IP:   ip,
+		Port: int(binary.BigEndian.Uint16(port)),
+	}, nil
+}
+
+// socksAssociate asks a SOCKS5 proxy to relay UDP datagrams on our behalf.
+func socksAssociate(proxyUrl string) (*socksRelay, error) {
+	u, err := parseUdpProxy(proxyUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.DialTimeout("tcp", u.Host, SOCKS_DIAL_TIMEOUT)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to connect to proxy %s: %s",
+			u.Host, err.Error())
+	}
+
+	fail := func(err error) (*socksRelay, error) {
+		conn.Close()
+		return nil, fmt.Errorf("SOCKS5 UDP association with %s failed: %s",
+			u.Host, err.Error())
+	}
+
+	conn.SetDeadline(time.Now().Add(SOCKS_DIAL_TIMEOUT))
+
+	if err := socksAuth(conn, u); err != nil {
+		return fail(err)
+	}
+
+	// We don't know which address our datagrams will come from, so ask for
+	// the association with an unspecified client address.
+	req := []byte{
+		socksVersion, socksCmdUdpAssociate, 0,
+		socksAtypIpv4, 0, 0, 0, 0, 0, 0,
+	}
+	if _, err := conn.Write(req); err != nil {
+		return fail(err)
+	}
+
+	hdr := make([]byte, 4)
+	if _, err := io.ReadFull(conn, hdr); err != nil {
+		return fail(err)
+	}
+	if hdr[1] != 0 {
+		reason := socksReplyMap[hdr[1]]
+		if reason == "" {
+			reason = "error " + strconv.Itoa(int(hdr[1]))
+		}
+		if hdr[1] == 7 {
+			reason += "; the proxy does not support UDP ASSOCIATE"
+		}
+		return fail(fmt.Errorf("%s", reason))
+	}
+
+	addr, err := socksReadAddr(conn, hdr[3])
+	if err != nil {
+		return fail(err)
+	}
+
+	// A relay address of 0.0.0.0 means "the address you connected to".
+	if addr.IP.IsUnspecified() {
+		addr.IP = conn.RemoteAddr().(*net.TCPAddr).IP
+	}
+
+	conn.SetDeadline(time.Time{})
+
+	return &socksRelay{
+		ctrl: conn,
+		addr: addr,
+	}, nil
+}
+
+// Prefixes a datagram with the relay header addressing it to dst.
+func socksWrap(dst *net.UDPAddr, data []byte) []byte {
+	b := []byte{0, 0, 0}
+	if ip4 := dst.IP.To4(); ip4 != nil {
+		b = append(b, socksAtypIpv4)
+		b = append(b, ip4...)
+	} else {
+		b = append(b, socksAtypIpv6)
+		b = append(b, dst.IP.To16()...)
+	}
+	b = append(b, byte(dst.Port>>8), byte(dst.Port))
+
+	return append(b, data...)
+}
+
+// Strips the relay header from a received datagram, returning the address of
+// the datagram's original sender.
+func socksUnwrap(data []byte) (*net.UDPAddr, []byte, error) {
+	if len(data) < 4 {
+		return nil, nil, fmt.Errorf("short SOCKS5 UDP datagram")
+	}
+	if data[2] != 0 {
+		return nil, nil, fmt.Errorf("fragmented SOCKS5 UDP datagram")
+	}
+
+	r := bytes.NewReader(data[4:])
+	src, err := socksReadAddr(r, data[3])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return src, data[len(data)-r.Len():], nil
+}
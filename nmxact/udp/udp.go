@@ -24,18 +24,111 @@ import (
 	"net"
 
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmxutil"
 )
 
 const MAX_PACKET_SIZE = 2048
 
-func Listen(peerString string, dispatchCb func(data []byte)) (
-	*net.UDPConn, *net.UDPAddr, error) {
+// Reads datagrams from conn until it is closed.  Datagrams that don't fit in
+// MAX_PACKET_SIZE bytes are discarded and reported via errCb.
+func readLoop(conn *net.UDPConn,
+	dispatchCb func(data []byte, src *net.UDPAddr),
+	errCb func(err error, src *net.UDPAddr)) {
+
+	// Allocate an extra byte so that we can detect an oversized datagram;
+	// the read call silently truncates anything that doesn't fit.
+	data := make([]byte, MAX_PACKET_SIZE+1)
+
+	for {
+		nr, srcAddr, err := conn.ReadFromUDP(data)
+		if err != nil {
+			// Connection closed or read error.
+			return
+		}
+
+		nmxutil.Log(log.DebugLevel, "Received UDP message",
+			"src", srcAddr, "len", nr)
+		if nr > MAX_PACKET_SIZE {
+			nmxutil.Log(log.WarnLevel, "Discarding oversized UDP message",
+				"src", srcAddr, "limit", MAX_PACKET_SIZE)
+			errCb(nmxutil.FmtRspSizeError(MAX_PACKET_SIZE,
+				"UDP response from %v exceeds max packet size (%d)",
+				srcAddr, MAX_PACKET_SIZE), srcAddr)
+			continue
+		}
+		dispatchCb(data[0:nr], srcAddr)
+	}
+}
 
+func resolvePeer(peerString string) (*net.UDPAddr, error) {
 	addr, err := net.ResolveUDPAddr("udp", peerString)
 	if err != nil {
-		return nil, nil,
-			fmt.Errorf("Failure resolving name for UDP session: %s",
-				err.Error())
+		return nil, fmt.Errorf("Failure resolving name for UDP session: %s",
+			err.Error())
+	}
+	return addr, nil
+}
+
+// Largest valid differentiated services code point (six bits).
+const MAX_DSCP = 63
+
+// Marks datagrams that conn sends to peer with the given DSCP.  The DSCP
+// occupies the upper six bits of the IPv4 ToS / IPv6 traffic class byte.
+func setDscp(conn *net.UDPConn, peer *net.UDPAddr, dscp int) error {
+	if dscp < 0 || dscp > MAX_DSCP {
+		return fmt.Errorf("Invalid DSCP value: %d (must be 0-%d)",
+			dscp, MAX_DSCP)
+	}
+
+	var err error
+	if peer.IP.To4() != nil {
+		err = ipv4.NewConn(conn).SetTOS(dscp << 2)
+	} else {
+		err = ipv6.NewConn(conn).SetTrafficClass(dscp << 2)
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to set DSCP %d on UDP socket: %s",
+			dscp, err.Error())
+	}
+
+	return nil
+}
+
+// Indicates whether a datagram from src can be a response from peer.  Peers
+// specified by a multicast, broadcast, or unspecified address are answered
+// from some other address, so any source is accepted for them.
+func peerMatches(peer *net.UDPAddr, src *net.UDPAddr) bool {
+	if peer.IP.IsMulticast() || peer.IP.IsUnspecified() ||
+		peer.IP.Equal(net.IPv4bcast) {
+
+		return true
+	}
+
+	return src != nil && peer.Port == src.Port && peer.IP.Equal(src.IP)
+}
+
+// Listens for datagrams from the specified peer.  Datagrams that don't fit in
+// MAX_PACKET_SIZE bytes are discarded and reported via errCb.  Datagrams from
+// any other source are dropped.
+func Listen(peerString string, dispatchCb func(data []byte),
+	errCb func(err error)) (*net.UDPConn, *net.UDPAddr, error) {
+
+	return listen(peerString, nil, dispatchCb, errCb, nil)
+}
+
+// Like Listen, but also reports each dropped datagram from an unexpected
+// source via strayCb, if non-nil.  If relay is non-nil, datagrams are
+// expected to arrive through that SOCKS5 UDP association.
+func listen(peerString string, relay *socksRelay,
+	dispatchCb func(data []byte), errCb func(err error),
+	strayCb func(src *net.UDPAddr)) (*net.UDPConn, *net.UDPAddr, error) {
+
+	addr, err := resolvePeer(peerString)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	conn, err := net.ListenUDP("udp", nil)
@@ -44,20 +137,49 @@ func Listen(peerString string, dispatchCb func(data []byte)) (
 			fmt.Errorf("Failed to listen for UDP responses: %s", err.Error())
 	}
 
-	go func() {
-		data := make([]byte, MAX_PACKET_SIZE)
+	stray := func(src *net.UDPAddr) bool {
+		if peerMatches(addr, src) {
+			return false
+		}
+
+		nmxutil.Log(log.DebugLevel,
+			"Dropping UDP message from unexpected source",
+			"src", src, "peer", addr)
+		if strayCb != nil {
+			strayCb(src)
+		}
+		return true
+	}
 
-		for {
-			nr, srcAddr, err := conn.ReadFromUDP(data)
-			if err != nil {
-				// Connection closed or read error.
-				return
+	go readLoop(conn,
+		func(data []byte, src *net.UDPAddr) {
+			if relay != nil {
+				if !peerMatches(relay.addr, src) {
+					stray(src)
+					return
+				}
+
+				var err error
+				src, data, err = socksUnwrap(data)
+				if err != nil {
+					nmxutil.Log(log.DebugLevel,
+						"Dropping malformed datagram from SOCKS5 relay",
+						"err", err)
+					return
+				}
 			}
 
-			log.Debugf("Received message from %v %d", srcAddr, nr)
-			dispatchCb(data[0:nr])
-		}
-	}()
+			if !stray(src) {
+				dispatchCb(data)
+			}
+		},
+		func(err error, src *net.UDPAddr) {
+			// The relay hides the original source of an oversized
+			// datagram; assume it came from the peer.
+			if relay != nil || !stray(src) {
+				errCb(err)
+			}
+		})
 
 	return conn, addr, nil
 }
@@ -22,6 +22,8 @@ package udp
 import (
 	"fmt"
 	"net"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/runtimeco/go-coap"
@@ -32,6 +34,7 @@ import (
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmxutil"
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/omp"
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/xport"
 )
 
 type UdpSesn struct {
@@ -39,9 +42,41 @@ type UdpSesn struct {
 	addr *net.UDPAddr
 	conn *net.UDPConn
 	txvr *mgmt.Transceiver
+
+	// Protects addr, conn and relay.
+	mtx sync.Mutex
+
+	// Non-nil if this session uses its transport's shared socket.
+	shared *sharedConn
+
+	// Shared with all sessions on the transport; nil if unlimited.
+	limiter *nmxutil.RateLimiter
+
+	// Non-nil if traffic goes through a SOCKS5 proxy.
+	relay *socksRelay
+
+	// The transport's session list, which the session joins once open; nil
+	// if the session was not built by a transport.
+	sesnList *xport.SesnList
+
+	// Number of datagrams dropped because they did not come from the peer.
+	// Accessed atomically.
+	strayCount uint64
+
+	timer sesn.ConnTimer
 }
 
 func NewUdpSesn(cfg sesn.SesnCfg) (*UdpSesn, error) {
+	if cfg.Udp.Dscp < 0 || cfg.Udp.Dscp > MAX_DSCP {
+		return nil, fmt.Errorf("Invalid DSCP value: %d (must be 0-%d)",
+			cfg.Udp.Dscp, MAX_DSCP)
+	}
+	if cfg.Udp.Proxy != "" {
+		if _, err := parseUdpProxy(cfg.Udp.Proxy); err != nil {
+			return nil, err
+		}
+	}
+
 	s := &UdpSesn{
 		cfg: cfg,
 	}
@@ -50,37 +85,156 @@ func NewUdpSesn(cfg sesn.SesnCfg) (*UdpSesn, error) {
 	if err != nil {
 		return nil, err
 	}
+	txvr.SetReassemblyTimeout(cfg.Udp.ReassemblyTimeout)
+	txvr.SetOmpRes(cfg.OmpRes)
+	txvr.SetNmpAuth(cfg.NmpAuth)
+	txvr.SetMaxOutstanding(cfg.MaxOutstanding)
 	s.txvr = txvr
 
 	return s, nil
 }
 
+func newUdpSesnShared(cfg sesn.SesnCfg, sc *sharedConn) (*UdpSesn, error) {
+	// The shared socket's marking applies to every peer on it.
+	if cfg.Udp.Dscp != 0 {
+		return nil, fmt.Errorf("UDP sessions on a shared socket can't " +
+			"set a DSCP value")
+	}
+
+	s, err := NewUdpSesn(cfg)
+	if err != nil {
+		return nil, err
+	}
+	s.shared = sc
+
+	return s, nil
+}
+
+func (s *UdpSesn) openShared() error {
+	addr, err := resolvePeer(s.cfg.PeerSpec.Udp)
+	if err != nil {
+		return err
+	}
+
+	err = s.shared.addPeer(addr,
+		func(data []byte) {
+			s.txvr.DispatchNmpRsp(data)
+		},
+		func(err error) {
+			s.txvr.ErrorAll(err)
+		})
+	if err != nil {
+		return err
+	}
+
+	s.addr = addr
+	s.conn = s.shared.conn
+	return nil
+}
+
 func (s *UdpSesn) Open() error {
+	s.mtx.Lock()
+
 	if s.conn != nil {
+		s.mtx.Unlock()
 		return nmxutil.NewSesnAlreadyOpenError(
 			"Attempt to open an already-open UDP session")
 	}
 
-	conn, addr, err := Listen(s.cfg.PeerSpec.Udp,
+	s.timer.Reset()
+	start := time.Now()
+
+	err := s.open()
+	s.mtx.Unlock()
+	if err != nil {
+		return err
+	}
+
+	s.timer.Add(sesn.CONN_PHASE_CONNECT, start)
+	if s.sesnList != nil {
+		s.sesnList.Add(s, s.cfg.PeerSpec.Udp)
+	}
+	s.queryMtuPref()
+	return nil
+}
+
+// Connects the session's socket.  The caller must hold the lock.
+func (s *UdpSesn) open() error {
+	if s.shared != nil {
+		if s.cfg.Udp.Proxy != "" {
+			return fmt.Errorf("UDP sessions on a shared socket can't use " +
+				"a proxy")
+		}
+		return s.openShared()
+	}
+
+	var relay *socksRelay
+	if s.cfg.Udp.Proxy != "" {
+		var err error
+		relay, err = socksAssociate(s.cfg.Udp.Proxy)
+		if err != nil {
+			return err
+		}
+	}
+
+	conn, addr, err := listen(s.cfg.PeerSpec.Udp, relay,
 		func(data []byte) {
 			s.txvr.DispatchNmpRsp(data)
+		},
+		func(err error) {
+			s.txvr.ErrorAll(err)
+		},
+		func(src *net.UDPAddr) {
+			atomic.AddUint64(&s.strayCount, 1)
 		})
 	if err != nil {
+		if relay != nil {
+			relay.close()
+		}
 		return err
 	}
 
+	if s.cfg.Udp.Dscp != 0 {
+		if err := setDscp(conn, addr, s.cfg.Udp.Dscp); err != nil {
+			conn.Close()
+			if relay != nil {
+				relay.close()
+			}
+			return err
+		}
+	}
+
 	s.addr = addr
 	s.conn = conn
+	s.relay = relay
 	return nil
 }
 
+func (s *UdpSesn) queryMtuPref() {
+	if s.cfg.MtuQueryTimeout != 0 {
+		s.txvr.QueryMtuPref(s.TxRxMgmt, s.cfg.MtuQueryTimeout)
+	}
+}
+
 func (s *UdpSesn) Close() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
 	if s.conn == nil {
 		return nmxutil.NewSesnClosedError(
 			"Attempt to close an unopened UDP session")
 	}
 
-	s.conn.Close()
+	if s.shared != nil {
+		// The shared socket stays open until the transport stops.
+		s.shared.removePeer(s.addr)
+	} else {
+		s.conn.Close()
+	}
+	if s.relay != nil {
+		s.relay.close()
+		s.relay = nil
+	}
 	s.txvr.ErrorAll(fmt.Errorf("closed"))
 	s.txvr.Stop()
 	s.conn = nil
@@ -88,20 +242,57 @@ func (s *UdpSesn) Close() error {
 	return nil
 }
 
+// Returns the number of datagrams this session has dropped because they came
+// from an address other than its peer.
+func (s *UdpSesn) StrayCount() uint64 {
+	return atomic.LoadUint64(&s.strayCount)
+}
+
 func (s *UdpSesn) IsOpen() bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
 	return s.conn != nil
 }
 
 func (s *UdpSesn) MtuIn() int {
-	return MAX_PACKET_SIZE -
+	mtu := MAX_PACKET_SIZE -
 		omp.OMP_MSG_OVERHEAD -
 		nmp.NMP_HDR_SIZE
+
+	// Datagrams from a SOCKS5 relay carry an extra header.
+	if s.cfg.Udp.Proxy != "" {
+		mtu -= SOCKS_UDP_HDR_MAX
+	}
+	return mtu
 }
 
 func (s *UdpSesn) MtuOut() int {
-	return MAX_PACKET_SIZE -
+	return s.txvr.LimitMtu(MAX_PACKET_SIZE -
 		omp.OMP_MSG_OVERHEAD -
-		nmp.NMP_HDR_SIZE
+		nmp.NMP_HDR_SIZE)
+}
+
+func (s *UdpSesn) tx(b []byte) error {
+	if s.limiter != nil {
+		s.limiter.Wait(len(b))
+	}
+
+	s.mtx.Lock()
+	conn, addr, relay := s.conn, s.addr, s.relay
+	s.mtx.Unlock()
+
+	if conn == nil {
+		return fmt.Errorf("Attempt to transmit over closed UDP session")
+	}
+
+	if relay != nil {
+		_, err := conn.WriteToUDP(socksWrap(addr, b), relay.addr)
+		return err
+	}
+
+	_, err := conn.WriteToUDP(b, addr)
+	return err
 }
 
 func (s *UdpSesn) TxRxMgmt(m *nmp.NmpMsg,
@@ -111,11 +302,12 @@ func (s *UdpSesn) TxRxMgmt(m *nmp.NmpMsg,
 		return nil, fmt.Errorf("Attempt to transmit over closed UDP session")
 	}
 
-	txRaw := func(b []byte) error {
-		_, err := s.conn.WriteToUDP(b, s.addr)
-		return err
+	start := time.Now()
+	rsp, err := s.txvr.TxRxMgmt(s.tx, m, s.MtuOut(), timeout)
+	if err == nil {
+		s.timer.RspRcvd(start)
 	}
-	return s.txvr.TxRxMgmt(txRaw, m, s.MtuOut(), timeout)
+	return rsp, err
 }
 
 func (s *UdpSesn) TxRxMgmtAsync(m *nmp.NmpMsg,
@@ -135,12 +327,7 @@ func (s *UdpSesn) AbortRx(seq uint8) error {
 }
 
 func (s *UdpSesn) TxCoap(m coap.Message) error {
-	txRaw := func(b []byte) error {
-		_, err := s.conn.WriteToUDP(b, s.addr)
-		return err
-	}
-
-	return s.txvr.TxCoap(txRaw, m, s.MtuOut())
+	return s.txvr.TxCoap(s.tx, m, s.MtuOut())
 }
 
 func (s *UdpSesn) MgmtProto() sesn.MgmtProto {
@@ -155,6 +342,22 @@ func (s *UdpSesn) StopListenCoap(mc nmcoap.MsgCriteria) {
 	s.txvr.StopListenCoap(mc)
 }
 
+func (s *UdpSesn) OmpRes() string {
+	return s.cfg.OmpRes
+}
+
+func (s *UdpSesn) Tag() string {
+	return s.cfg.Tag
+}
+
+func (s *UdpSesn) NmpAuth() *nmp.NmpAuth {
+	return s.cfg.NmpAuth
+}
+
+func (s *UdpSesn) ConnectTimings() sesn.ConnTimings {
+	return s.timer.Timings()
+}
+
 func (s *UdpSesn) CoapIsTcp() bool {
 	return false
 }
@@ -0,0 +1,117 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package udp
+
+import (
+	"fmt"
+	"net"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmxutil"
+)
+
+type sharedPeer struct {
+	dispatchCb func(data []byte)
+	errCb      func(err error)
+}
+
+// sharedConn is a single UDP socket used by many sessions.  Inbound
+// datagrams are routed to the session registered for their source address.
+type sharedConn struct {
+	conn  *net.UDPConn
+	peers map[string]sharedPeer
+	mtx   sync.Mutex
+}
+
+func newSharedConn() (*sharedConn, error) {
+	conn, err := net.ListenUDP("udp", nil)
+	if err != nil {
+		return nil,
+			fmt.Errorf("Failed to listen for UDP responses: %s", err.Error())
+	}
+
+	sc := &sharedConn{
+		conn:  conn,
+		peers: map[string]sharedPeer{},
+	}
+
+	go readLoop(conn, sc.dispatch, sc.error)
+
+	return sc, nil
+}
+
+func (sc *sharedConn) lookup(src *net.UDPAddr) (sharedPeer, bool) {
+	sc.mtx.Lock()
+	defer sc.mtx.Unlock()
+
+	p, ok := sc.peers[src.String()]
+	return p, ok
+}
+
+func (sc *sharedConn) dispatch(data []byte, src *net.UDPAddr) {
+	p, ok := sc.lookup(src)
+	if !ok {
+		nmxutil.Log(log.DebugLevel, "Dropping UDP message from unknown peer",
+			"src", src)
+		return
+	}
+	p.dispatchCb(data)
+}
+
+func (sc *sharedConn) error(err error, src *net.UDPAddr) {
+	if p, ok := sc.lookup(src); ok {
+		p.errCb(err)
+	}
+}
+
+// addPeer registers a session's callbacks for datagrams from addr.  Only one
+// session per peer address is permitted; otherwise responses could not be
+// routed unambiguously.
+func (sc *sharedConn) addPeer(addr *net.UDPAddr, dispatchCb func([]byte),
+	errCb func(error)) error {
+
+	sc.mtx.Lock()
+	defer sc.mtx.Unlock()
+
+	key := addr.String()
+	if _, ok := sc.peers[key]; ok {
+		return fmt.Errorf("Shared UDP socket already has a session for %s",
+			key)
+	}
+
+	sc.peers[key] = sharedPeer{
+		dispatchCb: dispatchCb,
+		errCb:      errCb,
+	}
+	return nil
+}
+
+func (sc *sharedConn) removePeer(addr *net.UDPAddr) {
+	sc.mtx.Lock()
+	defer sc.mtx.Unlock()
+
+	delete(sc.peers, addr.String())
+}
+
+func (sc *sharedConn) close() error {
+	return sc.conn.Close()
+}
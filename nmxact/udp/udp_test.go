@@ -0,0 +1,283 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package udp
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmxutil"
+)
+
+func TestListenOversized(t *testing.T) {
+	peer, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer peer.Close()
+
+	dataCh := make(chan []byte, 2)
+	errCh := make(chan error, 2)
+	conn, _, err := Listen(peer.LocalAddr().String(),
+		func(data []byte) {
+			dataCh <- append([]byte(nil), data...)
+		},
+		func(err error) {
+			errCh <- err
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	dst := &net.UDPAddr{
+		IP:   net.IPv4(127, 0, 0, 1),
+		Port: conn.LocalAddr().(*net.UDPAddr).Port,
+	}
+
+	tests := []struct {
+		size      int
+		oversized bool
+	}{
+		{MAX_PACKET_SIZE, false},
+		{MAX_PACKET_SIZE + 1, true},
+		{MAX_PACKET_SIZE * 2, true},
+	}
+
+	for _, tt := range tests {
+		if _, err := peer.WriteToUDP(make([]byte, tt.size), dst); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case data := <-dataCh:
+			if tt.oversized {
+				t.Fatalf("size=%d: datagram dispatched (%d bytes)",
+					tt.size, len(data))
+			}
+			if len(data) != tt.size {
+				t.Fatalf("size=%d: dispatched %d bytes", tt.size, len(data))
+			}
+		case err := <-errCh:
+			if !tt.oversized {
+				t.Fatalf("size=%d: unexpected error: %v", tt.size, err)
+			}
+			if e, ok := err.(*nmxutil.RspSizeError); !ok ||
+				e.Limit != MAX_PACKET_SIZE {
+
+				t.Fatalf("size=%d: err = %v, want RspSizeError", tt.size, err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("size=%d: nothing received", tt.size)
+		}
+	}
+}
+
+func listenLoopback(t *testing.T) *net.UDPConn {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+func TestSharedConnRouting(t *testing.T) {
+	sc, err := newSharedConn()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.close()
+
+	dst := &net.UDPAddr{
+		IP:   net.IPv4(127, 0, 0, 1),
+		Port: sc.conn.LocalAddr().(*net.UDPAddr).Port,
+	}
+
+	peers := make([]*net.UDPConn, 3)
+	for i := range peers {
+		peers[i] = listenLoopback(t)
+		defer peers[i].Close()
+	}
+
+	// Peers 0 and 1 have sessions; peer 2 is unknown to the shared socket.
+	rxCh := make(chan int, 8)
+	for i := 0; i < 2; i++ {
+		i := i
+		err := sc.addPeer(peers[i].LocalAddr().(*net.UDPAddr),
+			func(data []byte) { rxCh <- i },
+			func(err error) {})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = sc.addPeer(peers[0].LocalAddr().(*net.UDPAddr),
+		func([]byte) {}, func(error) {})
+	if err == nil {
+		t.Fatalf("second session for the same peer accepted")
+	}
+
+	tests := []struct {
+		sender int
+		want   int // Index of the session that should receive; -1 for none.
+	}{
+		{0, 0},
+		{1, 1},
+		{2, -1},
+		{1, 1},
+		{0, 0},
+	}
+
+	for _, tt := range tests {
+		if _, err := peers[tt.sender].WriteToUDP([]byte{0}, dst); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case got := <-rxCh:
+			if got != tt.want {
+				t.Fatalf("sender=%d: routed to session %d, want %d",
+					tt.sender, got, tt.want)
+			}
+		case <-time.After(200 * time.Millisecond):
+			if tt.want != -1 {
+				t.Fatalf("sender=%d: nothing received", tt.sender)
+			}
+		}
+	}
+
+	// Once a session is removed, its peer's datagrams are dropped and the
+	// address can be registered again.
+	sc.removePeer(peers[0].LocalAddr().(*net.UDPAddr))
+	if _, err := peers[0].WriteToUDP([]byte{0}, dst); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-rxCh:
+		t.Fatalf("removed peer routed to session %d", got)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	err = sc.addPeer(peers[0].LocalAddr().(*net.UDPAddr),
+		func([]byte) {}, func(error) {})
+	if err != nil {
+		t.Fatalf("re-adding removed peer: %v", err)
+	}
+}
+
+func TestPeerMatches(t *testing.T) {
+	udpAddr := func(s string) *net.UDPAddr {
+		addr, err := net.ResolveUDPAddr("udp", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return addr
+	}
+
+	tests := []struct {
+		peer string
+		src  string
+		want bool
+	}{
+		{"192.168.1.5:1337", "192.168.1.5:1337", true},
+		{"192.168.1.5:1337", "192.168.1.5:1338", false},
+		{"192.168.1.5:1337", "192.168.1.6:1337", false},
+		{"[fe80::1]:1337", "[fe80::1]:1337", true},
+		{"[fe80::1]:1337", "[fe80::2]:1337", false},
+		{"224.0.1.187:5683", "192.168.1.5:5683", true},
+		{"[ff02::1]:5683", "[fe80::1]:5683", true},
+		{"255.255.255.255:1337", "192.168.1.5:1337", true},
+		{"0.0.0.0:1337", "192.168.1.5:1337", true},
+	}
+
+	for _, tt := range tests {
+		got := peerMatches(udpAddr(tt.peer), udpAddr(tt.src))
+		if got != tt.want {
+			t.Errorf("peerMatches(%s, %s) = %v, want %v",
+				tt.peer, tt.src, got, tt.want)
+		}
+	}
+
+	if peerMatches(udpAddr("192.168.1.5:1337"), nil) {
+		t.Errorf("nil source matched a unicast peer")
+	}
+}
+
+func TestListenDropsStray(t *testing.T) {
+	peer := listenLoopback(t)
+	defer peer.Close()
+	stranger := listenLoopback(t)
+	defer stranger.Close()
+
+	dataCh := make(chan []byte, 4)
+	strayCh := make(chan *net.UDPAddr, 4)
+	conn, _, err := listen(peer.LocalAddr().String(), nil,
+		func(data []byte) {
+			dataCh <- append([]byte(nil), data...)
+		},
+		func(err error) {},
+		func(src *net.UDPAddr) {
+			strayCh <- src
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	dst := &net.UDPAddr{
+		IP:   net.IPv4(127, 0, 0, 1),
+		Port: conn.LocalAddr().(*net.UDPAddr).Port,
+	}
+
+	tests := []struct {
+		sender *net.UDPConn
+		stray  bool
+	}{
+		{peer, false},
+		{stranger, true},
+		{peer, false},
+	}
+
+	for i, tt := range tests {
+		if _, err := tt.sender.WriteToUDP([]byte{byte(i)}, dst); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case data := <-dataCh:
+			if tt.stray {
+				t.Fatalf("datagram %d from stranger dispatched", i)
+			}
+			if len(data) != 1 || data[0] != byte(i) {
+				t.Fatalf("datagram %d: dispatched %v", i, data)
+			}
+		case src := <-strayCh:
+			if !tt.stray {
+				t.Fatalf("datagram %d from peer reported as stray", i)
+			}
+			if src.Port != tt.sender.LocalAddr().(*net.UDPAddr).Port {
+				t.Fatalf("datagram %d: stray source %v", i, src)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("datagram %d: nothing received", i)
+		}
+	}
+}
@@ -21,35 +21,122 @@ package udp
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmxutil"
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/xport"
 )
 
+type XportCfg struct {
+	// If set, all sessions built from the transport share a single socket
+	// rather than opening one each.  Responses are routed by source address,
+	// so at most one session per peer may be open at a time.
+	SharedSocket bool
+
+	// Maximum aggregate transmit rate, in bytes per second, across all
+	// sessions built from the transport.  0 means unlimited.
+	TxRate int
+
+	// Largest burst permitted by the rate limiter, in bytes.  0 defaults to
+	// one second's worth of traffic.
+	TxBurst int
+}
+
+func NewXportCfg() *XportCfg {
+	return &XportCfg{}
+}
+
 type UdpXport struct {
-	started bool
+	cfg      *XportCfg
+	shared   *sharedConn
+	limiter  *nmxutil.RateLimiter
+	started  bool
+	sesnList xport.SesnList
+
+	// Protects started and shared; Start and Stop may race.
+	mtx sync.Mutex
 }
 
 func NewUdpXport() *UdpXport {
-	return &UdpXport{}
+	return NewUdpXportWithCfg(NewXportCfg())
+}
+
+func NewUdpXportWithCfg(cfg *XportCfg) *UdpXport {
+	ux := &UdpXport{
+		cfg: cfg,
+	}
+	if cfg.TxRate > 0 {
+		ux.limiter = nmxutil.NewRateLimiter(cfg.TxRate, cfg.TxBurst)
+	}
+
+	return ux
 }
 
 func (ux *UdpXport) BuildSesn(cfg sesn.SesnCfg) (sesn.Sesn, error) {
-	return NewUdpSesn(cfg)
+	var s *UdpSesn
+	var err error
+
+	if ux.cfg.SharedSocket {
+		ux.mtx.Lock()
+		shared := ux.shared
+		ux.mtx.Unlock()
+
+		if shared == nil {
+			return nil, nmxutil.NewXportError(
+				"Attempt to build shared UDP session before xport started")
+		}
+		s, err = newUdpSesnShared(cfg, shared)
+	} else {
+		s, err = NewUdpSesn(cfg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.limiter = ux.limiter
+	s.sesnList = &ux.sesnList
+	return s, nil
+}
+
+// Sessions describes each open session built by the transport.
+func (ux *UdpXport) Sessions() []xport.SesnInfo {
+	return ux.sesnList.Infos("udp")
 }
 
 func (ux *UdpXport) Start() error {
+	ux.mtx.Lock()
+	defer ux.mtx.Unlock()
+
 	if ux.started {
 		return nmxutil.NewXportError("UDP xport started twice")
 	}
+
+	if ux.cfg.SharedSocket {
+		sc, err := newSharedConn()
+		if err != nil {
+			return nmxutil.NewXportError(err.Error())
+		}
+		ux.shared = sc
+	}
+
 	ux.started = true
 	return nil
 }
 
 func (ux *UdpXport) Stop() error {
+	ux.mtx.Lock()
+	defer ux.mtx.Unlock()
+
 	if !ux.started {
 		return nmxutil.NewXportError("UDP xport stopped twice")
 	}
+
+	if ux.shared != nil {
+		ux.shared.close()
+		ux.shared = nil
+	}
+
 	ux.started = false
 	return nil
 }
@@ -0,0 +1,121 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package xact
+
+import (
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmxutil"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
+)
+
+// BootInfo holds the boot loader details reported by a device.  Supported
+// is false if the device does not implement the boot loader info command.
+type BootInfo struct {
+	Supported   bool   `json:"supported"`
+	Bootloader  string `json:"bootloader,omitempty"`
+	Mode        int    `json:"mode"`
+	ModeName    string `json:"mode_name,omitempty"`
+	NoDowngrade bool   `json:"no_downgrade"`
+}
+
+type BootInfoCmd struct {
+	CmdBase
+}
+
+func NewBootInfoCmd() *BootInfoCmd {
+	return &BootInfoCmd{
+		CmdBase: NewCmdBase(),
+	}
+}
+
+type BootInfoResult struct {
+	Rc   int
+	Info BootInfo
+}
+
+func newBootInfoResult() *BootInfoResult {
+	return &BootInfoResult{
+		Info: BootInfo{
+			Mode: nmp.BOOT_MODE_UNKNOWN,
+		},
+	}
+}
+
+func (r *BootInfoResult) Status() int {
+	return r.Rc
+}
+
+func (c *BootInfoCmd) query(s sesn.Sesn,
+	q string) (*nmp.BootloaderInfoRsp, error) {
+
+	r := nmp.NewBootloaderInfoReq()
+	r.Query = q
+
+	rsp, err := txReq(s, r.Msg(), &c.CmdBase)
+	if err != nil {
+		return nil, err
+	}
+	return rsp.(*nmp.BootloaderInfoRsp), nil
+}
+
+// Run retrieves the boot loader name and, for MCUboot, its mode.  A device
+// that lacks the command (rc=ENOTSUP or no response) yields a successful
+// result with Info.Supported set to false.
+func (c *BootInfoCmd) Run(s sesn.Sesn) (Result, error) {
+	res := newBootInfoResult()
+
+	rsp, err := c.query(s, "")
+	if err != nil {
+		if nmxutil.IsRspTimeout(err) {
+			return res, nil
+		}
+		return nil, err
+	}
+	if rsp.Rc == nmp.NMP_ERR_ENOTSUP {
+		return res, nil
+	}
+	if rsp.Rc != 0 {
+		res.Rc = rsp.Rc
+		return res, nil
+	}
+
+	res.Info.Supported = true
+	res.Info.Bootloader = rsp.Bootloader
+
+	if rsp.Bootloader != "MCUboot" {
+		return res, nil
+	}
+
+	// The mode query is optional; report what we have if it fails.
+	rsp, err = c.query(s, "mode")
+	if err != nil {
+		if nmxutil.IsRspTimeout(err) {
+			return res, nil
+		}
+		return nil, err
+	}
+	if rsp.Rc == 0 {
+		res.Info.Mode = rsp.Mode
+		res.Info.ModeName = nmp.BootModeToString(rsp.Mode)
+		res.Info.NoDowngrade = rsp.NoDowngrade
+	}
+
+	return res, nil
+}
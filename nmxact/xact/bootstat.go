@@ -0,0 +1,159 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package xact
+
+import (
+	"strconv"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
+)
+
+// Config variables that hold the reason for the last reset and the number of
+// boots.  The boot counter is maintained by Mynewt's sys/reboot package.
+const BOOT_STATS_DFLT_REASON_VAR = "reboot/reason"
+const BOOT_STATS_DFLT_COUNT_VAR = "reboot/reboot_cnt"
+
+// BootStats describes a device's reset history.  Fields are nil if the
+// device doesn't expose them.
+type BootStats struct {
+	ResetReason *string `json:"reset_reason"`
+	BootCount   *int    `json:"boot_count"`
+}
+
+// Reads a single config variable.  nil is returned if the device doesn't
+// have the variable.
+func readOptConfigVar(s sesn.Sesn, name string,
+	c *CmdBase) (*string, int, error) {
+
+	r := nmp.NewConfigReadReq()
+	r.Name = name
+
+	rsp, err := txReq(s, r.Msg(), c)
+	if err != nil {
+		return nil, 0, err
+	}
+	srsp := rsp.(*nmp.ConfigReadRsp)
+
+	switch srsp.Rc {
+	case 0:
+		return &srsp.Val, 0, nil
+	case nmp.NMP_ERR_ENOENT, nmp.NMP_ERR_EINVAL, nmp.NMP_ERR_ENOTSUP:
+		return nil, 0, nil
+	default:
+		return nil, srsp.Rc, nil
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// $read                                                                    //
+//////////////////////////////////////////////////////////////////////////////
+
+type BootStatsReadCmd struct {
+	CmdBase
+	ReasonVar string
+	CountVar  string
+}
+
+func NewBootStatsReadCmd() *BootStatsReadCmd {
+	return &BootStatsReadCmd{
+		CmdBase:   NewCmdBase(),
+		ReasonVar: BOOT_STATS_DFLT_REASON_VAR,
+		CountVar:  BOOT_STATS_DFLT_COUNT_VAR,
+	}
+}
+
+type BootStatsReadResult struct {
+	Rc    int
+	Stats BootStats
+}
+
+func newBootStatsReadResult() *BootStatsReadResult {
+	return &BootStatsReadResult{}
+}
+
+func (r *BootStatsReadResult) Status() int {
+	return r.Rc
+}
+
+func (c *BootStatsReadCmd) Run(s sesn.Sesn) (Result, error) {
+	res := newBootStatsReadResult()
+
+	reason, rc, err := readOptConfigVar(s, c.ReasonVar, &c.CmdBase)
+	if err != nil {
+		return nil, err
+	}
+	if rc != 0 {
+		res.Rc = rc
+		return res, nil
+	}
+	res.Stats.ResetReason = reason
+
+	count, rc, err := readOptConfigVar(s, c.CountVar, &c.CmdBase)
+	if err != nil {
+		return nil, err
+	}
+	if rc != 0 {
+		res.Rc = rc
+		return res, nil
+	}
+	if count != nil {
+		// A value that isn't a number is treated as absent.
+		if n, err := strconv.Atoi(*count); err == nil {
+			res.Stats.BootCount = &n
+		}
+	}
+
+	return res, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// $reset count                                                             //
+//////////////////////////////////////////////////////////////////////////////
+
+// BootCountResetCmd sets the boot counter to zero and persists it.
+type BootCountResetCmd struct {
+	CmdBase
+	CountVar string
+}
+
+func NewBootCountResetCmd() *BootCountResetCmd {
+	return &BootCountResetCmd{
+		CmdBase:  NewCmdBase(),
+		CountVar: BOOT_STATS_DFLT_COUNT_VAR,
+	}
+}
+
+func (c *BootCountResetCmd) Run(s sesn.Sesn) (Result, error) {
+	r := nmp.NewConfigWriteReq()
+	r.Name = c.CountVar
+	r.Val = "0"
+	r.Save = true
+
+	rsp, err := txReq(s, r.Msg(), &c.CmdBase)
+	if err != nil {
+		return nil, err
+	}
+	srsp := rsp.(*nmp.ConfigWriteRsp)
+
+	res := newConfigWriteResult()
+	res.Rsp = srsp
+	return res, nil
+}
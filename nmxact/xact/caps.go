@@ -0,0 +1,140 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package xact
+
+import (
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmxutil"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
+)
+
+// Describes a single side-effect free request used to determine whether a
+// device supports a particular NMP group / command ID.
+type CapsProbe struct {
+	Name   string
+	Group  uint16
+	Id     uint8
+	NewCmd func() Cmd
+}
+
+// Mynewt does not expose an introspection command, so the capability set is
+// inferred by sending these requests and inspecting the responses.
+var CapsProbes = []CapsProbe{
+	{"echo", nmp.NMP_GROUP_DEFAULT, nmp.NMP_ID_DEF_ECHO,
+		func() Cmd { return NewEchoCmd() }},
+	{"taskstat", nmp.NMP_GROUP_DEFAULT, nmp.NMP_ID_DEF_TASKSTAT,
+		func() Cmd { return NewTaskStatCmd() }},
+	{"mpstat", nmp.NMP_GROUP_DEFAULT, nmp.NMP_ID_DEF_MPSTAT,
+		func() Cmd { return NewMempoolStatCmd() }},
+	{"datetime", nmp.NMP_GROUP_DEFAULT, nmp.NMP_ID_DEF_DATETIME_STR,
+		func() Cmd { return NewDateTimeReadCmd() }},
+	{"image state", nmp.NMP_GROUP_IMAGE, nmp.NMP_ID_IMAGE_STATE,
+		func() Cmd { return NewImageStateReadCmd() }},
+	{"image corelist", nmp.NMP_GROUP_IMAGE, nmp.NMP_ID_IMAGE_CORELIST,
+		func() Cmd { return NewCoreListCmd() }},
+	{"stat list", nmp.NMP_GROUP_STAT, nmp.NMP_ID_STAT_LIST,
+		func() Cmd { return NewStatListCmd() }},
+	{"log list", nmp.NMP_GROUP_LOG, nmp.NMP_ID_LOG_LIST,
+		func() Cmd { return NewLogListCmd() }},
+	{"log module_list", nmp.NMP_GROUP_LOG, nmp.NMP_ID_LOG_MODULE_LIST,
+		func() Cmd { return NewLogModuleListCmd() }},
+	{"log level_list", nmp.NMP_GROUP_LOG, nmp.NMP_ID_LOG_LEVEL_LIST,
+		func() Cmd { return NewLogLevelListCmd() }},
+	{"run list", nmp.NMP_GROUP_RUN, nmp.NMP_ID_RUN_LIST,
+		func() Cmd { return NewRunListCmd() }},
+}
+
+type CapsEntry struct {
+	Name      string `json:"name"`
+	Group     uint16 `json:"group"`
+	Id        uint8  `json:"id"`
+	Supported bool   `json:"supported"`
+
+	// Status code reported by the device; -1 if the device did not respond.
+	Rc int `json:"rc"`
+}
+
+type CapsCmd struct {
+	CmdBase
+}
+
+func NewCapsCmd() *CapsCmd {
+	return &CapsCmd{
+		CmdBase: NewCmdBase(),
+	}
+}
+
+type CapsResult struct {
+	Entries []CapsEntry
+}
+
+func newCapsResult() *CapsResult {
+	return &CapsResult{}
+}
+
+func (r *CapsResult) Status() int {
+	return nmp.NMP_ERR_OK
+}
+
+// Indicates whether the probe for the specified group and ID succeeded.
+func (r *CapsResult) Supports(group uint16, id uint8) bool {
+	for _, e := range r.Entries {
+		if e.Group == group && e.Id == id {
+			return e.Supported
+		}
+	}
+
+	return false
+}
+
+func (c *CapsCmd) Run(s sesn.Sesn) (Result, error) {
+	res := newCapsResult()
+
+	for _, p := range CapsProbes {
+		if c.abortErr != nil {
+			return nil, c.abortErr
+		}
+
+		e := CapsEntry{
+			Name:  p.Name,
+			Group: p.Group,
+			Id:    p.Id,
+			Rc:    -1,
+		}
+
+		cmd := p.NewCmd()
+		cmd.SetTxOptions(c.TxOptions())
+
+		pres, err := cmd.Run(s)
+		if err != nil {
+			// A device that doesn't recognize a request may just drop it.
+			if !nmxutil.IsRspTimeout(err) {
+				return nil, err
+			}
+		} else {
+			e.Rc = pres.Status()
+			e.Supported = e.Rc != nmp.NMP_ERR_ENOTSUP
+		}
+
+		res.Entries = append(res.Entries, e)
+	}
+
+	return res, nil
+}
@@ -20,7 +20,10 @@
 package xact
 
 import (
+	"fmt"
+
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmxutil"
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
 )
 
@@ -75,6 +78,9 @@ type ConfigWriteCmd struct {
 	Name string
 	Val  string
 	Save bool
+
+	// If non-nil, written instead of Val, preserving its CBOR type.
+	TypedVal interface{}
 }
 
 func NewConfigWriteCmd() *ConfigWriteCmd {
@@ -100,6 +106,7 @@ func (c *ConfigWriteCmd) Run(s sesn.Sesn) (Result, error) {
 	r.Name = c.Name
 	r.Val = c.Val
 	r.Save = c.Save
+	r.TypedVal = c.TypedVal
 
 	rsp, err := txReq(s, r.Msg(), &c.CmdBase)
 	if err != nil {
@@ -111,3 +118,122 @@ func (c *ConfigWriteCmd) Run(s sesn.Sesn) (Result, error) {
 	res.Rsp = srsp
 	return res, nil
 }
+
+//////////////////////////////////////////////////////////////////////////////
+// $dump                                                                    //
+//////////////////////////////////////////////////////////////////////////////
+
+// ConfigDumpCmd reads every config value from a device.  Pages are requested
+// until the device reports there are no more.  If the device does not
+// support the list command, the values listed in Names are read one at a
+// time instead.
+type ConfigDumpCmd struct {
+	CmdBase
+	Names []string
+}
+
+func NewConfigDumpCmd() *ConfigDumpCmd {
+	return &ConfigDumpCmd{
+		CmdBase: NewCmdBase(),
+	}
+}
+
+type ConfigDumpResult struct {
+	Rc    int
+	Vals  map[string]string
+	Paged bool
+}
+
+func newConfigDumpResult() *ConfigDumpResult {
+	return &ConfigDumpResult{
+		Vals: map[string]string{},
+	}
+}
+
+func (r *ConfigDumpResult) Status() int {
+	return r.Rc
+}
+
+func (c *ConfigDumpCmd) readList(s sesn.Sesn, res *ConfigDumpResult) error {
+	off := 0
+	for {
+		r := nmp.NewConfigListReq()
+		r.Off = off
+
+		rsp, err := txReq(s, r.Msg(), &c.CmdBase)
+		if err != nil {
+			return err
+		}
+		srsp := rsp.(*nmp.ConfigListRsp)
+		if srsp.Rc != 0 {
+			res.Rc = srsp.Rc
+			return nil
+		}
+
+		for k, v := range srsp.Vals {
+			res.Vals[k] = v
+		}
+		res.Paged = true
+
+		if srsp.Next < 0 {
+			return nil
+		}
+		if srsp.Next <= off {
+			return fmt.Errorf("config list offset did not advance: %d -> %d",
+				off, srsp.Next)
+		}
+		off = srsp.Next
+	}
+}
+
+func (c *ConfigDumpCmd) readEach(s sesn.Sesn, res *ConfigDumpResult) error {
+	for _, name := range c.Names {
+		r := nmp.NewConfigReadReq()
+		r.Name = name
+
+		rsp, err := txReq(s, r.Msg(), &c.CmdBase)
+		if err != nil {
+			return err
+		}
+		srsp := rsp.(*nmp.ConfigReadRsp)
+
+		// Skip names the device does not know about.
+		if srsp.Rc == nmp.NMP_ERR_ENOENT {
+			continue
+		}
+		if srsp.Rc != 0 {
+			res.Rc = srsp.Rc
+			return nil
+		}
+		res.Vals[name] = srsp.Val
+	}
+
+	return nil
+}
+
+func (c *ConfigDumpCmd) Run(s sesn.Sesn) (Result, error) {
+	res := newConfigDumpResult()
+
+	err := c.readList(s, res)
+	if err != nil && !(nmxutil.IsRspTimeout(err) && !res.Paged) {
+		return nil, err
+	}
+
+	if err == nil && (res.Rc != nmp.NMP_ERR_ENOTSUP || res.Paged) {
+		return res, nil
+	}
+
+	// The device does not implement the list command; fall back to reading
+	// the requested names individually.
+	if len(c.Names) == 0 {
+		res.Rc = nmp.NMP_ERR_ENOTSUP
+		return res, nil
+	}
+
+	res.Rc = 0
+	if err := c.readEach(s, res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
@@ -0,0 +1,91 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package xact
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
+)
+
+func TestConfigWriteGolden(t *testing.T) {
+	rx, s := newRecordingSesn(t)
+
+	c := NewConfigWriteCmd()
+	c.Name = "id/serial"
+	c.Val = "abc"
+	if _, err := c.Run(s); err == nil {
+		t.Fatalf("recording session returned a response")
+	}
+
+	checkGoldenFrame(t, onlyFrame(t, rx),
+		"0200001800030000"+
+			"a2"+
+			"646e616d65"+"6969642f73657269616c"+
+			"6376616c"+"63616263")
+}
+
+// Typed values are sent in a map, whose key order is not fixed, so each key
+// and value pair is checked on its own.
+func TestConfigWriteTyped(t *testing.T) {
+	tests := []struct {
+		val interface{}
+		enc string
+	}{
+		{int64(7), "07"},
+		{int64(-5), "24"},
+		{int64(1000), "1903e8"},
+		{true, "f5"},
+		{false, "f4"},
+		{[]byte{0xde, 0xad}, "42dead"},
+		{"", "60"},
+	}
+
+	name := "646e616d65" + "6969642f73657269616c"
+	for _, test := range tests {
+		rx, s := newRecordingSesn(t)
+
+		c := NewConfigWriteCmd()
+		c.Name = "id/serial"
+		c.Val = "ignored"
+		c.TypedVal = test.val
+		if _, err := c.Run(s); err == nil {
+			t.Fatalf("recording session returned a response")
+		}
+
+		frame := onlyFrame(t, rx)
+		body := frame[nmp.NMP_HDR_SIZE:]
+		val := "6376616c" + test.enc
+
+		exp, _ := hex.DecodeString("a2" + name + val)
+		if len(body) != len(exp) || body[0] != 0xa2 {
+			t.Errorf("%#v: wrong body: %x", test.val, body)
+			continue
+		}
+		for _, kv := range []string{name, val} {
+			b, _ := hex.DecodeString(kv)
+			if !bytes.Contains(body, b) {
+				t.Errorf("%#v: body %x lacks %s", test.val, body, kv)
+			}
+		}
+	}
+}
@@ -20,6 +20,8 @@
 package xact
 
 import (
+	"time"
+
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
 )
@@ -50,6 +52,29 @@ func (r *DateTimeReadResult) Status() int {
 	return r.Rsp.Rc
 }
 
+// Devices report a date before this year when their clock has never been set.
+const DATETIME_MIN_VALID_YEAR = 2000
+
+// Layouts a device may use for its datetime string; the zone is optional.
+var dateTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+}
+
+// ClockUnset indicates whether the device reported a datetime before
+// DATETIME_MIN_VALID_YEAR, which means its clock was never set after boot.
+// A datetime that can't be parsed is not considered unset.
+func (r *DateTimeReadResult) ClockUnset() bool {
+	for _, layout := range dateTimeLayouts {
+		t, err := time.Parse(layout, r.Rsp.DateTime)
+		if err == nil {
+			return t.Year() < DATETIME_MIN_VALID_YEAR
+		}
+	}
+
+	return false
+}
+
 func (c *DateTimeReadCmd) Run(s sesn.Sesn) (Result, error) {
 	r := nmp.NewDateTimeReadReq()
 
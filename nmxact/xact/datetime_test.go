@@ -0,0 +1,51 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package xact
+
+import (
+	"testing"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
+)
+
+func TestDateTimeClockUnset(t *testing.T) {
+	tests := []struct {
+		dateTime string
+		want     bool
+	}{
+		{"1970-01-01T00:00:12.345678", true},
+		{"1970-01-01T00:00:12Z", true},
+		{"1999-12-31T23:59:59.999999+00:00", true},
+		{"2000-01-01T00:00:00", false},
+		{"2024-05-17T10:21:44.120000-07:00", false},
+		{"garbage", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		r := newDateTimeReadResult()
+		r.Rsp = nmp.NewDateTimeReadRsp()
+		r.Rsp.DateTime = tt.dateTime
+
+		if got := r.ClockUnset(); got != tt.want {
+			t.Errorf("%q: ClockUnset = %v, want %v", tt.dateTime, got, tt.want)
+		}
+	}
+}
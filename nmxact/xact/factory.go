@@ -0,0 +1,241 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package xact
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
+)
+
+// Names of the steps recorded in a FactoryResetResult.
+const FACTORY_STEP_CONFIG = "config clear"
+const FACTORY_STEP_ERASE = "image erase"
+const FACTORY_STEP_RESET = "reset"
+
+type FactoryResetStep struct {
+	Name string
+	Err  error
+
+	// True if the device does not support the step.
+	Skipped bool
+}
+
+// Called as each step of a factory reset completes.
+type FactoryResetStepFn func(step FactoryResetStep)
+
+// FactoryResetCmd returns a device to a clean state:
+//  1. Clear the configuration.  Every value the device lists is written
+//     empty, except those whose names start with one of KeepPrefixes, and
+//     the result is saved.  Devices that cannot list their configuration
+//     have the values named in Names cleared instead.
+//  2. Erase the image slot that is not running.
+//  3. Reset the device.
+//
+// A step the device does not support is skipped rather than failing the
+// whole command.
+type FactoryResetCmd struct {
+	CmdBase
+
+	// Config values to clear if the device cannot list its configuration.
+	Names []string
+
+	// Config values whose names start with any of these are left intact.
+	KeepPrefixes []string
+
+	// If non-nil, called as each step completes.
+	StepCb FactoryResetStepFn
+}
+
+type FactoryResetResult struct {
+	Steps []FactoryResetStep
+
+	// Config values that were cleared and kept, sorted by name.
+	Cleared []string
+	Kept    []string
+
+	// Config values the device refused to clear, with the rc it returned.
+	Failed map[string]int
+}
+
+func NewFactoryResetCmd() *FactoryResetCmd {
+	return &FactoryResetCmd{
+		CmdBase: NewCmdBase(),
+	}
+}
+
+func newFactoryResetResult() *FactoryResetResult {
+	return &FactoryResetResult{
+		Failed: map[string]int{},
+	}
+}
+
+func (r *FactoryResetResult) Status() int {
+	for _, step := range r.Steps {
+		if step.Err != nil {
+			return nmp.NMP_ERR_EUNKNOWN
+		}
+	}
+
+	return nmp.NMP_ERR_OK
+}
+
+func (c *FactoryResetCmd) addStep(res *FactoryResetResult, name string,
+	skipped bool, err error) error {
+
+	step := FactoryResetStep{
+		Name:    name,
+		Err:     err,
+		Skipped: skipped,
+	}
+	res.Steps = append(res.Steps, step)
+
+	if c.StepCb != nil {
+		c.StepCb(step)
+	}
+
+	if err != nil {
+		return fmt.Errorf("Factory reset failed at %s step: %s",
+			name, err.Error())
+	}
+
+	return nil
+}
+
+func (c *FactoryResetCmd) keep(name string) bool {
+	for _, p := range c.KeepPrefixes {
+		if strings.HasPrefix(name, p) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *FactoryResetCmd) writeConfig(s sesn.Sesn, name string,
+	save bool) (int, error) {
+
+	cmd := NewConfigWriteCmd()
+	cmd.SetTxOptions(c.TxOptions())
+	cmd.Name = name
+	cmd.Save = save
+	if name != "" {
+		// Send the empty value explicitly; an empty Val is omitted.
+		cmd.TypedVal = ""
+	}
+
+	res, err := cmd.Run(s)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.Status(), nil
+}
+
+// Clears the configuration.  The returned bool is true if the device does
+// not support listing or reading it.
+func (c *FactoryResetCmd) clearConfig(s sesn.Sesn,
+	res *FactoryResetResult) (bool, error) {
+
+	dcmd := NewConfigDumpCmd()
+	dcmd.SetTxOptions(c.TxOptions())
+	dcmd.Names = c.Names
+
+	r, err := dcmd.Run(s)
+	if err != nil {
+		return false, err
+	}
+
+	dres := r.(*ConfigDumpResult)
+	if nmp.RcNotSupported(dres.Rc) {
+		return true, nil
+	}
+	if dres.Rc != 0 {
+		return false, fmt.Errorf("config read failed; rc=%d", dres.Rc)
+	}
+
+	names := make([]string, 0, len(dres.Vals))
+	for name, _ := range dres.Vals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if c.keep(name) {
+			res.Kept = append(res.Kept, name)
+			continue
+		}
+
+		rc, err := c.writeConfig(s, name, false)
+		if err != nil {
+			return false, err
+		}
+		if rc != 0 {
+			res.Failed[name] = rc
+		} else {
+			res.Cleared = append(res.Cleared, name)
+		}
+	}
+
+	rc, err := c.writeConfig(s, "", true)
+	if err != nil {
+		return false, err
+	}
+	if rc != 0 {
+		return false, fmt.Errorf("config save failed; rc=%d", rc)
+	}
+
+	return false, nil
+}
+
+func (c *FactoryResetCmd) Run(s sesn.Sesn) (Result, error) {
+	res := newFactoryResetResult()
+
+	skipped, err := c.clearConfig(s, res)
+	if err := c.addStep(res, FACTORY_STEP_CONFIG, skipped, err); err != nil {
+		return res, err
+	}
+
+	ecmd := NewImageEraseCmd()
+	ecmd.SetTxOptions(c.TxOptions())
+	skipped = false
+	er, err := ecmd.Run(s)
+	if err == nil {
+		rc := er.Status()
+		if nmp.RcNotSupported(rc) {
+			skipped = true
+		} else if rc != 0 {
+			err = fmt.Errorf("image erase failed; rc=%d", rc)
+		}
+	}
+	if err := c.addStep(res, FACTORY_STEP_ERASE, skipped, err); err != nil {
+		return res, err
+	}
+
+	err = ResetAndWait(s, c.TxOptions())
+	if err := c.addStep(res, FACTORY_STEP_RESET, false, err); err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
@@ -0,0 +1,288 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package xact
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmxutil"
+)
+
+// factoryDev answers the requests a factory reset sends.
+type factoryDev struct {
+	mtx sync.Mutex
+
+	// Values the device holds.  If listRc is non-zero, the device rejects
+	// the list command with it.
+	vals   map[string]string
+	listRc int
+
+	// rc for writes to individual values; missing names succeed.
+	writeRc map[string]int
+	eraseRc int
+
+	cleared []string
+	saved   bool
+	erased  bool
+	reset   bool
+}
+
+func (d *factoryDev) rsp(m *nmp.NmpMsg) (nmp.NmpRsp, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	var rsp nmp.NmpRsp
+	switch req := m.Body.(type) {
+	case *nmp.ConfigListReq:
+		r := nmp.NewConfigListRsp()
+		r.Rc = d.listRc
+		if r.Rc == 0 {
+			r.Vals = d.vals
+		}
+		rsp = r
+
+	case *nmp.ConfigReadReq:
+		r := nmp.NewConfigReadRsp()
+		if v, ok := d.vals[req.Name]; ok {
+			r.Val = v
+		} else {
+			r.Rc = nmp.NMP_ERR_ENOENT
+		}
+		rsp = r
+
+	case *nmp.ConfigWriteReq:
+		r := nmp.NewConfigWriteRsp()
+		if req.Save {
+			d.saved = true
+		} else if r.Rc = d.writeRc[req.Name]; r.Rc == 0 {
+			d.cleared = append(d.cleared, req.Name)
+		}
+		rsp = r
+
+	case *nmp.ImageEraseReq:
+		r := nmp.NewImageEraseRsp()
+		r.Rc = d.eraseRc
+		d.erased = r.Rc == 0
+		rsp = r
+
+	case *nmp.ResetReq:
+		d.reset = true
+		rsp = nmp.NewResetRsp()
+
+	case *nmp.EchoReq:
+		// The device goes down as soon as it has been reset.
+		return nil, nmxutil.NewRspTimeoutError("device is down")
+
+	default:
+		return nil, fmt.Errorf("unexpected request: %T", m.Body)
+	}
+
+	rsp.SetHdr(fakeRspHdr(m))
+	return rsp, nil
+}
+
+func stepNames(steps []FactoryResetStep) []string {
+	names := []string{}
+	for _, step := range steps {
+		names = append(names, fmt.Sprintf("%s/skipped=%v/err=%v", step.Name,
+			step.Skipped, step.Err != nil))
+	}
+	return names
+}
+
+func TestFactoryResetClearConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		dev     *factoryDev
+		names   []string
+		keep    []string
+		cleared []string
+		kept    []string
+		failed  map[string]int
+		skipped bool
+	}{
+		{
+			name: "clear all",
+			dev: &factoryDev{vals: map[string]string{
+				"id/serial": "1", "lora/key": "abc", "app/mode": "2",
+			}},
+			cleared: []string{"app/mode", "id/serial", "lora/key"},
+			failed:  map[string]int{},
+		},
+		{
+			name: "keep prefixes",
+			dev: &factoryDev{vals: map[string]string{
+				"id/serial": "1", "id/model": "x", "lora/key": "abc",
+				"app/mode": "2",
+			}},
+			keep:    []string{"id/", "lora/k"},
+			cleared: []string{"app/mode"},
+			kept:    []string{"id/model", "id/serial", "lora/key"},
+			failed:  map[string]int{},
+		},
+		{
+			name: "refused value",
+			dev: &factoryDev{
+				vals:    map[string]string{"a/x": "1", "a/y": "2"},
+				writeRc: map[string]int{"a/x": nmp.NMP_ERR_EINVAL},
+			},
+			cleared: []string{"a/y"},
+			failed:  map[string]int{"a/x": nmp.NMP_ERR_EINVAL},
+		},
+		{
+			name: "list unsupported, names given",
+			dev: &factoryDev{
+				vals:   map[string]string{"a/x": "1", "id/serial": "2"},
+				listRc: nmp.NMP_ERR_ENOTSUP,
+			},
+			names:   []string{"a/x", "id/serial", "a/missing"},
+			keep:    []string{"id/"},
+			cleared: []string{"a/x"},
+			kept:    []string{"id/serial"},
+			failed:  map[string]int{},
+		},
+		{
+			name: "list unsupported, no names",
+			dev: &factoryDev{
+				vals:   map[string]string{"a/x": "1"},
+				listRc: nmp.NMP_ERR_ENOTSUP,
+			},
+			failed:  map[string]int{},
+			skipped: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := tt.dev
+			s := newFakeSesn(t, dev.rsp)
+
+			c := NewFactoryResetCmd()
+			c.Names = tt.names
+			c.KeepPrefixes = tt.keep
+
+			res := newFactoryResetResult()
+			skipped, err := c.clearConfig(s, res)
+			if err != nil {
+				t.Fatalf("clearConfig: %v", err)
+			}
+
+			if skipped != tt.skipped {
+				t.Errorf("skipped = %v, want %v", skipped, tt.skipped)
+			}
+			if !reflect.DeepEqual(res.Cleared, tt.cleared) {
+				t.Errorf("Cleared = %v, want %v", res.Cleared, tt.cleared)
+			}
+			if !reflect.DeepEqual(dev.cleared, tt.cleared) {
+				t.Errorf("device cleared %v, want %v", dev.cleared,
+					tt.cleared)
+			}
+			if !reflect.DeepEqual(res.Kept, tt.kept) {
+				t.Errorf("Kept = %v, want %v", res.Kept, tt.kept)
+			}
+			if !reflect.DeepEqual(res.Failed, tt.failed) {
+				t.Errorf("Failed = %v, want %v", res.Failed, tt.failed)
+			}
+			if dev.saved == tt.skipped {
+				t.Errorf("saved = %v, want %v", dev.saved, !tt.skipped)
+			}
+		})
+	}
+}
+
+func TestFactoryResetRun(t *testing.T) {
+	tests := []struct {
+		name    string
+		dev     *factoryDev
+		steps   []string
+		status  int
+		wantErr bool
+	}{
+		{
+			name: "all steps",
+			dev:  &factoryDev{vals: map[string]string{"a/x": "1"}},
+			steps: []string{
+				"config clear/skipped=false/err=false",
+				"image erase/skipped=false/err=false",
+				"reset/skipped=false/err=false",
+			},
+		},
+		{
+			name: "unsupported steps skipped",
+			dev: &factoryDev{
+				listRc:  nmp.NMP_ERR_ENOTSUP,
+				eraseRc: nmp.NMP_ERR_ENOTSUP,
+			},
+			steps: []string{
+				"config clear/skipped=true/err=false",
+				"image erase/skipped=true/err=false",
+				"reset/skipped=false/err=false",
+			},
+		},
+		{
+			name: "erase failure stops",
+			dev:  &factoryDev{eraseRc: nmp.NMP_ERR_ENOMEM},
+			steps: []string{
+				"config clear/skipped=false/err=false",
+				"image erase/skipped=false/err=true",
+			},
+			status:  nmp.NMP_ERR_EUNKNOWN,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := tt.dev
+			s := newFakeSesn(t, dev.rsp)
+
+			var cbSteps []FactoryResetStep
+			c := NewFactoryResetCmd()
+			c.StepCb = func(step FactoryResetStep) {
+				cbSteps = append(cbSteps, step)
+			}
+
+			r, err := c.Run(s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+
+			res := r.(*FactoryResetResult)
+			if got := stepNames(res.Steps); !reflect.DeepEqual(got,
+				tt.steps) {
+
+				t.Errorf("steps = %v, want %v", got, tt.steps)
+			}
+			if !reflect.DeepEqual(cbSteps, res.Steps) {
+				t.Errorf("callback saw %v, result has %v", cbSteps, res.Steps)
+			}
+			if res.Status() != tt.status {
+				t.Errorf("status = %d, want %d", res.Status(), tt.status)
+			}
+			if dev.reset == tt.wantErr {
+				t.Errorf("reset = %v, want %v", dev.reset, !tt.wantErr)
+			}
+		})
+	}
+}
@@ -20,7 +20,9 @@
 package xact
 
 import (
+	"crypto/sha256"
 	"fmt"
+	"io"
 
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/mgmt"
 	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
@@ -35,7 +37,13 @@ type FsDownloadProgressCb func(c *FsDownloadCmd, r *nmp.FsDownloadRsp)
 type FsDownloadCmd struct {
 	CmdBase
 	Name       string
+	Off        int // Offset to start reading at; nonzero when resuming.
 	ProgressCb FsDownloadProgressCb
+
+	// If non-nil, each chunk is written here as it arrives and only the
+	// most recent response is kept in the result, so memory use does not
+	// grow with the size of the file.
+	Writer io.Writer
 }
 
 func NewFsDownloadCmd() *FsDownloadCmd {
@@ -46,6 +54,12 @@ func NewFsDownloadCmd() *FsDownloadCmd {
 
 type FsDownloadResult struct {
 	Rsps []*nmp.FsDownloadRsp
+
+	// Number of bytes received.
+	Len int
+
+	// SHA256 of the bytes received; set once the download completes.
+	Sha256 []byte
 }
 
 func newFsDownloadResult() *FsDownloadResult {
@@ -59,7 +73,8 @@ func (r *FsDownloadResult) Status() int {
 
 func (c *FsDownloadCmd) Run(s sesn.Sesn) (Result, error) {
 	res := newFsDownloadResult()
-	off := 0
+	off := c.Off
+	h := sha256.New()
 
 	for {
 		r := nmp.NewFsDownloadReq()
@@ -71,18 +86,30 @@ func (c *FsDownloadCmd) Run(s sesn.Sesn) (Result, error) {
 			return nil, err
 		}
 		frsp := rsp.(*nmp.FsDownloadRsp)
+		if c.Writer != nil {
+			res.Rsps = res.Rsps[:0]
+		}
 		res.Rsps = append(res.Rsps, frsp)
 
 		if frsp.Rc != 0 {
 			break
 		}
 
+		if c.Writer != nil {
+			if _, err := c.Writer.Write(frsp.Data); err != nil {
+				return nil, err
+			}
+		}
+		h.Write(frsp.Data)
+		res.Len += len(frsp.Data)
+
 		if c.ProgressCb != nil {
 			c.ProgressCb(c, frsp)
 		}
 
 		if len(frsp.Data) == 0 {
 			// Download complete.
+			res.Sha256 = h.Sum(nil)
 			break
 		}
 
@@ -207,3 +234,201 @@ func (c *FsUploadCmd) Run(s sesn.Sesn) (Result, error) {
 
 	return res, nil
 }
+
+//////////////////////////////////////////////////////////////////////////////
+// $stat                                                                    //
+//////////////////////////////////////////////////////////////////////////////
+
+// FsStat describes a remote file.  Mtime is a Unix timestamp and is only
+// meaningful if HasMtime is set.
+type FsStat struct {
+	Name     string `json:"name"`
+	Size     uint32 `json:"size"`
+	HasMtime bool   `json:"-"`
+	Mtime    int64  `json:"mtime,omitempty"`
+}
+
+type FsStatCmd struct {
+	CmdBase
+	Name string
+}
+
+func NewFsStatCmd() *FsStatCmd {
+	return &FsStatCmd{
+		CmdBase: NewCmdBase(),
+	}
+}
+
+type FsStatResult struct {
+	Rc   int
+	Stat FsStat
+}
+
+func newFsStatResult() *FsStatResult {
+	return &FsStatResult{}
+}
+
+func (r *FsStatResult) Status() int {
+	return r.Rc
+}
+
+// statByRead determines a file's size from the first chunk of a download;
+// the device includes the total length in its response to an offset-zero
+// read.
+func (c *FsStatCmd) statByRead(s sesn.Sesn, res *FsStatResult) error {
+	r := nmp.NewFsDownloadReq()
+	r.Name = c.Name
+	r.Off = 0
+
+	rsp, err := txReq(s, r.Msg(), &c.CmdBase)
+	if err != nil {
+		return err
+	}
+	frsp := rsp.(*nmp.FsDownloadRsp)
+
+	res.Rc = frsp.Rc
+	res.Stat.Size = frsp.Len
+	return nil
+}
+
+// Run queries the file status command.  Devices that do not implement it
+// are queried with a read of the start of the file instead.
+func (c *FsStatCmd) Run(s sesn.Sesn) (Result, error) {
+	res := newFsStatResult()
+	res.Stat.Name = c.Name
+
+	r := nmp.NewFsStatReq()
+	r.Name = c.Name
+
+	rsp, err := txReq(s, r.Msg(), &c.CmdBase)
+	if err != nil {
+		return nil, err
+	}
+	srsp := rsp.(*nmp.FsStatRsp)
+
+	if srsp.Rc == nmp.NMP_ERR_ENOTSUP {
+		if err := c.statByRead(s, res); err != nil {
+			return nil, err
+		}
+		return res, nil
+	}
+
+	res.Rc = srsp.Rc
+	res.Stat.Size = srsp.Len
+	if srsp.Mtime != nil {
+		res.Stat.HasMtime = true
+		res.Stat.Mtime = *srsp.Mtime
+	}
+
+	return res, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// $hash                                                                    //
+//////////////////////////////////////////////////////////////////////////////
+
+type FsHashCmd struct {
+	CmdBase
+	Name string
+	Type string
+	Off  int
+	Len  int
+}
+
+func NewFsHashCmd() *FsHashCmd {
+	return &FsHashCmd{
+		CmdBase: NewCmdBase(),
+		Type:    nmp.FS_HASH_SHA256,
+	}
+}
+
+type FsHashResult struct {
+	Rsp *nmp.FsHashRsp
+}
+
+func newFsHashResult() *FsHashResult {
+	return &FsHashResult{}
+}
+
+func (r *FsHashResult) Status() int {
+	return r.Rsp.Rc
+}
+
+func (c *FsHashCmd) Run(s sesn.Sesn) (Result, error) {
+	r := nmp.NewFsHashReq()
+	r.Name = c.Name
+	r.Type = c.Type
+	r.Off = uint32(c.Off)
+	r.Len = uint32(c.Len)
+
+	rsp, err := txReq(s, r.Msg(), &c.CmdBase)
+	if err != nil {
+		return nil, err
+	}
+	srsp := rsp.(*nmp.FsHashRsp)
+
+	res := newFsHashResult()
+	res.Rsp = srsp
+	return res, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// $mounts                                                                  //
+//////////////////////////////////////////////////////////////////////////////
+
+// FsMount describes a file system mounted on a device.  Size and Free are
+// in bytes and are zero if the device does not report them.
+type FsMount struct {
+	Path string `json:"path"`
+	Type string `json:"type"`
+	Size uint64 `json:"size,omitempty"`
+	Free uint64 `json:"free,omitempty"`
+}
+
+// FsMountsCmd lists the device's mounted file systems.  Devices without
+// mount information respond with NMP_ERR_ENOTSUP.
+type FsMountsCmd struct {
+	CmdBase
+}
+
+func NewFsMountsCmd() *FsMountsCmd {
+	return &FsMountsCmd{
+		CmdBase: NewCmdBase(),
+	}
+}
+
+type FsMountsResult struct {
+	Rc     int
+	Mounts []FsMount
+}
+
+func newFsMountsResult() *FsMountsResult {
+	return &FsMountsResult{}
+}
+
+func (r *FsMountsResult) Status() int {
+	return r.Rc
+}
+
+func (c *FsMountsCmd) Run(s sesn.Sesn) (Result, error) {
+	r := nmp.NewFsMountsReq()
+
+	rsp, err := txReq(s, r.Msg(), &c.CmdBase)
+	if err != nil {
+		return nil, err
+	}
+	srsp := rsp.(*nmp.FsMountsRsp)
+
+	res := newFsMountsResult()
+	res.Rc = srsp.Rc
+	for _, m := range srsp.Mounts {
+		res.Mounts = append(res.Mounts, FsMount{
+			Path: m.Path,
+			Type: m.Type,
+			Size: m.Size,
+			Free: m.Free,
+		})
+	}
+
+	return res, nil
+}
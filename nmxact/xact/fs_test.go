@@ -0,0 +1,105 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package xact
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
+)
+
+func TestFsDownload(t *testing.T) {
+	file := make([]byte, 1000)
+	for i := range file {
+		file[i] = byte(i * 13)
+	}
+	const chunkSz = 128
+
+	tests := []struct {
+		name   string
+		off    int
+		stream bool
+	}{
+		{"buffered", 0, false},
+		{"streamed", 0, true},
+		{"resumed", 300, true},
+	}
+
+	for _, tt := range tests {
+		s := newFakeSesn(t, func(m *nmp.NmpMsg) (nmp.NmpRsp, error) {
+			req := m.Body.(*nmp.FsDownloadReq)
+
+			end := int(req.Off) + chunkSz
+			if end > len(file) {
+				end = len(file)
+			}
+
+			rsp := nmp.NewFsDownloadRsp()
+			rsp.SetHdr(fakeRspHdr(m))
+			rsp.Off = req.Off
+			rsp.Len = uint32(len(file))
+			rsp.Data = file[req.Off:end]
+			return rsp, nil
+		})
+
+		var out bytes.Buffer
+		c := NewFsDownloadCmd()
+		c.Name = "/cfg/run"
+		c.Off = tt.off
+		if tt.stream {
+			c.Writer = &out
+		}
+
+		res, err := c.Run(s)
+		if err != nil {
+			t.Fatalf("%s: download failed: %v", tt.name, err)
+		}
+		fres := res.(*FsDownloadResult)
+
+		want := file[tt.off:]
+		sum := sha256.Sum256(want)
+		if fres.Len != len(want) || !bytes.Equal(fres.Sha256, sum[:]) {
+			t.Errorf("%s: len=%d sha256=%x, want len=%d sha256=%x",
+				tt.name, fres.Len, fres.Sha256, len(want), sum)
+		}
+
+		if tt.stream {
+			if !bytes.Equal(out.Bytes(), want) {
+				t.Errorf("%s: wrote %d bytes, want %d", tt.name, out.Len(),
+					len(want))
+			}
+			if len(fres.Rsps) != 1 {
+				t.Errorf("%s: kept %d responses, want 1", tt.name,
+					len(fres.Rsps))
+			}
+		} else {
+			var got []byte
+			for _, rsp := range fres.Rsps {
+				got = append(got, rsp.Data...)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s: responses hold %d bytes, want %d", tt.name,
+					len(got), len(want))
+			}
+		}
+	}
+}
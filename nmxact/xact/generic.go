@@ -0,0 +1,77 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package xact
+
+import (
+	"fmt"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
+)
+
+// GenericCmd sends a request whose group, id, and body are chosen at run
+// time.  The response must have been registered with nmp.RegisterGenericRsp.
+type GenericCmd struct {
+	CmdBase
+	Op     uint8
+	Group  uint16
+	Id     uint8
+	Fields map[string]interface{}
+}
+
+func NewGenericCmd() *GenericCmd {
+	return &GenericCmd{
+		CmdBase: NewCmdBase(),
+		Op:      nmp.NMP_OP_READ,
+	}
+}
+
+type GenericResult struct {
+	Rsp *nmp.GenericRsp
+}
+
+func newGenericResult() *GenericResult {
+	return &GenericResult{}
+}
+
+func (r *GenericResult) Status() int {
+	return r.Rsp.Rc()
+}
+
+func (c *GenericCmd) Run(s sesn.Sesn) (Result, error) {
+	r := nmp.NewGenericReq(c.Op, c.Group, c.Id)
+	for k, v := range c.Fields {
+		r.Fields[k] = v
+	}
+
+	rsp, err := txReq(s, r.Msg(), &c.CmdBase)
+	if err != nil {
+		return nil, err
+	}
+	grsp, ok := rsp.(*nmp.GenericRsp)
+	if !ok {
+		return nil, fmt.Errorf("unexpected response type for group=%d "+
+			"id=%d: %T", c.Group, c.Id, rsp)
+	}
+
+	res := newGenericResult()
+	res.Rsp = grsp
+	return res, nil
+}
@@ -0,0 +1,100 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package xact
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
+)
+
+// Config variable that holds the hardware id.  Mynewt's sys/id package
+// exports it under this name.
+const HWID_DFLT_VAR = "id/hwid"
+
+// Converts a hardware id config value to bytes.  Firmware reports the id
+// either as hex, optionally with separators, or base64-encoded.  A value in
+// neither form is taken as raw bytes.
+func parseHwId(val string) []byte {
+	h := strings.NewReplacer(":", "", "-", "", " ", "").Replace(val)
+	h = strings.TrimPrefix(strings.ToLower(h), "0x")
+	if b, err := hex.DecodeString(h); err == nil && len(b) > 0 {
+		return b
+	}
+
+	if b, err := base64.StdEncoding.DecodeString(val); err == nil &&
+		len(b) > 0 {
+
+		return b
+	}
+
+	return []byte(val)
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// $read                                                                    //
+//////////////////////////////////////////////////////////////////////////////
+
+type HwIdReadCmd struct {
+	CmdBase
+	Var string
+}
+
+func NewHwIdReadCmd() *HwIdReadCmd {
+	return &HwIdReadCmd{
+		CmdBase: NewCmdBase(),
+		Var:     HWID_DFLT_VAR,
+	}
+}
+
+// HwIdReadResult holds a device's unique hardware id.  HwId is nil if the
+// device doesn't expose one.
+type HwIdReadResult struct {
+	Rc   int
+	HwId []byte
+}
+
+func newHwIdReadResult() *HwIdReadResult {
+	return &HwIdReadResult{}
+}
+
+func (r *HwIdReadResult) Status() int {
+	return r.Rc
+}
+
+func (c *HwIdReadCmd) Run(s sesn.Sesn) (Result, error) {
+	res := newHwIdReadResult()
+
+	val, rc, err := readOptConfigVar(s, c.Var, &c.CmdBase)
+	if err != nil {
+		return nil, err
+	}
+	if rc != 0 {
+		res.Rc = rc
+		return res, nil
+	}
+	if val != nil && *val != "" {
+		res.HwId = parseHwId(*val)
+	}
+
+	return res, nil
+}
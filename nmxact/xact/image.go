@@ -22,6 +22,9 @@ package xact
 import (
 	"crypto/sha256"
 	"fmt"
+	"io"
+	"os"
+	"strconv"
 
 	pb "gopkg.in/cheggaaa/pb.v1"
 
@@ -43,9 +46,14 @@ const IMAGE_UPLOAD_STATUS_MISSED = -1
 const IMAGE_UPLOAD_CHUNK_MISSED_WM = -1
 const IMAGE_UPLOAD_START_WS = 1
 const IMAGE_UPLOAD_DEF_MAX_WS = 5
+const IMAGE_UPLOAD_DEF_CHUNK_RETRIES = 3
 const IMAGE_UPLOAD_STATUS_EXPECTED = 0
 const IMAGE_UPLOAD_STATUS_RQ = 1
 
+// Let the device choose the slot to upload into.
+const IMAGE_UPLOAD_SLOT_DEFAULT = -1
+const IMAGE_UPLOAD_SLOT_MAX = 255
+
 type ImageUploadProgressFn func(c *ImageUploadCmd, r *nmp.ImageUploadRsp)
 type ImageUploadCmd struct {
 	CmdBase
@@ -54,7 +62,17 @@ type ImageUploadCmd struct {
 	Upgrade    bool
 	ProgressCb ImageUploadProgressFn
 	ImageNum   int
+	Slot       int
 	MaxWinSz   int
+
+	// Number of times a chunk is resent after a failed transmit or a missing
+	// response before the upload is abandoned.
+	ChunkRetries int
+
+	// If set, a zero-length chunk at the end of the image is sent once all
+	// data has been acknowledged.  Some firmware needs it to commit the
+	// final partial flash write.
+	Flush bool
 }
 
 type ImageUploadIntTracker struct {
@@ -65,16 +83,43 @@ type ImageUploadIntTracker struct {
 	WCap     int
 	Off      int
 	MaxRxOff int32
+
+	// Failure count of each chunk, keyed by offset.
+	Failures map[int]int
+	// Set when a chunk runs out of retries.
+	Err error
+
+	// The offset each request's chunk ends at, i.e., the offset the device
+	// should acknowledge, keyed by NMP sequence number.  Used to describe
+	// malformed responses.
+	seqEnds map[uint8]int
 }
 
 type ImageUploadResult struct {
 	Rsps []*nmp.ImageUploadRsp
+
+	// Number of chunks that were resent after a failure.
+	RetriedChunks int
 }
 
 func NewImageUploadCmd() *ImageUploadCmd {
 	return &ImageUploadCmd{
-		CmdBase: NewCmdBase(),
+		CmdBase:      NewCmdBase(),
+		Slot:         IMAGE_UPLOAD_SLOT_DEFAULT,
+		ChunkRetries: IMAGE_UPLOAD_DEF_CHUNK_RETRIES,
+	}
+}
+
+// validateUploadSlot checks that slot is either IMAGE_UPLOAD_SLOT_DEFAULT or
+// a slot number that can be encoded in an upload request.
+func validateUploadSlot(slot int) error {
+	if slot == IMAGE_UPLOAD_SLOT_DEFAULT {
+		return nil
 	}
+	if slot < 0 || slot > IMAGE_UPLOAD_SLOT_MAX {
+		return fmt.Errorf("Invalid image slot: %d", slot)
+	}
+	return nil
 }
 
 func newImageUploadResult() *ImageUploadResult {
@@ -90,7 +135,7 @@ func (r *ImageUploadResult) Status() int {
 }
 
 func buildImageUploadReq(imageSz int, hash []byte, upgrade bool, chunk []byte,
-	off int, imageNum int, seq uint8) *nmp.ImageUploadReq {
+	off int, imageNum int, slot int, seq uint8) *nmp.ImageUploadReq {
 
 	r := nmp.NewImageUploadReqWithSeq(seq)
 
@@ -98,6 +143,10 @@ func buildImageUploadReq(imageSz int, hash []byte, upgrade bool, chunk []byte,
 		r.Len = uint32(imageSz)
 		r.DataSha = hash
 		r.Upgrade = upgrade
+		if slot != IMAGE_UPLOAD_SLOT_DEFAULT {
+			slot8 := uint8(slot)
+			r.Slot = &slot8
+		}
 	}
 	r.Off = uint32(off)
 	r.Data = chunk
@@ -114,10 +163,10 @@ func min(a, b int) int {
 }
 
 func encodeUploadReq(s sesn.Sesn, hash []byte, upgrade bool, data []byte,
-	off int, chunklen int, imageNum int, seq uint8) ([]byte, error) {
+	off int, chunklen int, imageNum int, slot int, seq uint8) ([]byte, error) {
 
 	r := buildImageUploadReq(len(data), hash, upgrade, data[off:off+chunklen],
-		off, imageNum, seq)
+		off, imageNum, slot, seq)
 	enc, err := mgmt.EncodeMgmt(s, r.Msg())
 	if err != nil {
 		return nil, err
@@ -127,7 +176,7 @@ func encodeUploadReq(s sesn.Sesn, hash []byte, upgrade bool, data []byte,
 }
 
 func findChunkLen(s sesn.Sesn, hash []byte, upgrade bool, data []byte,
-	off int, imageNum int, seq uint8) (int, error) {
+	off int, imageNum int, slot int, seq uint8) (int, error) {
 
 	// Let's start by encoding max allowed chunk len and we will see how many
 	// bytes we need to cut
@@ -135,7 +184,8 @@ func findChunkLen(s sesn.Sesn, hash []byte, upgrade bool, data []byte,
 
 	// Keep reducing the chunk size until the request fits the MTU.
 	for {
-		enc, err := encodeUploadReq(s, hash, upgrade, data, off, chunklen, imageNum, seq)
+		enc, err := encodeUploadReq(s, hash, upgrade, data, off, chunklen, imageNum,
+			slot, seq)
 		if err != nil {
 			return 0, err
 		}
@@ -157,7 +207,8 @@ func findChunkLen(s sesn.Sesn, hash []byte, upgrade bool, data []byte,
 	return chunklen, nil
 }
 
-func nextImageUploadReq(s sesn.Sesn, upgrade bool, data []byte, off int, imageNum int) (
+func nextImageUploadReq(s sesn.Sesn, upgrade bool, data []byte, off int,
+	imageNum int, slot int) (
 	*nmp.ImageUploadReq, error) {
 	var hash []byte = nil
 
@@ -178,7 +229,8 @@ func nextImageUploadReq(s sesn.Sesn, upgrade bool, data []byte, off int, imageNu
 	seq := nmxutil.NextNmpSeq()
 
 	// Find chunk length
-	chunklen, err := findChunkLen(s, hash, upgrade, data, off, imageNum, seq)
+	chunklen, err := findChunkLen(s, hash, upgrade, data, off, imageNum,
+		slot, seq)
 	if err != nil {
 		return nil, err
 	}
@@ -187,7 +239,8 @@ func nextImageUploadReq(s sesn.Sesn, upgrade bool, data []byte, off int, imageNu
 	// fit we'll recalculate without hash
 	if off == 0 && chunklen < IMAGE_UPLOAD_MIN_1ST_CHUNK {
 		hash = nil
-		chunklen, err = findChunkLen(s, hash, upgrade, data, off, imageNum, seq)
+		chunklen, err = findChunkLen(s, hash, upgrade, data, off, imageNum,
+			slot, seq)
 		if err != nil {
 			return nil, err
 		}
@@ -202,7 +255,7 @@ func nextImageUploadReq(s sesn.Sesn, upgrade bool, data []byte, off int, imageNu
 	}
 
 	r := buildImageUploadReq(len(data), hash, upgrade,
-		data[off:off+chunklen], off, imageNum, seq)
+		data[off:off+chunklen], off, imageNum, slot, seq)
 
 	// Request above should encode just fine since we calculate proper chunk
 	// length but (at least for now) let's double check it
@@ -273,7 +326,7 @@ func (t *ImageUploadIntTracker) HandleResponse(c *ImageUploadCmd, rsp nmp.NmpRsp
 		t.UpdateTracker(int(irsp.Off), IMAGE_UPLOAD_STATUS_RQ)
 
 		if t.MaxRxOff < int32(irsp.Off) {
-			t.MaxRxOff = int32(irsp.Off)
+			atomic.StoreInt32(&t.MaxRxOff, int32(irsp.Off))
 		}
 		if c.ProgressCb != nil {
 			c.ProgressCb(c, irsp)
@@ -297,7 +350,53 @@ func (t *ImageUploadIntTracker) HandleResponse(c *ImageUploadCmd, rsp nmp.NmpRsp
 	}
 }
 
-func (t *ImageUploadIntTracker) HandleError(off int, err error) bool {
+// malformedRspError describes an upload response that could not be used,
+// including what the device sent and the offset it should have acknowledged.
+func (t *ImageUploadIntTracker) malformedRspError(seq uint8,
+	fields map[string]interface{}, cause error) error {
+
+	t.Mutex.Lock()
+	end, ok := t.seqEnds[seq]
+	t.Mutex.Unlock()
+
+	expected := "unknown"
+	if ok {
+		expected = strconv.Itoa(end)
+	}
+	received := "missing"
+	if off, ok := fields["off"]; ok {
+		received = fmt.Sprintf("%v", off)
+	}
+
+	return fmt.Errorf("Malformed image upload response (seq=%d): %s; "+
+		"expected off=%s, received off=%s; fields=%v",
+		seq, cause.Error(), expected, received, fields)
+}
+
+// chunkFailed records a failed attempt to send the chunk at off and schedules
+// it to be resent.  If the chunk has no retries left, t.Err is set and false
+// is returned.  The caller must hold t.Mutex.
+func (t *ImageUploadIntTracker) chunkFailed(c *ImageUploadCmd, off int,
+	err error, res *ImageUploadResult) bool {
+
+	t.Failures[off]++
+	if t.Failures[off] > c.ChunkRetries {
+		t.Err = fmt.Errorf("Image upload chunk at offset %d failed %d times: %s",
+			off, t.Failures[off], err.Error())
+		return false
+	}
+
+	res.RetriedChunks++
+
+	// Resend the chunk right away rather than waiting for the device to ask
+	// for it again.
+	t.RspMap[off] = IMAGE_UPLOAD_CHUNK_MISSED_WM - 1
+	return true
+}
+
+func (t *ImageUploadIntTracker) HandleError(c *ImageUploadCmd, off int,
+	err error, res *ImageUploadResult) bool {
+
 	/*XXX: there could be an Unauthorize or EOF error  when the rate is too high
 	  due to a large window, we retry. example:
 	  "failed to decrypt message: coap_sec_tunnel: decode GCM fail EOF"
@@ -317,6 +416,11 @@ func (t *ImageUploadIntTracker) HandleError(off int, err error) bool {
 	t.TuneWS = false
 	t.WCount -= 1
 	t.UpdateTracker(off, IMAGE_UPLOAD_STATUS_MISSED)
+	if !t.chunkFailed(c, off, err, res) {
+		// Out of retries.  Wake the main loop if it is waiting for a window
+		// slot so that it sees t.Err and aborts the upload.
+		return wFull
+	}
 
 	// Indicate transition from window being full to with open slot(s)
 	if wFull && t.WCap > t.WCount {
@@ -327,6 +431,10 @@ func (t *ImageUploadIntTracker) HandleError(off int, err error) bool {
 }
 
 func (c *ImageUploadCmd) Run(s sesn.Sesn) (Result, error) {
+	if err := validateUploadSlot(c.Slot); err != nil {
+		return nil, err
+	}
+
 	res := newImageUploadResult()
 	ch := make(chan int)
 	rspc := make(chan nmp.NmpRsp, c.MaxWinSz)
@@ -339,6 +447,8 @@ func (c *ImageUploadCmd) Run(s sesn.Sesn) (Result, error) {
 		Off:      c.StartOff,
 		RspMap:   make(map[int]int),
 		MaxRxOff: 0,
+		Failures: make(map[int]int),
+		seqEnds:  make(map[uint8]int),
 	}
 
 	for int(atomic.LoadInt32(&t.MaxRxOff)) < len(c.Data) {
@@ -349,26 +459,40 @@ func (c *ImageUploadCmd) Run(s sesn.Sesn) (Result, error) {
 
 		t.ProcessMissedChunks()
 
+		t.Mutex.Lock()
+		if t.Err != nil {
+			t.Mutex.Unlock()
+			return nil, t.Err
+		}
+		t.Mutex.Unlock()
+
 		if t.Off == len(c.Data) {
 			continue
 		}
 
 		t.Mutex.Lock()
-		r, err := nextImageUploadReq(s, c.Upgrade, c.Data, t.Off, c.ImageNum)
+		r, err := nextImageUploadReq(s, c.Upgrade, c.Data, t.Off, c.ImageNum,
+			c.Slot)
 		if err != nil {
 			t.Mutex.Unlock()
 			return nil, err
 		}
 
 		t.Off = (int(r.Off) + len(r.Data))
+		t.seqEnds[r.Hdr().Seq] = t.Off
 
 		// Use up a chunk in window
 		t.WCount += 1
 		err = txReqAsync(s, r.Msg(), &c.CmdBase, rspc, errc)
 		if err != nil {
 			log.Debugf("err txReqAsync %v", err)
+			t.WCount -= 1
+			if !t.chunkFailed(c, int(r.Off), err, res) {
+				t.Mutex.Unlock()
+				return nil, t.Err
+			}
 			t.Mutex.Unlock()
-			break
+			continue
 		}
 		// Mark the expected offset in successful tx of this chunk. i.e off + len
 		t.UpdateTracker(int(r.Off)+len(r.Data), IMAGE_UPLOAD_STATUS_EXPECTED)
@@ -377,12 +501,31 @@ func (c *ImageUploadCmd) Run(s sesn.Sesn) (Result, error) {
 		go func(off int) {
 			select {
 			case err := <-errc:
-				sig := t.HandleError(off, err)
+				if derr, ok := err.(*nmp.RspDecodeError); ok {
+					err = t.malformedRspError(derr.Hdr.Seq, derr.Fields,
+						derr.Err)
+				}
+				sig := t.HandleError(c, off, err, res)
 				if sig {
 					<-ch
 				}
 				return
 			case rsp := <-rspc:
+				irsp := rsp.(*nmp.ImageUploadRsp)
+				if irsp.Rc == 0 && int(irsp.Off) > len(c.Data) {
+					err := t.malformedRspError(irsp.Hdr().Seq,
+						map[string]interface{}{
+							"rc":  irsp.Rc,
+							"off": irsp.Off,
+						},
+						fmt.Errorf("offset beyond end of %d-byte image",
+							len(c.Data)))
+					sig := t.HandleError(c, off, err, res)
+					if sig {
+						<-ch
+					}
+					return
+				}
 				sig := t.HandleResponse(c, rsp, res)
 				if sig {
 					<-ch
@@ -392,7 +535,14 @@ func (c *ImageUploadCmd) Run(s sesn.Sesn) (Result, error) {
 		}(int(r.Off))
 	}
 
-	if int(t.MaxRxOff) == len(c.Data) {
+	if int(atomic.LoadInt32(&t.MaxRxOff)) == len(c.Data) {
+		if c.Flush {
+			err := flushImageUpload(s, &c.CmdBase, len(c.Data), c.Upgrade,
+				c.ImageNum, c.Slot, res)
+			if err != nil {
+				return nil, err
+			}
+		}
 		return res, nil
 	} else {
 		return nil, fmt.Errorf("ImageUpload unexpected error after %d/%d bytes",
@@ -400,6 +550,39 @@ func (c *ImageUploadCmd) Run(s sesn.Sesn) (Result, error) {
 	}
 }
 
+// flushImageUpload sends the zero-length chunk that marks the end of an
+// upload and waits for the device to acknowledge it.  Firmware that doesn't
+// expect the marker rejects it with EINVAL / ENOTSUP or ignores it; either
+// way, the upload is considered complete.  Any other rejection is recorded in
+// res so that it becomes the result's status.
+func flushImageUpload(s sesn.Sesn, cb *CmdBase, imageSz int, upgrade bool,
+	imageNum int, slot int, res *ImageUploadResult) error {
+
+	r := buildImageUploadReq(imageSz, nil, upgrade, []byte{}, imageSz,
+		imageNum, slot, nmxutil.NextNmpSeq())
+
+	rsp, err := txReq(s, r.Msg(), cb)
+	if err != nil {
+		if nmxutil.IsRspTimeout(err) {
+			log.Debugf("No response to image upload flush; " +
+				"assuming the device doesn't need one")
+			return nil
+		}
+		return err
+	}
+	irsp := rsp.(*nmp.ImageUploadRsp)
+
+	switch irsp.Rc {
+	case nmp.NMP_ERR_EINVAL, nmp.NMP_ERR_ENOTSUP:
+		log.Debugf("Device rejected image upload flush (rc=%d); "+
+			"assuming it doesn't need one", irsp.Rc)
+	default:
+		res.Rsps = append(res.Rsps, irsp)
+	}
+
+	return nil
+}
+
 //////////////////////////////////////////////////////////////////////////////
 // $upgrade                                                                 //
 //////////////////////////////////////////////////////////////////////////////
@@ -428,6 +611,31 @@ type ImageUpgradeCmd struct {
 	ProgressBar *pb.ProgressBar
 	ImageNum    int
 	MaxWinSz    int
+
+	// Number of times a single chunk is resent before the upload fails.
+	ChunkRetries int
+
+	// Slot to upload into, or IMAGE_UPLOAD_SLOT_DEFAULT to let the device
+	// choose.  Only honored by firmware that supports explicit slots.  The
+	// separate erase step is skipped for an explicit slot.
+	Slot int
+
+	// If set, the upload is refused with a DowngradeError when the image's
+	// header version is lower than that of the device's confirmed image.
+	DenyDowngrade bool
+
+	// If non-zero, continue an earlier upload of the same image from this
+	// offset.  The device must confirm that it has staged exactly this many
+	// bytes; otherwise the upload starts over from the beginning.  The erase
+	// step is skipped when resuming.
+	ResumeOff int
+
+	// See ImageUploadCmd.Flush.
+	Flush bool
+
+	// If non-nil, receives a progress event for each phase of the upgrade
+	// and for every upload response that advances the offset.
+	ProgressEventCb ProgressEventFn
 }
 
 type ImageUpgradeResult struct {
@@ -437,10 +645,56 @@ type ImageUpgradeResult struct {
 
 func NewImageUpgradeCmd() *ImageUpgradeCmd {
 	return &ImageUpgradeCmd{
-		CmdBase:  NewCmdBase(),
-		NoErase:  false,
-		ImageNum: 0,
+		CmdBase:      NewCmdBase(),
+		NoErase:      false,
+		ImageNum:     0,
+		Slot:         IMAGE_UPLOAD_SLOT_DEFAULT,
+		ChunkRetries: IMAGE_UPLOAD_DEF_CHUNK_RETRIES,
+	}
+}
+
+// NewImageUpgradeCmdReader creates an upgrade command whose image is read
+// from r.  size is the image's total length; reading fewer or more bytes is
+// an error.  The whole image is buffered, since the first upload request
+// carries the image hash and lost chunks may be resent from any offset.
+func NewImageUpgradeCmdReader(r io.Reader, size int) (*ImageUpgradeCmd, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("Invalid image size: %d", size)
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, fmt.Errorf("Failed to read %d-byte image: %s", size,
+			err.Error())
+	}
+
+	// Ensure the reader didn't have more to give.
+	var extra [1]byte
+	if n, _ := r.Read(extra[:]); n != 0 {
+		return nil, fmt.Errorf("Image is larger than specified size (%d)",
+			size)
+	}
+
+	c := NewImageUpgradeCmd()
+	c.Data = data
+	return c, nil
+}
+
+// NewImageUpgradeCmdFile creates an upgrade command for the image file at
+// path.
+func NewImageUpgradeCmdFile(path string) (*ImageUpgradeCmd, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
 	}
+
+	return NewImageUpgradeCmdReader(f, int(fi.Size()))
 }
 
 func newImageUpgradeResult() *ImageUpgradeResult {
@@ -488,13 +742,58 @@ func (c *ImageUpgradeCmd) runErase(s sesn.Sesn) (*ImageEraseResult, error) {
 	return res.(*ImageEraseResult), nil
 }
 
-func (c *ImageUpgradeCmd) runUpload(s sesn.Sesn) (*ImageUploadResult, error) {
-	startOff := 0
+// verifyResume sends the chunk at c.ResumeOff.  The device only accepts it if
+// it has staged exactly that many bytes.  The offset to continue from is
+// returned along with the device's response, or 0 if the upload has to start
+// over.
+func (c *ImageUpgradeCmd) verifyResume(
+	s sesn.Sesn) (int, *nmp.ImageUploadRsp, error) {
+
+	if c.ResumeOff < 0 || c.ResumeOff >= len(c.Data) {
+		return 0, nil, nil
+	}
+
+	r, err := nextImageUploadReq(s, c.Upgrade, c.Data, c.ResumeOff,
+		c.ImageNum, c.Slot)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	rsp, err := txReq(s, r.Msg(), &c.CmdBase)
+	if err != nil {
+		return 0, nil, err
+	}
+	irsp := rsp.(*nmp.ImageUploadRsp)
+
+	want := c.ResumeOff + len(r.Data)
+	if irsp.Rc != 0 || int(irsp.Off) != want {
+		log.Debugf("Can't resume image upload at %d: rc=%d off=%d",
+			c.ResumeOff, irsp.Rc, irsp.Off)
+		return 0, nil, nil
+	}
+
+	return want, irsp, nil
+}
+
+func (c *ImageUpgradeCmd) runUpload(s sesn.Sesn,
+	startOff int) (*ImageUploadResult, error) {
+
+	emitProgress(c.ProgressEventCb, PROGRESS_PHASE_UPLOAD, startOff,
+		len(c.Data), "")
+	maxOff := startOff
+
 	progressCb := func(uc *ImageUploadCmd, r *nmp.ImageUploadRsp) {
 		if r.Rc == 0 {
 			startOff = int(r.Off)
+			if startOff > maxOff {
+				maxOff = startOff
+				emitProgress(c.ProgressEventCb, PROGRESS_PHASE_UPLOAD,
+					maxOff, len(c.Data), "")
+			}
+		}
+		if c.ProgressCb != nil {
+			c.ProgressCb(uc, r)
 		}
-		c.ProgressCb(uc, r)
 	}
 
 	for {
@@ -508,8 +807,11 @@ func (c *ImageUpgradeCmd) runUpload(s sesn.Sesn) (*ImageUploadResult, error) {
 		cmd.Upgrade = c.Upgrade
 		cmd.ProgressCb = progressCb
 		cmd.ImageNum = c.ImageNum
+		cmd.Slot = c.Slot
 		cmd.SetTxOptions(opt)
 		cmd.MaxWinSz = c.MaxWinSz
+		cmd.ChunkRetries = c.ChunkRetries
+		cmd.Flush = c.Flush
 
 		res, err := cmd.Run(s)
 		if err == nil {
@@ -529,7 +831,37 @@ func (c *ImageUpgradeCmd) Run(s sesn.Sesn) (Result, error) {
 	var eres *ImageEraseResult = nil
 	var err error
 
-	if c.NoErase == false {
+	if err := validateUploadSlot(c.Slot); err != nil {
+		return nil, err
+	}
+
+	if c.DenyDowngrade {
+		err = checkDowngrade(s, c.Data, c.ImageNum, c.TxOptions())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	startOff := 0
+	var resumeRsp *nmp.ImageUploadRsp
+	if c.ResumeOff > 0 {
+		emitProgress(c.ProgressEventCb, PROGRESS_PHASE_RESUME, 0, 0,
+			fmt.Sprintf("Checking for a partial upload at offset %d",
+				c.ResumeOff))
+		startOff, resumeRsp, err = c.verifyResume(s)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// The erase command has no slot argument and always erases the default
+	// secondary slot, so it must not precede an upload to an explicit slot.
+	// The device erases the target slot as the upload begins.
+	if c.NoErase == false && startOff == 0 &&
+		c.Slot == IMAGE_UPLOAD_SLOT_DEFAULT {
+
+		emitProgress(c.ProgressEventCb, PROGRESS_PHASE_ERASE, 0, 0,
+			"Erasing image slot")
 		eres, err = c.runErase(s)
 		if err != nil {
 			return nil, err
@@ -537,9 +869,26 @@ func (c *ImageUpgradeCmd) Run(s sesn.Sesn) (Result, error) {
 	} else {
 		eres = nil
 	}
-	ures, err := c.runUpload(s)
-	if err != nil {
-		return nil, err
+
+	var ures *ImageUploadResult
+	if resumeRsp != nil && startOff == len(c.Data) {
+		// The chunk sent to verify the resume completed the upload.
+		ures = newImageUploadResult()
+		ures.Rsps = append(ures.Rsps, resumeRsp)
+		if c.Flush {
+			err := flushImageUpload(s, &c.CmdBase, len(c.Data), c.Upgrade,
+				c.ImageNum, c.Slot, ures)
+			if err != nil {
+				return nil, err
+			}
+		}
+		emitProgress(c.ProgressEventCb, PROGRESS_PHASE_UPLOAD, startOff,
+			len(c.Data), "")
+	} else {
+		ures, err = c.runUpload(s, startOff)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	upgradeRes := newImageUpgradeResult()
@@ -554,6 +903,12 @@ func (c *ImageUpgradeCmd) Run(s sesn.Sesn) (Result, error) {
 
 type ImageStateReadCmd struct {
 	CmdBase
+
+	// If set and the session drops during the exchange (e.g., because the
+	// device reset), the session is reopened and the read is retried once.
+	// Only transports that report a lost connection, such as BLE, can detect
+	// this; on others the read simply times out.
+	Reconnect bool
 }
 
 type ImageStateReadResult struct {
@@ -574,10 +929,28 @@ func (r *ImageStateReadResult) Status() int {
 	return r.Rsp.Rc
 }
 
+// Indicates whether err means the session was lost rather than the request
+// merely timing out.
+func sesnDropped(s sesn.Sesn, err error) bool {
+	return nmxutil.IsBleSesnDisconnect(err) || !s.IsOpen()
+}
+
 func (c *ImageStateReadCmd) Run(s sesn.Sesn) (Result, error) {
 	r := nmp.NewImageStateReadReq()
 
 	rsp, err := txReq(s, r.Msg(), &c.CmdBase)
+	if err != nil && c.Reconnect && sesnDropped(s, err) {
+		log.Debugf("Session dropped during image state read; reconnecting")
+		if !s.IsOpen() {
+			if oerr := s.Open(); oerr != nil {
+				return nil, fmt.Errorf("Failed to reconnect after session "+
+					"dropped (%s): %s", err.Error(), oerr.Error())
+			}
+		}
+
+		r = nmp.NewImageStateReadReq()
+		rsp, err = txReq(s, r.Msg(), &c.CmdBase)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -632,6 +1005,59 @@ func (c *ImageStateWriteCmd) Run(s sesn.Sesn) (Result, error) {
 	return res, nil
 }
 
+//////////////////////////////////////////////////////////////////////////////
+// $swap                                                                    //
+//////////////////////////////////////////////////////////////////////////////
+
+// ImageSwapCmd requests a specific swap type on the next reset.  A test swap
+// boots the image with the given hash once, reverting unless it is
+// confirmed; a perm swap switches to it permanently.  Requesting none
+// confirms the running image, cancelling a pending revert.  A revert cannot
+// be requested directly; one is pending whenever the running image is
+// unconfirmed.
+type ImageSwapCmd struct {
+	CmdBase
+	Type nmp.SwapType
+	Hash []byte
+}
+
+func NewImageSwapCmd() *ImageSwapCmd {
+	return &ImageSwapCmd{
+		CmdBase: NewCmdBase(),
+	}
+}
+
+func (c *ImageSwapCmd) Run(s sesn.Sesn) (Result, error) {
+	r := nmp.NewImageStateWriteReq()
+
+	switch c.Type {
+	case nmp.SWAP_TYPE_TEST, nmp.SWAP_TYPE_PERM:
+		if len(c.Hash) == 0 {
+			return nil, fmt.Errorf("Swap type %s requires an image hash",
+				c.Type.String())
+		}
+		r.Hash = c.Hash
+		r.Confirm = c.Type == nmp.SWAP_TYPE_PERM
+
+	case nmp.SWAP_TYPE_NONE:
+		r.Confirm = true
+
+	default:
+		return nil, fmt.Errorf("Swap type %s cannot be requested",
+			c.Type.String())
+	}
+
+	rsp, err := txReq(s, r.Msg(), &c.CmdBase)
+	if err != nil {
+		return nil, err
+	}
+	srsp := rsp.(*nmp.ImageStateRsp)
+
+	res := newImageStateWriteResult()
+	res.Rsp = srsp
+	return res, nil
+}
+
 //////////////////////////////////////////////////////////////////////////////
 // $corelist                                                                //
 //////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,313 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package xact
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
+)
+
+func imageUploadTestData() []byte {
+	data := make([]byte, 64)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	return data
+}
+
+// The first chunk carries the image length and its SHA256.
+func TestImageUploadFirstChunkGolden(t *testing.T) {
+	rx, s := newRecordingSesn(t)
+
+	r, err := nextImageUploadReq(s, false, imageUploadTestData(), 0, 0,
+		IMAGE_UPLOAD_SLOT_DEFAULT)
+	if err != nil {
+		t.Fatalf("failed to build request: %s", err.Error())
+	}
+
+	c := NewCmdBase()
+	if _, err := txReq(s, r.Msg(), &c); err == nil {
+		t.Fatalf("recording session returned a response")
+	}
+
+	checkGoldenFrame(t, onlyFrame(t, rx),
+		"0200008000010001"+
+			"a5"+
+			"65696d616765"+"00"+
+			"636f6666"+"00"+
+			"636c656e"+"1840"+
+			"63736861"+"5820"+
+			"fdeab9acf3710362bd2658cdc9a29e8f"+
+			"9c757fcf9811603a8c447cd1d9151108"+
+			"6464617461"+"5840"+
+			"000102030405060708090a0b0c0d0e0f"+
+			"101112131415161718191a1b1c1d1e1f"+
+			"202122232425262728292a2b2c2d2e2f"+
+			"303132333435363738393a3b3c3d3e3f")
+}
+
+// Later chunks carry only the offset and data.
+func TestImageUploadLaterChunkGolden(t *testing.T) {
+	rx, s := newRecordingSesn(t)
+
+	r, err := nextImageUploadReq(s, false, imageUploadTestData(), 32, 0,
+		IMAGE_UPLOAD_SLOT_DEFAULT)
+	if err != nil {
+		t.Fatalf("failed to build request: %s", err.Error())
+	}
+
+	c := NewCmdBase()
+	if _, err := txReq(s, r.Msg(), &c); err == nil {
+		t.Fatalf("recording session returned a response")
+	}
+
+	checkGoldenFrame(t, onlyFrame(t, rx),
+		"0200003500010001"+
+			"a3"+
+			"65696d616765"+"00"+
+			"636f6666"+"1820"+
+			"6464617461"+"5820"+
+			"202122232425262728292a2b2c2d2e2f"+
+			"303132333435363738393a3b3c3d3e3f")
+}
+
+// Runs an upload against a device that fails the first failN attempts to send
+// the chunk at offset 0 and accepts everything else.
+func runFlakyImageUpload(t *testing.T, failN int) (*ImageUploadResult,
+	error) {
+
+	data := make([]byte, 1500)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+
+	var mtx sync.Mutex
+	img := make([]byte, len(data))
+	attempts := map[uint32]int{}
+
+	s := newFakeSesn(t, func(m *nmp.NmpMsg) (nmp.NmpRsp, error) {
+		req := m.Body.(*nmp.ImageUploadReq)
+
+		mtx.Lock()
+		defer mtx.Unlock()
+
+		attempts[req.Off]++
+		if req.Off == 0 && attempts[req.Off] <= failN {
+			return nil, fmt.Errorf("injected failure")
+		}
+		copy(img[req.Off:], req.Data)
+
+		rsp := nmp.NewImageUploadRsp()
+		rsp.SetHdr(fakeRspHdr(m))
+		rsp.Off = req.Off + uint32(len(req.Data))
+		return rsp, nil
+	})
+
+	c := NewImageUploadCmd()
+	c.Data = data
+	c.MaxWinSz = IMAGE_UPLOAD_START_WS
+	c.ChunkRetries = 2
+
+	res, err := c.Run(s)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(img, data) {
+		t.Fatalf("device received a corrupt image")
+	}
+	return res.(*ImageUploadResult), nil
+}
+
+func TestImageUploadChunkRetry(t *testing.T) {
+	tests := []struct {
+		name    string
+		failN   int
+		retried int
+		errSub  string
+	}{
+		{"no failures", 0, 0, ""},
+		{"fail once", 1, 1, ""},
+		{"fail up to limit", 2, 2, ""},
+		{"fail always", 1000, 0, "failed 3 times"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := runFlakyImageUpload(t, tt.failN)
+			if tt.errSub != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errSub) {
+					t.Fatalf("err = %v, want one containing %q", err,
+						tt.errSub)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("upload failed: %v", err)
+			}
+			if res.RetriedChunks != tt.retried {
+				t.Errorf("RetriedChunks = %d, want %d", res.RetriedChunks,
+					tt.retried)
+			}
+		})
+	}
+}
+
+func TestNewImageUpgradeCmdReader(t *testing.T) {
+	data := imageUploadTestData()
+
+	tests := []struct {
+		name    string
+		data    []byte
+		size    int
+		wantErr bool
+	}{
+		{"exact", data, len(data), false},
+		{"short", data, len(data) + 1, true},
+		{"long", data, len(data) - 1, true},
+		{"zero size", data, 0, true},
+		{"negative size", data, -1, true},
+	}
+
+	for _, tt := range tests {
+		c, err := NewImageUpgradeCmdReader(bytes.NewReader(tt.data), tt.size)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: no error", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if !bytes.Equal(c.Data, tt.data) {
+			t.Errorf("%s: image data not read intact", tt.name)
+		}
+	}
+}
+
+func TestImageUpgradeProgressEvents(t *testing.T) {
+	data := make([]byte, 1500)
+
+	tests := []struct {
+		name      string
+		noErase   bool
+		wantErase bool
+	}{
+		{"erase", false, true},
+		{"no erase", true, false},
+	}
+
+	for _, tt := range tests {
+		s := newFakeSesn(t, func(m *nmp.NmpMsg) (nmp.NmpRsp, error) {
+			switch req := m.Body.(type) {
+			case *nmp.ImageEraseReq:
+				rsp := nmp.NewImageEraseRsp()
+				rsp.SetHdr(fakeRspHdr(m))
+				return rsp, nil
+
+			case *nmp.ImageUploadReq:
+				rsp := nmp.NewImageUploadRsp()
+				rsp.SetHdr(fakeRspHdr(m))
+				rsp.Off = req.Off + uint32(len(req.Data))
+				return rsp, nil
+
+			default:
+				return nil, fmt.Errorf("unexpected request: %T", req)
+			}
+		})
+
+		var evs []ProgressEvent
+		c := NewImageUpgradeCmd()
+		c.Data = data
+		c.NoErase = tt.noErase
+		c.MaxWinSz = IMAGE_UPLOAD_START_WS
+		c.ProgressEventCb = func(ev ProgressEvent) {
+			evs = append(evs, ev)
+		}
+
+		if _, err := c.Run(s); err != nil {
+			t.Fatalf("%s: upgrade failed: %v", tt.name, err)
+		}
+
+		if tt.wantErase {
+			if len(evs) == 0 || evs[0].Phase != PROGRESS_PHASE_ERASE ||
+				evs[0].Total != 0 {
+
+				t.Fatalf("%s: events %+v don't start with an erase",
+					tt.name, evs)
+			}
+			evs = evs[1:]
+		}
+
+		if len(evs) < 2 {
+			t.Fatalf("%s: too few upload events: %+v", tt.name, evs)
+		}
+		prev := -1
+		for _, ev := range evs {
+			if ev.Phase != PROGRESS_PHASE_UPLOAD || ev.Total != len(data) ||
+				ev.Current <= prev {
+
+				t.Fatalf("%s: bad upload event sequence: %+v", tt.name, evs)
+			}
+			prev = ev.Current
+		}
+		if evs[0].Current != 0 || prev != len(data) {
+			t.Errorf("%s: upload events run from %d to %d, want 0 to %d",
+				tt.name, evs[0].Current, prev, len(data))
+		}
+	}
+}
+
+func TestImageUploadMalformedRsp(t *testing.T) {
+	data := imageUploadTestData()
+
+	s := newFakeSesn(t, func(m *nmp.NmpMsg) (nmp.NmpRsp, error) {
+		req := m.Body.(*nmp.ImageUploadReq)
+
+		// Acknowledge an offset past the end of the image.
+		rsp := nmp.NewImageUploadRsp()
+		rsp.SetHdr(fakeRspHdr(m))
+		rsp.Off = req.Off + uint32(len(req.Data)) + 100
+		return rsp, nil
+	})
+
+	c := NewImageUploadCmd()
+	c.Data = data
+	c.MaxWinSz = IMAGE_UPLOAD_START_WS
+	c.ChunkRetries = 1
+
+	_, err := c.Run(s)
+	if err == nil {
+		t.Fatalf("upload succeeded despite malformed responses")
+	}
+
+	want := fmt.Sprintf("expected off=%d, received off=%d",
+		len(data), len(data)+100)
+	if !strings.Contains(err.Error(), want) {
+		t.Errorf("err = %v, want one containing %q", err, want)
+	}
+}
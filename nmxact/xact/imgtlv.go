@@ -0,0 +1,291 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package xact
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+)
+
+// Image header and TLV trailer layout, as written by newt and imgtool:
+//
+// [header][body][protected TLV info][protected TLVs][TLV info][TLVs]
+//
+// The protected TLV area is present only if the header's ProtTlvSz field is
+// nonzero.  The image hash covers the header, body, and protected TLVs.
+
+const (
+	IMAGE_HDR_SIZE       = 32
+	IMAGE_TLV_INFO_SIZE  = 4
+	IMAGE_TLV_HDR_SIZE   = 4
+	IMAGE_TLV_INFO_MAGIC = 0x6907
+	IMAGE_TLV_PROT_MAGIC = 0x6908
+)
+
+const (
+	IMAGE_TLV_KEYHASH     = 0x01
+	IMAGE_TLV_PUBKEY      = 0x02
+	IMAGE_TLV_SHA256      = 0x10
+	IMAGE_TLV_RSA2048     = 0x20
+	IMAGE_TLV_ECDSA224    = 0x21
+	IMAGE_TLV_ECDSA256    = 0x22
+	IMAGE_TLV_RSA3072     = 0x23
+	IMAGE_TLV_ED25519     = 0x24
+	IMAGE_TLV_ENC_RSA     = 0x30
+	IMAGE_TLV_ENC_KEK     = 0x31
+	IMAGE_TLV_ENC_EC256   = 0x32
+	IMAGE_TLV_ENC_X25519  = 0x33
+	IMAGE_TLV_DEPENDENCY  = 0x40
+	IMAGE_TLV_SEC_CNT     = 0x50
+	IMAGE_TLV_BOOT_RECORD = 0x60
+)
+
+var ImageTlvTypeNameMap = map[uint8]string{
+	IMAGE_TLV_KEYHASH:     "KEYHASH",
+	IMAGE_TLV_PUBKEY:      "PUBKEY",
+	IMAGE_TLV_SHA256:      "SHA256",
+	IMAGE_TLV_RSA2048:     "RSA2048",
+	IMAGE_TLV_ECDSA224:    "ECDSA224",
+	IMAGE_TLV_ECDSA256:    "ECDSA256",
+	IMAGE_TLV_RSA3072:     "RSA3072",
+	IMAGE_TLV_ED25519:     "ED25519",
+	IMAGE_TLV_ENC_RSA:     "ENC_RSA",
+	IMAGE_TLV_ENC_KEK:     "ENC_KEK",
+	IMAGE_TLV_ENC_EC256:   "ENC_EC256",
+	IMAGE_TLV_ENC_X25519:  "ENC_X25519",
+	IMAGE_TLV_DEPENDENCY:  "DEPENDENCY",
+	IMAGE_TLV_SEC_CNT:     "SEC_CNT",
+	IMAGE_TLV_BOOT_RECORD: "BOOT_RECORD",
+}
+
+func ImageTlvTypeToString(t uint8) string {
+	name := ImageTlvTypeNameMap[t]
+	if name == "" {
+		name = "???"
+	}
+	return name
+}
+
+type ImageHeader struct {
+	Magic     uint32
+	LoadAddr  uint32
+	HdrSz     uint16
+	ProtTlvSz uint16
+	ImgSz     uint32
+	Flags     uint32
+	Vers      ImageVersion
+}
+
+type ImageTlv struct {
+	Type      uint8
+	Protected bool
+	Data      []byte
+}
+
+// ImageDependency is the decoded body of a DEPENDENCY TLV: the image
+// requires image ImageNum to be at least version MinVer.
+type ImageDependency struct {
+	ImageNum uint8
+	MinVer   ImageVersion
+}
+
+type ImageInfo struct {
+	Header ImageHeader
+	Tlvs   []ImageTlv
+
+	// SHA256 of the header, body, and protected TLVs.
+	Hash []byte
+}
+
+func parseImageHeader(data []byte) (ImageHeader, error) {
+	var hdr ImageHeader
+
+	if len(data) < IMAGE_HDR_SIZE {
+		return hdr, fmt.Errorf("Image too short to contain a header")
+	}
+
+	hdr.Magic = binary.LittleEndian.Uint32(data[0:4])
+	if hdr.Magic != IMAGE_HDR_MAGIC {
+		return hdr, fmt.Errorf("Bad image magic: 0x%08x", hdr.Magic)
+	}
+	hdr.LoadAddr = binary.LittleEndian.Uint32(data[4:8])
+	hdr.HdrSz = binary.LittleEndian.Uint16(data[8:10])
+	hdr.ProtTlvSz = binary.LittleEndian.Uint16(data[10:12])
+	hdr.ImgSz = binary.LittleEndian.Uint32(data[12:16])
+	hdr.Flags = binary.LittleEndian.Uint32(data[16:20])
+
+	vers, err := ImageHeaderVersion(data)
+	if err != nil {
+		return hdr, err
+	}
+	hdr.Vers = vers
+
+	if int(hdr.HdrSz) < IMAGE_HDR_SIZE {
+		return hdr, fmt.Errorf("Invalid image header size: %d", hdr.HdrSz)
+	}
+
+	return hdr, nil
+}
+
+// parseTlvArea parses the TLV area beginning at off.  It returns the TLVs
+// and the offset just past the end of the area.
+func parseTlvArea(data []byte, off int, magic uint16,
+	protected bool) ([]ImageTlv, int, error) {
+
+	if off+IMAGE_TLV_INFO_SIZE > len(data) {
+		return nil, 0, fmt.Errorf("Image truncated at TLV info (offset %d)",
+			off)
+	}
+
+	m := binary.LittleEndian.Uint16(data[off : off+2])
+	if m != magic {
+		return nil, 0, fmt.Errorf(
+			"Bad TLV info magic at offset %d: 0x%04x (expected 0x%04x)",
+			off, m, magic)
+	}
+	totLen := int(binary.LittleEndian.Uint16(data[off+2 : off+4]))
+	end := off + totLen
+	if totLen < IMAGE_TLV_INFO_SIZE || end > len(data) {
+		return nil, 0, fmt.Errorf("Invalid TLV area length: %d", totLen)
+	}
+
+	var tlvs []ImageTlv
+	for cur := off + IMAGE_TLV_INFO_SIZE; cur < end; {
+		if cur+IMAGE_TLV_HDR_SIZE > end {
+			return nil, 0, fmt.Errorf("Truncated TLV header at offset %d",
+				cur)
+		}
+
+		typ := data[cur]
+		dataLen := int(binary.LittleEndian.Uint16(data[cur+2 : cur+4]))
+		cur += IMAGE_TLV_HDR_SIZE
+
+		if cur+dataLen > end {
+			return nil, 0, fmt.Errorf(
+				"TLV type 0x%02x at offset %d overruns TLV area", typ,
+				cur-IMAGE_TLV_HDR_SIZE)
+		}
+
+		tlvs = append(tlvs, ImageTlv{
+			Type:      typ,
+			Protected: protected,
+			Data:      data[cur : cur+dataLen],
+		})
+		cur += dataLen
+	}
+
+	return tlvs, end, nil
+}
+
+// ParseImageInfo parses an image's header and TLV trailer.
+func ParseImageInfo(data []byte) (*ImageInfo, error) {
+	hdr, err := parseImageHeader(data)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &ImageInfo{
+		Header: hdr,
+	}
+
+	off := int(hdr.HdrSz) + int(hdr.ImgSz)
+	if off > len(data) {
+		return nil, fmt.Errorf(
+			"Image truncated: header claims %d bytes, file has %d",
+			off, len(data))
+	}
+
+	hashEnd := off
+	if hdr.ProtTlvSz != 0 {
+		tlvs, end, err := parseTlvArea(data, off, IMAGE_TLV_PROT_MAGIC, true)
+		if err != nil {
+			return nil, err
+		}
+		if end-off != int(hdr.ProtTlvSz) {
+			return nil, fmt.Errorf(
+				"Protected TLV area size mismatch: header=%d actual=%d",
+				hdr.ProtTlvSz, end-off)
+		}
+		info.Tlvs = append(info.Tlvs, tlvs...)
+		off = end
+		hashEnd = end
+	}
+
+	tlvs, _, err := parseTlvArea(data, off, IMAGE_TLV_INFO_MAGIC, false)
+	if err != nil {
+		return nil, err
+	}
+	info.Tlvs = append(info.Tlvs, tlvs...)
+
+	sum := sha256.Sum256(data[:hashEnd])
+	info.Hash = sum[:]
+
+	return info, nil
+}
+
+// ParseImageDependency decodes the body of a DEPENDENCY TLV.
+func ParseImageDependency(data []byte) (ImageDependency, error) {
+	var dep ImageDependency
+
+	if len(data) != 12 {
+		return dep, fmt.Errorf("Invalid dependency TLV length: %d", len(data))
+	}
+
+	dep.ImageNum = data[0]
+	dep.MinVer.Major = data[4]
+	dep.MinVer.Minor = data[5]
+	dep.MinVer.Rev = binary.LittleEndian.Uint16(data[6:8])
+	dep.MinVer.BuildNum = binary.LittleEndian.Uint32(data[8:12])
+	return dep, nil
+}
+
+// HashValid indicates whether the image contains a SHA256 TLV matching its
+// calculated hash.
+func (info *ImageInfo) HashValid() bool {
+	for _, tlv := range info.Tlvs {
+		if tlv.Type == IMAGE_TLV_SHA256 && bytes.Equal(tlv.Data, info.Hash) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValueString renders the TLV's value: decoded for known fixed-format types,
+// hex otherwise.
+func (tlv ImageTlv) ValueString() string {
+	switch tlv.Type {
+	case IMAGE_TLV_DEPENDENCY:
+		dep, err := ParseImageDependency(tlv.Data)
+		if err == nil {
+			return fmt.Sprintf("image=%d version>=%s", dep.ImageNum,
+				dep.MinVer.String())
+		}
+
+	case IMAGE_TLV_SEC_CNT:
+		if len(tlv.Data) == 4 {
+			return fmt.Sprintf("%d", binary.LittleEndian.Uint32(tlv.Data))
+		}
+	}
+
+	return hex.EncodeToString(tlv.Data)
+}
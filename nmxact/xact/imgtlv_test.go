@@ -0,0 +1,202 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package xact
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"testing"
+)
+
+type imgTestTlv struct {
+	typ  uint8
+	data []byte
+}
+
+func imgTestTlvArea(magic uint16, tlvs []imgTestTlv) []byte {
+	var body []byte
+	for _, tlv := range tlvs {
+		hdr := make([]byte, IMAGE_TLV_HDR_SIZE)
+		hdr[0] = tlv.typ
+		binary.LittleEndian.PutUint16(hdr[2:4], uint16(len(tlv.data)))
+		body = append(body, hdr...)
+		body = append(body, tlv.data...)
+	}
+
+	info := make([]byte, IMAGE_TLV_INFO_SIZE)
+	binary.LittleEndian.PutUint16(info[0:2], magic)
+	binary.LittleEndian.PutUint16(info[2:4],
+		uint16(IMAGE_TLV_INFO_SIZE+len(body)))
+	return append(info, body...)
+}
+
+// buildTestImage returns an image with version 1.2.3.4, a 16-byte body, a
+// protected SEC_CNT TLV, and an unprotected SHA256 TLV.
+func buildTestImage() []byte {
+	hdr := make([]byte, IMAGE_HDR_SIZE)
+	binary.LittleEndian.PutUint32(hdr[0:4], IMAGE_HDR_MAGIC)
+	binary.LittleEndian.PutUint16(hdr[8:10], IMAGE_HDR_SIZE)
+	binary.LittleEndian.PutUint32(hdr[12:16], 16)
+	hdr[20] = 1
+	hdr[21] = 2
+	binary.LittleEndian.PutUint16(hdr[22:24], 3)
+	binary.LittleEndian.PutUint32(hdr[24:28], 4)
+
+	secCnt := make([]byte, 4)
+	binary.LittleEndian.PutUint32(secCnt, 7)
+	prot := imgTestTlvArea(IMAGE_TLV_PROT_MAGIC, []imgTestTlv{
+		{IMAGE_TLV_SEC_CNT, secCnt},
+	})
+	binary.LittleEndian.PutUint16(hdr[10:12], uint16(len(prot)))
+
+	data := append(hdr, bytes.Repeat([]byte{0xa5}, 16)...)
+	data = append(data, prot...)
+
+	hash := sha256.Sum256(data)
+	return append(data, imgTestTlvArea(IMAGE_TLV_INFO_MAGIC, []imgTestTlv{
+		{IMAGE_TLV_SHA256, hash[:]},
+	})...)
+}
+
+func TestParseImageInfo(t *testing.T) {
+	info, err := ParseImageInfo(buildTestImage())
+	if err != nil {
+		t.Fatalf("parse failed: %s", err.Error())
+	}
+
+	hdr := info.Header
+	if hdr.HdrSz != IMAGE_HDR_SIZE || hdr.ImgSz != 16 ||
+		hdr.ProtTlvSz != IMAGE_TLV_INFO_SIZE+IMAGE_TLV_HDR_SIZE+4 {
+
+		t.Errorf("wrong header: %+v", hdr)
+	}
+	if hdr.Vers != (ImageVersion{1, 2, 3, 4}) {
+		t.Errorf("wrong version: %s", hdr.Vers.String())
+	}
+
+	if len(info.Tlvs) != 2 {
+		t.Fatalf("wrong number of TLVs; have=%d want=2", len(info.Tlvs))
+	}
+	if tlv := info.Tlvs[0]; tlv.Type != IMAGE_TLV_SEC_CNT || !tlv.Protected {
+		t.Errorf("wrong first TLV: %+v", tlv)
+	}
+	if s := info.Tlvs[0].ValueString(); s != "7" {
+		t.Errorf("wrong SEC_CNT value: %s", s)
+	}
+	if tlv := info.Tlvs[1]; tlv.Type != IMAGE_TLV_SHA256 || tlv.Protected {
+		t.Errorf("wrong second TLV: %+v", tlv)
+	}
+
+	if !info.HashValid() {
+		t.Errorf("hash does not match SHA256 TLV; hash=%x", info.Hash)
+	}
+}
+
+func TestParseImageInfoBadHash(t *testing.T) {
+	data := buildTestImage()
+
+	// Corrupt the body; the trailer is unchanged.
+	data[IMAGE_HDR_SIZE] ^= 0xff
+
+	info, err := ParseImageInfo(data)
+	if err != nil {
+		t.Fatalf("parse failed: %s", err.Error())
+	}
+	if info.HashValid() {
+		t.Errorf("corrupt image reported as valid")
+	}
+}
+
+func TestParseImageInfoErrors(t *testing.T) {
+	good := buildTestImage()
+	protOff := IMAGE_HDR_SIZE + 16
+	protSz := int(binary.LittleEndian.Uint16(good[10:12]))
+	tlvOff := protOff + protSz
+
+	tests := []struct {
+		name   string
+		mangle func(data []byte) []byte
+	}{
+		{"short header", func(data []byte) []byte {
+			return data[:IMAGE_HDR_SIZE-1]
+		}},
+		{"bad magic", func(data []byte) []byte {
+			data[0] ^= 0xff
+			return data
+		}},
+		{"truncated body", func(data []byte) []byte {
+			return data[:protOff-1]
+		}},
+		{"bad protected TLV magic", func(data []byte) []byte {
+			data[protOff] ^= 0xff
+			return data
+		}},
+		{"protected size mismatch", func(data []byte) []byte {
+			binary.LittleEndian.PutUint16(data[10:12], uint16(protSz+4))
+			return data
+		}},
+		{"bad TLV magic", func(data []byte) []byte {
+			data[tlvOff] ^= 0xff
+			return data
+		}},
+		{"TLV area overruns image", func(data []byte) []byte {
+			return data[:len(data)-1]
+		}},
+		{"TLV overruns area", func(data []byte) []byte {
+			// Grow the SHA256 TLV's length past the end of its area.
+			off := tlvOff + IMAGE_TLV_INFO_SIZE + 2
+			binary.LittleEndian.PutUint16(data[off:off+2], sha256.Size+1)
+			return data
+		}},
+	}
+
+	for _, test := range tests {
+		data := test.mangle(buildTestImage())
+		if _, err := ParseImageInfo(data); err == nil {
+			t.Errorf("%s: no error", test.name)
+		}
+	}
+}
+
+func TestParseImageDependency(t *testing.T) {
+	data := []byte{
+		1, 0, 0, 0, // image number, padding
+		2, 3, 4, 0, // major, minor, revision
+		5, 0, 0, 0, // build number
+	}
+
+	dep, err := ParseImageDependency(data)
+	if err != nil {
+		t.Fatalf("parse failed: %s", err.Error())
+	}
+	if dep.ImageNum != 1 || dep.MinVer != (ImageVersion{2, 3, 4, 5}) {
+		t.Errorf("wrong dependency: %+v", dep)
+	}
+
+	tlv := ImageTlv{Type: IMAGE_TLV_DEPENDENCY, Data: data}
+	if s := tlv.ValueString(); s != "image=1 version>=2.3.4.5" {
+		t.Errorf("wrong dependency string: %s", s)
+	}
+
+	if _, err := ParseImageDependency(data[:11]); err == nil {
+		t.Errorf("short dependency TLV accepted")
+	}
+}
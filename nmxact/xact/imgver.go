@@ -0,0 +1,182 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package xact
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmxutil"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
+)
+
+const (
+	IMAGE_HDR_MAGIC    = 0x96f3b83d
+	IMAGE_HDR_VER_OFF  = 20
+	IMAGE_HDR_MIN_SIZE = 28
+)
+
+type ImageVersion struct {
+	Major    uint8
+	Minor    uint8
+	Rev      uint16
+	BuildNum uint32
+}
+
+func (v ImageVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d.%d", v.Major, v.Minor, v.Rev, v.BuildNum)
+}
+
+// Compare returns -1, 0, or 1 if v is lower than, equal to, or higher than
+// other.
+func (v ImageVersion) Compare(other ImageVersion) int {
+	a := []uint32{uint32(v.Major), uint32(v.Minor), uint32(v.Rev), v.BuildNum}
+	b := []uint32{uint32(other.Major), uint32(other.Minor), uint32(other.Rev),
+		other.BuildNum}
+
+	for i := range a {
+		if a[i] < b[i] {
+			return -1
+		}
+		if a[i] > b[i] {
+			return 1
+		}
+	}
+	return 0
+}
+
+// ParseImageVersion parses a version string of the form
+// "major[.minor[.rev[.build]]]", as reported in image state responses.
+func ParseImageVersion(s string) (ImageVersion, error) {
+	var v ImageVersion
+
+	parts := strings.Split(strings.TrimSpace(s), ".")
+	if len(parts) < 1 || len(parts) > 4 {
+		return v, fmt.Errorf("Invalid image version: \"%s\"", s)
+	}
+
+	bits := []int{8, 8, 16, 32}
+	nums := make([]uint64, 4)
+	for i, p := range parts {
+		n, err := strconv.ParseUint(p, 10, bits[i])
+		if err != nil {
+			return v, fmt.Errorf("Invalid image version: \"%s\"", s)
+		}
+		nums[i] = n
+	}
+
+	v.Major = uint8(nums[0])
+	v.Minor = uint8(nums[1])
+	v.Rev = uint16(nums[2])
+	v.BuildNum = uint32(nums[3])
+	return v, nil
+}
+
+// ImageHeaderVersion extracts the version from a Mynewt image header.
+func ImageHeaderVersion(data []byte) (ImageVersion, error) {
+	var v ImageVersion
+
+	if len(data) < IMAGE_HDR_MIN_SIZE {
+		return v, fmt.Errorf("Image too short to contain a header")
+	}
+	magic := binary.LittleEndian.Uint32(data[0:4])
+	if magic != IMAGE_HDR_MAGIC {
+		return v, fmt.Errorf("Bad image magic: 0x%08x", magic)
+	}
+
+	off := IMAGE_HDR_VER_OFF
+	v.Major = data[off]
+	v.Minor = data[off+1]
+	v.Rev = binary.LittleEndian.Uint16(data[off+2 : off+4])
+	v.BuildNum = binary.LittleEndian.Uint32(data[off+4 : off+8])
+	return v, nil
+}
+
+// Returns the version of the confirmed image with the given image number.  If
+// no image is marked confirmed, the active one is used.  ok is false if the
+// device has neither, e.g., on the first upload of an image number.
+func confirmedImageVersion(rsp *nmp.ImageStateRsp,
+	imageNum int) (ImageVersion, bool, error) {
+
+	var cur *nmp.ImageStateEntry
+	for i, img := range rsp.Images {
+		if img.Image != imageNum {
+			continue
+		}
+		if img.Confirmed {
+			cur = &rsp.Images[i]
+			break
+		}
+		if img.Active {
+			cur = &rsp.Images[i]
+		}
+	}
+
+	if cur == nil {
+		return ImageVersion{}, false, nil
+	}
+
+	ver, err := ParseImageVersion(cur.Version)
+	if err != nil {
+		return ImageVersion{}, false, err
+	}
+	return ver, true, nil
+}
+
+// checkDowngrade refuses an upload whose header version is lower than that
+// of the device's confirmed image.  There is nothing to downgrade if the
+// device has no current image with that number.
+func checkDowngrade(s sesn.Sesn, data []byte, imageNum int,
+	txOpts sesn.TxOptions) error {
+
+	newVer, err := ImageHeaderVersion(data)
+	if err != nil {
+		return err
+	}
+
+	c := NewImageStateReadCmd()
+	c.SetTxOptions(txOpts)
+	res, err := c.Run(s)
+	if err != nil {
+		return err
+	}
+	rsp := res.(*ImageStateReadResult).Rsp
+	if rsp.Rc != 0 {
+		return fmt.Errorf("Failed to read image state: rc=%d", rsp.Rc)
+	}
+
+	curVer, ok, err := confirmedImageVersion(rsp, imageNum)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+
+	if newVer.Compare(curVer) < 0 {
+		return nmxutil.FmtDowngradeError(
+			"Refusing to downgrade image %d from %s to %s",
+			imageNum, curVer.String(), newVer.String())
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package xact
+
+import (
+	"testing"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
+)
+
+func TestImageVersionCompare(t *testing.T) {
+	tests := []struct {
+		a   string
+		b   string
+		exp int
+	}{
+		{"1.2.3.4", "1.2.3.4", 0},
+		{"1.2.3.4", "1.2.3.5", -1},
+		{"1.2.4.0", "1.2.3.9", 1},
+		{"1.3", "1.2.99.99", 1},
+		{"0.9.9.9", "1", -1},
+		{"1", "1.0.0.0", 0},
+		{"2.0.0.0", "10.0.0.0", -1},
+		{"1.2.256.0", "1.2.255.0", 1},
+	}
+
+	for _, test := range tests {
+		a, err := ParseImageVersion(test.a)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %s", test.a, err.Error())
+		}
+		b, err := ParseImageVersion(test.b)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %s", test.b, err.Error())
+		}
+
+		if c := a.Compare(b); c != test.exp {
+			t.Errorf("%s vs %s: have=%d want=%d", test.a, test.b, c, test.exp)
+		}
+		if c := b.Compare(a); c != -test.exp {
+			t.Errorf("%s vs %s: have=%d want=%d", test.b, test.a, c, -test.exp)
+		}
+	}
+}
+
+func TestParseImageVersionInvalid(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"1.2.3.4.5",
+		"a.b",
+		"1..2",
+		"256.0.0.0",
+		"0.256.0.0",
+		"0.0.65536.0",
+		"0.0.0.4294967296",
+		"-1",
+	} {
+		if v, err := ParseImageVersion(s); err == nil {
+			t.Errorf("\"%s\" accepted as %s", s, v.String())
+		}
+	}
+}
+
+func TestConfirmedImageVersion(t *testing.T) {
+	rsp := &nmp.ImageStateRsp{
+		Images: []nmp.ImageStateEntry{
+			{Image: 0, Slot: 0, Version: "1.0.0.0", Active: true},
+			{Image: 0, Slot: 1, Version: "2.0.0.0", Confirmed: true},
+			{Image: 1, Slot: 0, Version: "3.0.0.0", Active: true},
+		},
+	}
+
+	tests := []struct {
+		imageNum int
+		ok       bool
+		ver      ImageVersion
+	}{
+		// The confirmed image takes precedence over the active one.
+		{0, true, ImageVersion{2, 0, 0, 0}},
+
+		// Without a confirmed image, the active one is used.
+		{1, true, ImageVersion{3, 0, 0, 0}},
+
+		// A new image number has no current version.
+		{2, false, ImageVersion{}},
+	}
+
+	for _, test := range tests {
+		ver, ok, err := confirmedImageVersion(rsp, test.imageNum)
+		if err != nil {
+			t.Fatalf("image %d: unexpected error: %s", test.imageNum,
+				err.Error())
+		}
+		if ok != test.ok || ver != test.ver {
+			t.Errorf("image %d: have=(%s, %v) want=(%s, %v)", test.imageNum,
+				ver.String(), ok, test.ver.String(), test.ok)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package xact
+
+import (
+	"fmt"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
+)
+
+// Number of bytes requested per memory read; larger reads are split.
+const MEM_READ_DFLT_CHUNK_LEN = 128
+
+// memRegister validates the memory group's number and makes sure its
+// responses can be decoded.
+func memRegister(group uint16) error {
+	if group < nmp.NMP_GROUP_PERUSER {
+		return fmt.Errorf("invalid memory group: %d (must be a per-user "+
+			"group, %d or above)", group, nmp.NMP_GROUP_PERUSER)
+	}
+
+	return nmp.RegisterMemRsps(group)
+}
+
+func memCheckWidth(addr uint64, length int, width int) error {
+	switch width {
+	case 1, 2, 4, 8:
+	default:
+		return fmt.Errorf("invalid access width: %d", width)
+	}
+
+	if addr%uint64(width) != 0 || length%width != 0 {
+		return fmt.Errorf("address 0x%x and length %d must be multiples "+
+			"of the access width (%d)", addr, length, width)
+	}
+
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// $read                                                                     //
+///////////////////////////////////////////////////////////////////////////////
+
+type MemReadCmd struct {
+	CmdBase
+	Group    uint16
+	Addr     uint64
+	Len      int
+	Width    int
+	ChunkLen int
+}
+
+func NewMemReadCmd() *MemReadCmd {
+	return &MemReadCmd{
+		CmdBase:  NewCmdBase(),
+		Width:    1,
+		ChunkLen: MEM_READ_DFLT_CHUNK_LEN,
+	}
+}
+
+type MemReadResult struct {
+	Rc   int
+	Data []byte
+}
+
+func newMemReadResult() *MemReadResult {
+	return &MemReadResult{}
+}
+
+func (r *MemReadResult) Status() int {
+	return r.Rc
+}
+
+// Run reads Len bytes starting at Addr, issuing one request per ChunkLen
+// bytes.  Reading stops at the first request the device rejects.
+func (c *MemReadCmd) Run(s sesn.Sesn) (Result, error) {
+	if err := memRegister(c.Group); err != nil {
+		return nil, err
+	}
+	if err := memCheckWidth(c.Addr, c.Len, c.Width); err != nil {
+		return nil, err
+	}
+
+	chunkLen := c.ChunkLen - c.ChunkLen%c.Width
+	if chunkLen <= 0 {
+		return nil, fmt.Errorf("invalid chunk length: %d", c.ChunkLen)
+	}
+
+	res := newMemReadResult()
+	for len(res.Data) < c.Len {
+		r := nmp.NewMemReadReq(c.Group)
+		r.Addr = c.Addr + uint64(len(res.Data))
+		r.Width = c.Width
+		r.Len = c.Len - len(res.Data)
+		if r.Len > chunkLen {
+			r.Len = chunkLen
+		}
+
+		rsp, err := txReq(s, r.Msg(), &c.CmdBase)
+		if err != nil {
+			return nil, err
+		}
+		srsp := rsp.(*nmp.MemReadRsp)
+
+		if srsp.Rc != 0 {
+			res.Rc = srsp.Rc
+			return res, nil
+		}
+		if len(srsp.Data) != r.Len {
+			return nil, fmt.Errorf("memory read at 0x%x returned %d bytes; "+
+				"expected %d", r.Addr, len(srsp.Data), r.Len)
+		}
+
+		res.Data = append(res.Data, srsp.Data...)
+	}
+
+	return res, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// $write                                                                    //
+///////////////////////////////////////////////////////////////////////////////
+
+type MemWriteCmd struct {
+	CmdBase
+	Group uint16
+	Addr  uint64
+	Width int
+	Data  []byte
+}
+
+func NewMemWriteCmd() *MemWriteCmd {
+	return &MemWriteCmd{
+		CmdBase: NewCmdBase(),
+		Width:   1,
+	}
+}
+
+type MemWriteResult struct {
+	Rsp *nmp.MemWriteRsp
+}
+
+func newMemWriteResult() *MemWriteResult {
+	return &MemWriteResult{}
+}
+
+func (r *MemWriteResult) Status() int {
+	return r.Rsp.Rc
+}
+
+func (c *MemWriteCmd) Run(s sesn.Sesn) (Result, error) {
+	if err := memRegister(c.Group); err != nil {
+		return nil, err
+	}
+	if err := memCheckWidth(c.Addr, len(c.Data), c.Width); err != nil {
+		return nil, err
+	}
+
+	r := nmp.NewMemWriteReq(c.Group)
+	r.Addr = c.Addr
+	r.Width = c.Width
+	r.Data = c.Data
+
+	rsp, err := txReq(s, r.Msg(), &c.CmdBase)
+	if err != nil {
+		return nil, err
+	}
+	srsp := rsp.(*nmp.MemWriteRsp)
+
+	res := newMemWriteResult()
+	res.Rsp = srsp
+	return res, nil
+}
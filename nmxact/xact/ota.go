@@ -0,0 +1,343 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package xact
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
+)
+
+// Names of the steps recorded in an OtaUpdateResult.
+const OTA_STEP_STATE_READ = "state read"
+const OTA_STEP_UPLOAD = "upload"
+const OTA_STEP_MARK = "mark"
+const OTA_STEP_RESET = "reset"
+const OTA_STEP_REBOOT_WAIT = "reboot wait"
+const OTA_STEP_VERIFY = "verify"
+const OTA_STEP_CONFIRM = "confirm"
+
+const OTA_DEF_REBOOT_TIMEOUT = 60 * time.Second
+
+type OtaStep struct {
+	Name string
+	Err  error
+}
+
+// Called as each step of an OTA update completes, successfully or not.
+type OtaStepFn func(step OtaStep)
+
+// OtaUpdateCmd performs the complete sequence required to switch a device to
+// a new image:
+//  1. Read the image state to determine the running image.
+//  2. Upload the new image to the secondary slot.
+//  3. Read the image state to determine the hash of the uploaded image.
+//  4. Mark the new image for test (TestFirst) or confirm it permanently.
+//  5. Reset the device and wait for it to go down.
+//  6. Poll the image state until the device is reachable again.  If the
+//     device is still running the old image, the update has been rolled
+//     back (or the image failed validation) and the command fails.
+//  7. If the image was only marked for test, confirm it, unless NoConfirm is
+//     set.
+//
+// If NoReset is set, the command stops after step 4 and the caller is
+// responsible for resetting the device.  An image marked for test then runs
+// once after that reset and is reverted on the following one unless the
+// caller confirms it (e.g., with `image confirm`) while it is running.  A
+// confirmed image is kept regardless.
+type OtaUpdateCmd struct {
+	CmdBase
+	Data       []byte
+	ImageNum   int
+	Upgrade    bool
+	NoErase    bool
+	MaxWinSz   int
+	ProgressCb ImageUploadProgressFn
+
+	// Receives progress events from the upload step.
+	ProgressEventCb ProgressEventFn
+
+	// If non-nil, called as each step completes.
+	StepCb OtaStepFn
+
+	// If true, the new image is marked for test and only confirmed once the
+	// device has booted into it.  Otherwise, it is confirmed before reset.
+	TestFirst bool
+
+	// Maximum time to wait for the device to come back after reset.
+	RebootTimeout time.Duration
+
+	// If true, no reset is sent after the image is marked; the remaining
+	// steps are left to the caller.
+	NoReset bool
+
+	// If true, an image marked for test is left unconfirmed once the device
+	// has booted it.  It is reverted on the next reset unless the caller
+	// confirms it.
+	NoConfirm bool
+}
+
+type OtaUpdateResult struct {
+	Steps      []OtaStep
+	OldHash    []byte
+	NewHash    []byte
+	RolledBack bool
+}
+
+func NewOtaUpdateCmd() *OtaUpdateCmd {
+	return &OtaUpdateCmd{
+		CmdBase:       NewCmdBase(),
+		NoErase:       true,
+		MaxWinSz:      IMAGE_UPLOAD_DEF_MAX_WS,
+		TestFirst:     true,
+		RebootTimeout: OTA_DEF_REBOOT_TIMEOUT,
+	}
+}
+
+func newOtaUpdateResult() *OtaUpdateResult {
+	return &OtaUpdateResult{}
+}
+
+func (r *OtaUpdateResult) Status() int {
+	for _, step := range r.Steps {
+		if step.Err != nil {
+			return nmp.NMP_ERR_EUNKNOWN
+		}
+	}
+
+	return nmp.NMP_ERR_OK
+}
+
+// Convenience wrapper around OtaUpdateCmd.
+func OtaUpdate(s sesn.Sesn, data []byte, c *OtaUpdateCmd) (
+	*OtaUpdateResult, error) {
+
+	c.Data = data
+	res, err := c.Run(s)
+	if res == nil {
+		return nil, err
+	}
+
+	return res.(*OtaUpdateResult), err
+}
+
+func (r *OtaUpdateResult) addStep(name string, err error) error {
+	r.Steps = append(r.Steps, OtaStep{
+		Name: name,
+		Err:  err,
+	})
+
+	if err != nil {
+		return fmt.Errorf("OTA update failed at %s step: %s",
+			name, err.Error())
+	}
+
+	return nil
+}
+
+// Records a step in the result and reports it to the step callback.
+func (c *OtaUpdateCmd) addStep(res *OtaUpdateResult, name string,
+	err error) error {
+
+	rerr := res.addStep(name, err)
+	if c.StepCb != nil {
+		c.StepCb(res.Steps[len(res.Steps)-1])
+	}
+
+	return rerr
+}
+
+func (c *OtaUpdateCmd) readState(s sesn.Sesn) (*nmp.ImageStateRsp, error) {
+	cmd := NewImageStateReadCmd()
+	cmd.SetTxOptions(c.TxOptions())
+
+	res, err := cmd.Run(s)
+	if err != nil {
+		return nil, err
+	}
+
+	sres := res.(*ImageStateReadResult)
+	if sres.Status() != 0 {
+		return nil, fmt.Errorf("image state read failed; rc=%d",
+			sres.Status())
+	}
+
+	return sres.Rsp, nil
+}
+
+func (c *OtaUpdateCmd) findImage(rsp *nmp.ImageStateRsp,
+	active bool) *nmp.ImageStateEntry {
+
+	for i, img := range rsp.Images {
+		if img.Image == c.ImageNum && img.Active == active {
+			return &rsp.Images[i]
+		}
+	}
+
+	return nil
+}
+
+func (c *OtaUpdateCmd) writeState(s sesn.Sesn, hash []byte,
+	confirm bool) error {
+
+	cmd := NewImageStateWriteCmd()
+	cmd.SetTxOptions(c.TxOptions())
+	cmd.Hash = hash
+	cmd.Confirm = confirm
+
+	res, err := cmd.Run(s)
+	if err != nil {
+		return err
+	}
+
+	if res.Status() != 0 {
+		return fmt.Errorf("image state write failed; rc=%d", res.Status())
+	}
+
+	return nil
+}
+
+// Repeatedly reads the image state until the device responds or the reboot
+// timeout expires.
+func (c *OtaUpdateCmd) waitReboot(s sesn.Sesn) (*nmp.ImageStateRsp, error) {
+	deadline := time.Now().Add(c.RebootTimeout)
+
+	for {
+		if c.abortErr != nil {
+			return nil, c.abortErr
+		}
+
+		// Connection-oriented transports lose the connection on reset.
+		var err error
+		if !s.IsOpen() {
+			err = s.Open()
+		}
+
+		if err == nil {
+			var rsp *nmp.ImageStateRsp
+			rsp, err = c.readState(s)
+			if err == nil {
+				return rsp, nil
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("device unreachable after reset: %s",
+				err.Error())
+		}
+
+		log.Debugf("waiting for device to reboot: %s", err.Error())
+		time.Sleep(time.Second)
+	}
+}
+
+func (c *OtaUpdateCmd) Run(s sesn.Sesn) (Result, error) {
+	res := newOtaUpdateResult()
+
+	rsp, err := c.readState(s)
+	if err := c.addStep(res, OTA_STEP_STATE_READ, err); err != nil {
+		return res, err
+	}
+	if img := c.findImage(rsp, true); img != nil {
+		res.OldHash = img.Hash
+	}
+
+	ucmd := NewImageUpgradeCmd()
+	ucmd.SetTxOptions(c.TxOptions())
+	ucmd.Data = c.Data
+	ucmd.NoErase = c.NoErase
+	ucmd.Upgrade = c.Upgrade
+	ucmd.ImageNum = c.ImageNum
+	ucmd.MaxWinSz = c.MaxWinSz
+	ucmd.ProgressCb = func(uc *ImageUploadCmd, r *nmp.ImageUploadRsp) {
+		if c.ProgressCb != nil {
+			c.ProgressCb(uc, r)
+		}
+	}
+	ucmd.ProgressEventCb = c.ProgressEventCb
+
+	ures, err := ucmd.Run(s)
+	if err == nil && ures.Status() != 0 {
+		err = fmt.Errorf("image upload failed; rc=%d", ures.Status())
+	}
+	if err := c.addStep(res, OTA_STEP_UPLOAD, err); err != nil {
+		return res, err
+	}
+
+	rsp, err = c.readState(s)
+	if err == nil {
+		img := c.findImage(rsp, false)
+		if img == nil || len(img.Hash) == 0 {
+			err = fmt.Errorf("uploaded image not found in image state")
+		} else {
+			res.NewHash = img.Hash
+		}
+	}
+	if err := c.addStep(res, OTA_STEP_STATE_READ, err); err != nil {
+		return res, err
+	}
+
+	err = c.writeState(s, res.NewHash, !c.TestFirst)
+	if err := c.addStep(res, OTA_STEP_MARK, err); err != nil {
+		return res, err
+	}
+
+	if c.NoReset {
+		return res, nil
+	}
+
+	// Waiting for the device to go down keeps the old firmware from answering
+	// the first state read.
+	err = ResetAndWait(s, c.TxOptions())
+	if err := c.addStep(res, OTA_STEP_RESET, err); err != nil {
+		return res, err
+	}
+
+	rsp, err = c.waitReboot(s)
+	if err := c.addStep(res, OTA_STEP_REBOOT_WAIT, err); err != nil {
+		return res, err
+	}
+
+	img := c.findImage(rsp, true)
+	if img == nil || !bytes.Equal(img.Hash, res.NewHash) {
+		if img != nil && bytes.Equal(img.Hash, res.OldHash) {
+			res.RolledBack = true
+		}
+		err = fmt.Errorf("device is not running the new image")
+	}
+	if err := c.addStep(res, OTA_STEP_VERIFY, err); err != nil {
+		return res, err
+	}
+
+	if c.TestFirst && !c.NoConfirm {
+		// An empty hash confirms the running image.
+		err = c.writeState(s, nil, true)
+		if err := c.addStep(res, OTA_STEP_CONFIRM, err); err != nil {
+			return res, err
+		}
+	}
+
+	return res, nil
+}
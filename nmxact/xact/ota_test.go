@@ -0,0 +1,281 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package xact
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmxutil"
+)
+
+var (
+	otaOldHash = bytes.Repeat([]byte{0xaa}, 32)
+	otaNewHash = bytes.Repeat([]byte{0xbb}, 32)
+)
+
+// otaDev models the image slots of a device with MCUboot.
+type otaDev struct {
+	mtx sync.Mutex
+
+	// If set, the device fails to boot the new image and reverts to the
+	// old one.
+	rejectNew bool
+
+	running   []byte
+	confirmed bool
+	staged    []byte
+	pending   bool
+	permanent bool
+	resets    int
+
+	uploadLen int
+	uploaded  []byte
+}
+
+func newOtaDev() *otaDev {
+	return &otaDev{
+		running:   otaOldHash,
+		confirmed: true,
+	}
+}
+
+func (d *otaDev) state() *nmp.ImageStateRsp {
+	rsp := nmp.NewImageStateRsp()
+	rsp.Images = append(rsp.Images, nmp.ImageStateEntry{
+		Slot:      0,
+		Hash:      d.running,
+		Active:    true,
+		Confirmed: d.confirmed,
+	})
+	if d.staged != nil {
+		rsp.Images = append(rsp.Images, nmp.ImageStateEntry{
+			Slot:      1,
+			Hash:      d.staged,
+			Pending:   d.pending,
+			Permanent: d.permanent,
+		})
+	}
+	return rsp
+}
+
+func (d *otaDev) rsp(m *nmp.NmpMsg) (nmp.NmpRsp, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	var rsp nmp.NmpRsp
+	switch req := m.Body.(type) {
+	case *nmp.ImageStateReadReq:
+		rsp = d.state()
+
+	case *nmp.ImageUploadReq:
+		if req.Off == 0 {
+			d.uploadLen = int(req.Len)
+			d.uploaded = nil
+			d.staged = nil
+		}
+		if int(req.Off) == len(d.uploaded) {
+			d.uploaded = append(d.uploaded, req.Data...)
+		}
+		if len(d.uploaded) == d.uploadLen {
+			d.staged = otaNewHash
+		}
+
+		r := nmp.NewImageUploadRsp()
+		r.Off = uint32(len(d.uploaded))
+		rsp = r
+
+	case *nmp.ImageStateWriteReq:
+		var r *nmp.ImageStateRsp
+		switch {
+		case req.Hash == nil && req.Confirm:
+			d.confirmed = true
+			r = d.state()
+		case d.staged != nil && bytes.Equal(req.Hash, d.staged):
+			d.pending = true
+			d.permanent = req.Confirm
+			r = d.state()
+		default:
+			r = nmp.NewImageStateRsp()
+			r.Rc = nmp.NMP_ERR_EINVAL
+		}
+		rsp = r
+
+	case *nmp.ResetReq:
+		d.resets++
+		if d.pending && !d.rejectNew {
+			d.running, d.staged = d.staged, d.running
+			d.confirmed = d.permanent
+		}
+		d.pending = false
+		d.permanent = false
+		rsp = nmp.NewResetRsp()
+
+	case *nmp.EchoReq:
+		// The device goes down as soon as it has been reset.
+		return nil, nmxutil.NewRspTimeoutError("device is down")
+
+	default:
+		return nil, fmt.Errorf("unexpected request: %T", m.Body)
+	}
+
+	rsp.SetHdr(fakeRspHdr(m))
+	return rsp, nil
+}
+
+func TestOtaUpdate(t *testing.T) {
+	allSteps := []string{
+		OTA_STEP_STATE_READ,
+		OTA_STEP_UPLOAD,
+		OTA_STEP_STATE_READ,
+		OTA_STEP_MARK,
+		OTA_STEP_RESET,
+		OTA_STEP_REBOOT_WAIT,
+		OTA_STEP_VERIFY,
+		OTA_STEP_CONFIRM,
+	}
+
+	tests := []struct {
+		name       string
+		testFirst  bool
+		noReset    bool
+		noConfirm  bool
+		rejectNew  bool
+		steps      []string
+		failStep   string
+		rolledBack bool
+		running    []byte
+		confirmed  bool
+		resets     int
+	}{
+		{
+			name:      "test first",
+			testFirst: true,
+			steps:     allSteps,
+			running:   otaNewHash,
+			confirmed: true,
+			resets:    1,
+		},
+		{
+			name:      "direct confirm",
+			steps:     allSteps[:7],
+			running:   otaNewHash,
+			confirmed: true,
+			resets:    1,
+		},
+		{
+			name:      "no confirm",
+			testFirst: true,
+			noConfirm: true,
+			steps:     allSteps[:7],
+			running:   otaNewHash,
+			resets:    1,
+		},
+		{
+			name:      "no reset",
+			testFirst: true,
+			noReset:   true,
+			steps:     allSteps[:4],
+			running:   otaOldHash,
+			confirmed: true,
+		},
+		{
+			name:       "rollback",
+			testFirst:  true,
+			rejectNew:  true,
+			steps:      allSteps[:7],
+			failStep:   OTA_STEP_VERIFY,
+			rolledBack: true,
+			running:    otaOldHash,
+			confirmed:  true,
+			resets:     1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := newOtaDev()
+			dev.rejectNew = tt.rejectNew
+			s := newFakeSesn(t, dev.rsp)
+
+			var cbSteps []OtaStep
+			c := NewOtaUpdateCmd()
+			c.TestFirst = tt.testFirst
+			c.NoReset = tt.noReset
+			c.NoConfirm = tt.noConfirm
+			c.StepCb = func(step OtaStep) {
+				cbSteps = append(cbSteps, step)
+			}
+
+			data := bytes.Repeat([]byte{0x5a}, 1000)
+			res, err := OtaUpdate(s, data, c)
+			if (err != nil) != (tt.failStep != "") {
+				t.Fatalf("err = %v, want failure at %q", err, tt.failStep)
+			}
+
+			names := []string{}
+			for i, step := range res.Steps {
+				names = append(names, step.Name)
+				failed := i == len(res.Steps)-1 && tt.failStep != ""
+				if (step.Err != nil) != failed {
+					t.Errorf("step %s: err = %v", step.Name, step.Err)
+				}
+			}
+			if !reflect.DeepEqual(names, tt.steps) {
+				t.Errorf("steps = %v, want %v", names, tt.steps)
+			}
+			if !reflect.DeepEqual(cbSteps, res.Steps) {
+				t.Errorf("callback saw %v, result has %v", cbSteps,
+					res.Steps)
+			}
+
+			if !bytes.Equal(res.OldHash, otaOldHash) {
+				t.Errorf("OldHash = %x, want %x", res.OldHash, otaOldHash)
+			}
+			if !bytes.Equal(res.NewHash, otaNewHash) {
+				t.Errorf("NewHash = %x, want %x", res.NewHash, otaNewHash)
+			}
+			if res.RolledBack != tt.rolledBack {
+				t.Errorf("RolledBack = %v, want %v", res.RolledBack,
+					tt.rolledBack)
+			}
+
+			if !bytes.Equal(dev.uploaded, data) {
+				t.Errorf("device received a corrupt image")
+			}
+			if !bytes.Equal(dev.running, tt.running) {
+				t.Errorf("device running %x, want %x", dev.running,
+					tt.running)
+			}
+			if dev.confirmed != tt.confirmed {
+				t.Errorf("device confirmed = %v, want %v", dev.confirmed,
+					tt.confirmed)
+			}
+			if dev.resets != tt.resets {
+				t.Errorf("device reset %d times, want %d", dev.resets,
+					tt.resets)
+			}
+		})
+	}
+}
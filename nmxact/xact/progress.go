@@ -0,0 +1,55 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package xact
+
+// Phases reported by long-running commands.
+const (
+	PROGRESS_PHASE_RESUME = "resume"
+	PROGRESS_PHASE_ERASE  = "erase"
+	PROGRESS_PHASE_UPLOAD = "upload"
+)
+
+// ProgressEvent describes the state of a long-running command.  Current and
+// Total are in the phase's natural unit (bytes for transfers).  A Total of 0
+// means the phase has no measurable extent; such events only announce the
+// phase.
+type ProgressEvent struct {
+	Phase   string `json:"phase"`
+	Current int    `json:"current"`
+	Total   int    `json:"total"`
+	Message string `json:"message,omitempty"`
+}
+
+// ProgressEventFn receives progress events in the order they occur.  It is
+// called synchronously from the command and must not block.
+type ProgressEventFn func(ev ProgressEvent)
+
+func emitProgress(fn ProgressEventFn, phase string, cur int, total int,
+	msg string) {
+
+	if fn != nil {
+		fn(ProgressEvent{
+			Phase:   phase,
+			Current: cur,
+			Total:   total,
+			Message: msg,
+		})
+	}
+}
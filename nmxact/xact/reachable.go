@@ -0,0 +1,159 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package xact
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmxutil"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
+)
+
+// Ping sends a single empty echo request and returns the round-trip time.
+// opt controls the response timeout and retries; retries are included in
+// the returned time.
+func Ping(s sesn.Sesn, opt sesn.TxOptions) (time.Duration, error) {
+	c := NewEchoCmd()
+	c.SetTxOptions(opt)
+
+	start := time.Now()
+	res, err := c.Run(s)
+	rtt := time.Since(start)
+	if err != nil {
+		return rtt, err
+	}
+	if res.Status() != 0 {
+		return rtt, fmt.Errorf("echo failed; rc=%d", res.Status())
+	}
+
+	return rtt, nil
+}
+
+// WaitReachable repeatedly sends an empty echo request until the device
+// responds or timeout elapses.  Each attempt waits up to interval for a
+// response.  If the session is closed (e.g., a connection-oriented transport
+// lost its connection when the device reset), it is reopened before each
+// attempt.  The time taken for the device to become reachable is returned.
+func WaitReachable(s sesn.Sesn, timeout time.Duration,
+	interval time.Duration) (time.Duration, error) {
+
+	if interval <= 0 {
+		return 0, fmt.Errorf("Invalid poll interval: %s", interval)
+	}
+
+	start := time.Now()
+	deadline := start.Add(timeout)
+
+	for {
+		attemptStart := time.Now()
+
+		var err error
+		if !s.IsOpen() {
+			err = s.Open()
+		}
+
+		if err == nil {
+			c := NewEchoCmd()
+			c.SetTxOptions(sesn.TxOptions{
+				Timeout: interval,
+				Tries:   1,
+			})
+
+			var res Result
+			res, err = c.Run(s)
+			if err == nil {
+				if res.Status() == 0 {
+					return time.Since(start), nil
+				}
+				err = fmt.Errorf("echo failed; rc=%d", res.Status())
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return time.Since(start), fmt.Errorf(
+				"device unreachable after %s: %s", timeout, err.Error())
+		}
+
+		log.Debugf("waiting for device: %s", err.Error())
+
+		// Don't spin if the attempt failed quickly.
+		if d := interval - time.Since(attemptStart); d > 0 {
+			time.Sleep(d)
+		}
+	}
+}
+
+// Longest WaitReset waits for a device to go down.  Mynewt resets about
+// 250 ms after acknowledging a reset request.
+const RESET_DOWN_TIMEOUT = 5 * time.Second
+
+// WaitReset waits for a device that has acknowledged a reset request to
+// actually go down: for its session to close or for an echo request to go
+// unanswered.  A device responds to the reset request before resetting, so
+// a request sent immediately afterwards may still reach the old firmware.
+// If the device keeps answering until timeout elapses, it is assumed to have
+// reset and come back between two echoes.
+func WaitReset(s sesn.Sesn, timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+
+	for time.Now().Before(deadline) {
+		if !s.IsOpen() {
+			return
+		}
+
+		c := NewEchoCmd()
+		c.SetTxOptions(sesn.TxOptions{
+			Timeout: time.Second,
+			Tries:   1,
+		})
+
+		res, err := c.Run(s)
+		if err != nil || res.Status() != 0 {
+			return
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	log.Debugf("device still reachable %s after reset", timeout)
+}
+
+// ResetAndWait sends a reset request and waits for the device to go down (see
+// WaitReset).  A device may reset before its response gets sent, so a
+// response timeout or a session that closed during the request is not an
+// error.
+func ResetAndWait(s sesn.Sesn, opt sesn.TxOptions) error {
+	c := NewResetCmd()
+	c.SetTxOptions(opt)
+
+	_, err := c.Run(s)
+	if nmxutil.IsRspTimeout(err) || (err != nil && !s.IsOpen()) {
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+
+	WaitReset(s, RESET_DOWN_TIMEOUT)
+	return nil
+}
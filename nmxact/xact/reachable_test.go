@@ -0,0 +1,98 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package xact
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmxutil"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
+)
+
+func TestPing(t *testing.T) {
+	const delay = 20 * time.Millisecond
+
+	tests := []struct {
+		name     string
+		timeouts int // Number of requests that time out before one succeeds.
+		rc       int
+		tries    int
+		wantReqs int
+		wantErr  bool
+	}{
+		{"ok", 0, 0, 1, 1, false},
+		{"device error", 0, 3, 1, 1, true},
+		{"timeout", 1, 0, 1, 1, true},
+		{"timeout then retry", 1, 0, 2, 2, false},
+	}
+
+	for _, tt := range tests {
+		var mtx sync.Mutex
+		timeouts := tt.timeouts
+
+		s := newFakeSesn(t, func(m *nmp.NmpMsg) (nmp.NmpRsp, error) {
+			mtx.Lock()
+			defer mtx.Unlock()
+
+			if timeouts > 0 {
+				timeouts--
+				return nil, nmxutil.NewRspTimeoutError("timeout")
+			}
+
+			time.Sleep(delay)
+			rsp := nmp.NewEchoRsp()
+			rsp.SetHdr(fakeRspHdr(m))
+			rsp.Payload = m.Body.(*nmp.EchoReq).Payload
+			rsp.Rc = tt.rc
+			return rsp, nil
+		})
+
+		opt := sesn.NewTxOptions()
+		opt.Tries = tt.tries
+
+		rtt, err := Ping(s, opt)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: no error", tt.name)
+			}
+		} else {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", tt.name, err)
+			}
+			if rtt < delay {
+				t.Errorf("%s: rtt = %v, want at least %v", tt.name, rtt, delay)
+			}
+		}
+
+		reqs := s.Requests()
+		if len(reqs) != tt.wantReqs {
+			t.Errorf("%s: sent %d requests, want %d", tt.name, len(reqs),
+				tt.wantReqs)
+		}
+		for _, req := range reqs {
+			if p := req.Body.(*nmp.EchoReq).Payload; p != "" {
+				t.Errorf("%s: echo payload = %q, want empty", tt.name, p)
+			}
+		}
+	}
+}
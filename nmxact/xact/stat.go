@@ -105,3 +105,86 @@ func (c *StatListCmd) Run(s sesn.Sesn) (Result, error) {
 	res.Rsp = srsp
 	return res, nil
 }
+
+//////////////////////////////////////////////////////////////////////////////
+// $read all                                                                //
+//////////////////////////////////////////////////////////////////////////////
+
+// StatGroupSnapshot holds the outcome of reading one stat group.  Exactly one
+// of Fields, Rc, and Err describes the outcome.
+type StatGroupSnapshot struct {
+	Fields map[string]interface{} `json:"fields,omitempty"`
+	Rc     int                    `json:"rc,omitempty"`
+	Err    string                 `json:"error,omitempty"`
+}
+
+type StatReadAllCmd struct {
+	CmdBase
+}
+
+func NewStatReadAllCmd() *StatReadAllCmd {
+	return &StatReadAllCmd{
+		CmdBase: NewCmdBase(),
+	}
+}
+
+type StatReadAllResult struct {
+	// Status of the list request.
+	Rc int
+
+	// Keyed by group name.
+	Groups map[string]StatGroupSnapshot
+}
+
+func newStatReadAllResult() *StatReadAllResult {
+	return &StatReadAllResult{
+		Groups: map[string]StatGroupSnapshot{},
+	}
+}
+
+func (r *StatReadAllResult) Status() int {
+	return r.Rc
+}
+
+// Run lists the device's stat groups and reads each one.  A group that can't
+// be read is recorded with its error and does not stop the others from being
+// read.
+func (c *StatReadAllCmd) Run(s sesn.Sesn) (Result, error) {
+	lr := nmp.NewStatListReq()
+
+	rsp, err := txReq(s, lr.Msg(), &c.CmdBase)
+	if err != nil {
+		return nil, err
+	}
+	lrsp := rsp.(*nmp.StatListRsp)
+
+	res := newStatReadAllResult()
+	if lrsp.Rc != 0 {
+		res.Rc = lrsp.Rc
+		return res, nil
+	}
+
+	for _, name := range lrsp.List {
+		r := nmp.NewStatReadReq()
+		r.Name = name
+
+		rsp, err := txReq(s, r.Msg(), &c.CmdBase)
+		if err != nil {
+			res.Groups[name] = StatGroupSnapshot{Err: err.Error()}
+			continue
+		}
+		srsp := rsp.(*nmp.StatReadRsp)
+
+		if srsp.Rc != 0 {
+			res.Groups[name] = StatGroupSnapshot{Rc: srsp.Rc}
+		} else {
+			fields := srsp.Fields
+			if fields == nil {
+				fields = map[string]interface{}{}
+			}
+			res.Groups[name] = StatGroupSnapshot{Fields: fields}
+		}
+	}
+
+	return res, nil
+}
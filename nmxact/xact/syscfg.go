@@ -0,0 +1,170 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package xact
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
+)
+
+// Where firmware conventionally exposes the syscfg settings it was built
+// with: as read-only config variables named under a common prefix, or as the
+// fields of a stats group.  The stats form can only carry numeric settings.
+const SYSCFG_DFLT_PREFIX = "syscfg/"
+const SYSCFG_DFLT_STAT_GROUP = "syscfg"
+
+// Sources of a device's syscfg settings.
+const (
+	SYSCFG_SRC_CONFIG = "config"
+	SYSCFG_SRC_STAT   = "stat"
+)
+
+// Syscfg holds the build-time settings reported by a device, keyed by
+// setting name.  Source is empty if the device doesn't expose them.
+type Syscfg struct {
+	Source   string            `json:"source"`
+	Settings map[string]string `json:"settings"`
+}
+
+func syscfgAbsent(rc int) bool {
+	switch rc {
+	case nmp.NMP_ERR_ENOENT, nmp.NMP_ERR_EINVAL, nmp.NMP_ERR_ENOTSUP:
+		return true
+	default:
+		return false
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// $read                                                                    //
+//////////////////////////////////////////////////////////////////////////////
+
+// SyscfgReadCmd reads a device's syscfg settings.  Config variables under
+// Prefix are tried first; if there are none, the StatGroup stats group is
+// read instead.  If the device cannot list its config variables, only the
+// settings in Names are read.
+type SyscfgReadCmd struct {
+	CmdBase
+	Prefix    string
+	StatGroup string
+	Names     []string
+}
+
+func NewSyscfgReadCmd() *SyscfgReadCmd {
+	return &SyscfgReadCmd{
+		CmdBase:   NewCmdBase(),
+		Prefix:    SYSCFG_DFLT_PREFIX,
+		StatGroup: SYSCFG_DFLT_STAT_GROUP,
+	}
+}
+
+type SyscfgReadResult struct {
+	Rc     int
+	Syscfg Syscfg
+}
+
+func newSyscfgReadResult() *SyscfgReadResult {
+	return &SyscfgReadResult{
+		Syscfg: Syscfg{
+			Settings: map[string]string{},
+		},
+	}
+}
+
+func (r *SyscfgReadResult) Status() int {
+	return r.Rc
+}
+
+func (c *SyscfgReadCmd) readConfig(s sesn.Sesn, res *SyscfgReadResult) error {
+	dc := NewConfigDumpCmd()
+	dc.SetTxOptions(c.TxOptions())
+	for _, name := range c.Names {
+		dc.Names = append(dc.Names, c.Prefix+name)
+	}
+
+	r, err := dc.Run(s)
+	if err != nil {
+		return err
+	}
+	dres := r.(*ConfigDumpResult)
+	if dres.Rc != 0 {
+		if !syscfgAbsent(dres.Rc) {
+			res.Rc = dres.Rc
+		}
+		return nil
+	}
+
+	for k, v := range dres.Vals {
+		if strings.HasPrefix(k, c.Prefix) {
+			res.Syscfg.Settings[strings.TrimPrefix(k, c.Prefix)] = v
+		}
+	}
+	if len(res.Syscfg.Settings) > 0 {
+		res.Syscfg.Source = SYSCFG_SRC_CONFIG
+	}
+
+	return nil
+}
+
+func (c *SyscfgReadCmd) readStat(s sesn.Sesn, res *SyscfgReadResult) error {
+	r := nmp.NewStatReadReq()
+	r.Name = c.StatGroup
+
+	rsp, err := txReq(s, r.Msg(), &c.CmdBase)
+	if err != nil {
+		return err
+	}
+	srsp := rsp.(*nmp.StatReadRsp)
+	if srsp.Rc != 0 {
+		if !syscfgAbsent(srsp.Rc) {
+			res.Rc = srsp.Rc
+		}
+		return nil
+	}
+
+	for k, v := range srsp.Fields {
+		res.Syscfg.Settings[k] = fmt.Sprintf("%v", v)
+	}
+	if len(res.Syscfg.Settings) > 0 {
+		res.Syscfg.Source = SYSCFG_SRC_STAT
+	}
+
+	return nil
+}
+
+func (c *SyscfgReadCmd) Run(s sesn.Sesn) (Result, error) {
+	res := newSyscfgReadResult()
+
+	if err := c.readConfig(s, res); err != nil {
+		return nil, err
+	}
+	if res.Rc != 0 || res.Syscfg.Source != "" || c.StatGroup == "" {
+		return res, nil
+	}
+
+	if err := c.readStat(s, res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
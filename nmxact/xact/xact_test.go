@@ -0,0 +1,141 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package xact
+
+import (
+	"bytes"
+	"encoding/hex"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmp"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/recording"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
+)
+
+// Offset of the sequence number in an NMP header.
+const goldenSeqOff = 6
+
+// newRecordingSesn returns an open NMP session that records each request it
+// is asked to send.
+func newRecordingSesn(t *testing.T) (*recording.RecordingXport, sesn.Sesn) {
+	rx := recording.NewRecordingXport()
+
+	s, err := rx.BuildSesn(sesn.NewSesnCfg())
+	if err != nil {
+		t.Fatalf("failed to build session: %s", err.Error())
+	}
+	if err := s.Open(); err != nil {
+		t.Fatalf("failed to open session: %s", err.Error())
+	}
+
+	return rx, s
+}
+
+// onlyFrame returns the single frame recorded by rx.
+func onlyFrame(t *testing.T, rx *recording.RecordingXport) []byte {
+	frames := rx.Frames()
+	if len(frames) != 1 {
+		t.Fatalf("wrong number of frames recorded; have=%d want=1",
+			len(frames))
+	}
+
+	return frames[0]
+}
+
+// checkGoldenFrame compares an encoded request against its expected bytes,
+// given as hex.  Sequence numbers come from a global counter, so the
+// header's sequence number is not compared.
+func checkGoldenFrame(t *testing.T, frame []byte, golden string) {
+	exp, err := hex.DecodeString(golden)
+	if err != nil {
+		t.Fatalf("bad golden frame: %s", err.Error())
+	}
+	if len(frame) < nmp.NMP_HDR_SIZE || len(frame) != len(exp) {
+		t.Fatalf("wrong frame;\nhave=%x\nwant=%x", frame, exp)
+	}
+
+	exp[goldenSeqOff] = frame[goldenSeqOff]
+	if !bytes.Equal(frame, exp) {
+		t.Fatalf("wrong frame;\nhave=%x\nwant=%x", frame, exp)
+	}
+}
+
+// fakeSesn is an open NMP session whose responses come from rspFn.  Every
+// request it is asked to send is recorded in reqs.
+type fakeSesn struct {
+	sesn.Sesn
+
+	rspFn func(m *nmp.NmpMsg) (nmp.NmpRsp, error)
+
+	mtx  sync.Mutex
+	reqs []*nmp.NmpMsg
+}
+
+func newFakeSesn(t *testing.T,
+	rspFn func(m *nmp.NmpMsg) (nmp.NmpRsp, error)) *fakeSesn {
+
+	_, s := newRecordingSesn(t)
+	return &fakeSesn{
+		Sesn:  s,
+		rspFn: rspFn,
+	}
+}
+
+func (s *fakeSesn) TxRxMgmt(m *nmp.NmpMsg,
+	timeout time.Duration) (nmp.NmpRsp, error) {
+
+	s.mtx.Lock()
+	s.reqs = append(s.reqs, m)
+	s.mtx.Unlock()
+
+	return s.rspFn(m)
+}
+
+func (s *fakeSesn) TxRxMgmtAsync(m *nmp.NmpMsg, timeout time.Duration,
+	ch chan nmp.NmpRsp, errc chan error) error {
+
+	// Like a real device, handle requests in the order they are sent.
+	rsp, err := s.TxRxMgmt(m, timeout)
+	go func() {
+		if err != nil {
+			errc <- err
+		} else {
+			ch <- rsp
+		}
+	}()
+	return nil
+}
+
+// Requests returns the requests sent so far.
+func (s *fakeSesn) Requests() []*nmp.NmpMsg {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return append([]*nmp.NmpMsg(nil), s.reqs...)
+}
+
+// fakeRspHdr returns the header of a response to m.
+func fakeRspHdr(m *nmp.NmpMsg) *nmp.NmpHdr {
+	hdr := m.Hdr
+	hdr.Op++
+	return &hdr
+}
@@ -0,0 +1,111 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package xport
+
+import (
+	"errors"
+	"syscall"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmxutil"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
+)
+
+// RetryCfg controls how StartWithRetry and BuildSesnWithRetry retry a
+// transport operation that fails.  This is independent of TxOptions.Tries,
+// which retries requests within an open session.
+type RetryCfg struct {
+	// Total number of attempts.  Values below 2 disable retries.
+	Tries int
+
+	Backoff sesn.BackoffCfg
+
+	// Reports whether an error is worth retrying.  nil selects
+	// IsTransientErr.
+	IsTransient func(err error) bool
+}
+
+// System errors that typically clear up on their own: a device held
+// momentarily by another process, or a daemon that isn't listening yet.
+var transientErrnos = []syscall.Errno{
+	syscall.EAGAIN,
+	syscall.EBUSY,
+	syscall.EINTR,
+	syscall.ECONNREFUSED,
+	syscall.ETIMEDOUT,
+}
+
+// IsTransientErr indicates whether a transport error is likely to go away if
+// the operation is repeated.  Errors such as a missing device are permanent.
+func IsTransientErr(err error) bool {
+	if nmxutil.IsRspTimeout(err) {
+		return true
+	}
+
+	var errno syscall.Errno
+	if !errors.As(err, &errno) {
+		return false
+	}
+	for _, e := range transientErrnos {
+		if errno == e {
+			return true
+		}
+	}
+	return false
+}
+
+func retry(cfg RetryCfg, desc string, fn func() error) error {
+	isTransient := cfg.IsTransient
+	if isTransient == nil {
+		isTransient = IsTransientErr
+	}
+
+	for i := 0; ; i++ {
+		err := fn()
+		if err == nil || i+1 >= cfg.Tries || !isTransient(err) {
+			return err
+		}
+
+		log.Debugf("%s failed (attempt %d of %d); retrying: %s",
+			desc, i+1, cfg.Tries, err.Error())
+		cfg.Backoff.Wait(i)
+	}
+}
+
+// StartWithRetry starts a transport, retrying transient failures as
+// specified by cfg.
+func StartWithRetry(x Xport, cfg RetryCfg) error {
+	return retry(cfg, "Transport start", x.Start)
+}
+
+// BuildSesnWithRetry builds a session, retrying transient failures as
+// specified by cfg.
+func BuildSesnWithRetry(x Xport, sc sesn.SesnCfg,
+	cfg RetryCfg) (sesn.Sesn, error) {
+
+	var s sesn.Sesn
+	err := retry(cfg, "Session build", func() error {
+		var err error
+		s, err = x.BuildSesn(sc)
+		return err
+	})
+	return s, err
+}
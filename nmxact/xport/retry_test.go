@@ -0,0 +1,137 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package xport
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/nmxutil"
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
+)
+
+func TestIsTransientErr(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"busy", syscall.EBUSY, true},
+		{"again", syscall.EAGAIN, true},
+		{"refused", syscall.ECONNREFUSED, true},
+		{"wrapped busy",
+			&os.PathError{Op: "open", Path: "/dev/ttyUSB0", Err: syscall.EBUSY},
+			true},
+		{"fmt wrapped", fmt.Errorf("dial: %w", syscall.ECONNREFUSED), true},
+		{"rsp timeout", nmxutil.NewRspTimeoutError("timeout"), true},
+		{"no such device", syscall.ENOENT, false},
+		{"wrapped no such device",
+			&os.PathError{Op: "open", Path: "/dev/ttyUSB0", Err: syscall.ENOENT},
+			false},
+		{"plain", errors.New("bad config"), false},
+	}
+
+	for _, tt := range tests {
+		if got := IsTransientErr(tt.err); got != tt.want {
+			t.Errorf("%s: IsTransientErr(%v) = %v, want %v",
+				tt.name, tt.err, got, tt.want)
+		}
+	}
+}
+
+// A transport whose Start and BuildSesn fail with errs, in order, before
+// succeeding.
+type flakyXport struct {
+	Xport
+	errs  []error
+	calls int
+}
+
+func (x *flakyXport) next() error {
+	x.calls++
+	if x.calls <= len(x.errs) {
+		return x.errs[x.calls-1]
+	}
+	return nil
+}
+
+func (x *flakyXport) Start() error {
+	return x.next()
+}
+
+func (x *flakyXport) BuildSesn(cfg sesn.SesnCfg) (sesn.Sesn, error) {
+	if err := x.next(); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func TestRetry(t *testing.T) {
+	busy := syscall.EBUSY
+	missing := syscall.ENOENT
+
+	tests := []struct {
+		name      string
+		tries     int
+		errs      []error
+		wantCalls int
+		wantErr   error
+	}{
+		{"success", 3, nil, 1, nil},
+		{"single try", 1, []error{busy}, 1, busy},
+		{"recovers", 3, []error{busy, busy}, 3, nil},
+		{"gives up", 3, []error{busy, busy, busy, busy}, 3, busy},
+		{"permanent", 3, []error{missing}, 1, missing},
+		{"turns permanent", 3, []error{busy, missing}, 2, missing},
+	}
+
+	for _, tt := range tests {
+		cfg := RetryCfg{Tries: tt.tries}
+
+		x := &flakyXport{errs: tt.errs}
+		err := StartWithRetry(x, cfg)
+		if err != tt.wantErr || x.calls != tt.wantCalls {
+			t.Errorf("%s: start: err=%v calls=%d, want err=%v calls=%d",
+				tt.name, err, x.calls, tt.wantErr, tt.wantCalls)
+		}
+
+		x = &flakyXport{errs: tt.errs}
+		_, err = BuildSesnWithRetry(x, sesn.NewSesnCfg(), cfg)
+		if err != tt.wantErr || x.calls != tt.wantCalls {
+			t.Errorf("%s: build: err=%v calls=%d, want err=%v calls=%d",
+				tt.name, err, x.calls, tt.wantErr, tt.wantCalls)
+		}
+	}
+}
+
+func TestRetryIsTransient(t *testing.T) {
+	// A custom classifier overrides the default one.
+	x := &flakyXport{errs: []error{syscall.ENOENT, syscall.ENOENT}}
+	err := StartWithRetry(x, RetryCfg{
+		Tries:       3,
+		IsTransient: func(err error) bool { return err == syscall.ENOENT },
+	})
+	if err != nil || x.calls != 3 {
+		t.Errorf("err=%v calls=%d, want success after 3 calls", err, x.calls)
+	}
+}
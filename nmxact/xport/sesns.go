@@ -0,0 +1,119 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package xport
+
+import (
+	"sync"
+	"time"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
+)
+
+// SesnInfo describes a live session built by a transport.
+type SesnInfo struct {
+	Peer    string           `json:"peer"`
+	Tag     string           `json:"tag,omitempty"`
+	Xport   string           `json:"xport"`
+	OpenFor time.Duration    `json:"open_for"` // Zero if unknown.
+	MtuOut  int              `json:"mtu_out"`
+	Timings sesn.ConnTimings `json:"timings"`
+	sesnRef sesn.Sesn
+}
+
+// Sesn returns the session the info describes.
+func (si SesnInfo) Sesn() sesn.Sesn {
+	return si.sesnRef
+}
+
+type sesnEntry struct {
+	s    sesn.Sesn
+	peer string
+}
+
+// SesnList tracks the open sessions a transport has built so they can be
+// listed.  Sessions add themselves once they open; a session that has closed
+// is forgotten, and one that is never opened is never recorded.  SesnList is
+// safe for concurrent use; its zero value is ready to use.  Sessions must not
+// hold their own lock when calling Add, since pruning calls IsOpen.
+type SesnList struct {
+	mtx     sync.Mutex
+	entries []sesnEntry
+}
+
+// prune removes sessions that have closed.  The caller must hold the lock.
+func (sl *SesnList) prune() {
+	live := sl.entries[:0]
+	for _, e := range sl.entries {
+		if e.s.IsOpen() {
+			live = append(live, e)
+		}
+	}
+	for i := len(live); i < len(sl.entries); i++ {
+		sl.entries[i] = sesnEntry{}
+	}
+	sl.entries = live
+}
+
+// Add records a session that has just opened.  Adding a session that is
+// already listed has no effect.
+func (sl *SesnList) Add(s sesn.Sesn, peer string) {
+	sl.mtx.Lock()
+	defer sl.mtx.Unlock()
+
+	sl.prune()
+	for _, e := range sl.entries {
+		if e.s == s {
+			return
+		}
+	}
+	sl.entries = append(sl.entries, sesnEntry{s, peer})
+}
+
+// Infos describes each open session, oldest first.  xportType is reported
+// in each entry's Xport field.
+func (sl *SesnList) Infos(xportType string) []SesnInfo {
+	sl.mtx.Lock()
+	defer sl.mtx.Unlock()
+
+	sl.prune()
+
+	var infos []SesnInfo
+	for _, e := range sl.entries {
+		if !e.s.IsOpen() {
+			continue
+		}
+
+		t := sesn.SesnConnectTimings(e.s)
+		info := SesnInfo{
+			Peer:    e.peer,
+			Tag:     sesn.SesnTag(e.s),
+			Xport:   xportType,
+			MtuOut:  e.s.MtuOut(),
+			Timings: t,
+			sesnRef: e.s,
+		}
+		if !t.Started.IsZero() {
+			info.OpenFor = time.Since(t.Started)
+		}
+		infos = append(infos, info)
+	}
+
+	return infos
+}
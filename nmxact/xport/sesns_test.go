@@ -0,0 +1,153 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package xport
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/comap-smart-home/mynewt-newtmgr/nmxact/sesn"
+)
+
+// A session whose open state is controlled by the test.
+type listSesn struct {
+	sesn.Sesn
+
+	mtu     int
+	started time.Time
+
+	mtx  sync.Mutex
+	open bool
+}
+
+func (s *listSesn) IsOpen() bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.open
+}
+
+func (s *listSesn) setOpen(open bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.open = open
+}
+
+func (s *listSesn) MtuOut() int {
+	return s.mtu
+}
+
+// A session that also reports its connect timings.
+type timedListSesn struct {
+	listSesn
+}
+
+func (s *timedListSesn) ConnectTimings() sesn.ConnTimings {
+	return sesn.ConnTimings{Started: s.started}
+}
+
+func TestSesnList(t *testing.T) {
+	a := &listSesn{mtu: 100, open: true}
+	b := &timedListSesn{listSesn{
+		mtu:     200,
+		started: time.Now().Add(-time.Minute),
+		open:    true,
+	}}
+	c := &listSesn{mtu: 300, open: true}
+
+	var sl SesnList
+
+	tests := []struct {
+		name  string
+		do    func()
+		peers []string
+	}{
+		{"empty", func() {}, nil},
+		{"add", func() { sl.Add(a, "a") }, []string{"a"}},
+		{"add second", func() { sl.Add(b, "b") }, []string{"a", "b"}},
+		{"duplicate", func() { sl.Add(a, "a") }, []string{"a", "b"}},
+		{"close", func() { a.setOpen(false) }, []string{"b"}},
+		{"add third", func() { sl.Add(c, "c") }, []string{"b", "c"}},
+		{"reopen", func() {
+			a.setOpen(true)
+			sl.Add(a, "a")
+		}, []string{"b", "c", "a"}},
+		{"close all", func() {
+			a.setOpen(false)
+			b.setOpen(false)
+			c.setOpen(false)
+		}, nil},
+	}
+
+	for _, tt := range tests {
+		tt.do()
+
+		infos := sl.Infos("test")
+		var peers []string
+		for _, info := range infos {
+			peers = append(peers, info.Peer)
+			if info.Xport != "test" {
+				t.Errorf("%s: %s: xport = %q", tt.name, info.Peer, info.Xport)
+			}
+		}
+
+		if len(peers) != len(tt.peers) {
+			t.Fatalf("%s: peers = %v, want %v", tt.name, peers, tt.peers)
+		}
+		for i := range peers {
+			if peers[i] != tt.peers[i] {
+				t.Fatalf("%s: peers = %v, want %v", tt.name, peers, tt.peers)
+			}
+		}
+	}
+}
+
+func TestSesnListInfo(t *testing.T) {
+	a := &listSesn{mtu: 100, open: true}
+	b := &timedListSesn{listSesn{
+		mtu:     200,
+		started: time.Now().Add(-time.Minute),
+		open:    true,
+	}}
+
+	var sl SesnList
+	sl.Add(a, "a")
+	sl.Add(b, "b")
+
+	infos := sl.Infos("test")
+	if len(infos) != 2 {
+		t.Fatalf("got %d infos, want 2", len(infos))
+	}
+
+	// Without connect timings, the open duration is unknown.
+	if infos[0].MtuOut != 100 || infos[0].OpenFor != 0 ||
+		infos[0].Sesn() != a {
+
+		t.Errorf("untimed session info = %+v", infos[0])
+	}
+
+	if infos[1].MtuOut != 200 || infos[1].OpenFor < time.Minute ||
+		infos[1].Sesn() != b {
+
+		t.Errorf("timed session info = %+v", infos[1])
+	}
+}
@@ -25,6 +25,11 @@ import (
 
 type RxFn func(data []byte)
 
+// Xport is a transport to one or more devices.  Transports keep no
+// package-level state, so any number of them, of the same or different
+// types, can be started and used concurrently within one process; each
+// builds and tracks its own sessions.  The NMP and CoAP sequence counters
+// are shared by all transports, but are guarded and only need to be unique.
 type Xport interface {
 	Start() error
 	Stop() error